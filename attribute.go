@@ -0,0 +1,107 @@
+package gitignore
+
+import (
+	"strings"
+)
+
+// AttributeState describes the state of a git attribute for a path.
+type AttributeState int
+
+const (
+	// AttributeUnspecified indicates no pattern assigns the attribute to the
+	// path, or the attribute has been reset with "!attr".
+	AttributeUnspecified AttributeState = iota
+
+	// AttributeSet indicates the attribute is set for the path (i.e. "attr").
+	AttributeSet
+
+	// AttributeUnset indicates the attribute is unset for the path (i.e.
+	// "-attr").
+	AttributeUnset
+
+	// AttributeValue indicates the attribute has been assigned a value for
+	// the path (i.e. "attr=value").
+	AttributeValue
+)
+
+// define the built-in attribute macros
+var _MACROS = map[string]string{
+	"binary": "-diff -merge -text",
+}
+
+// Attribute represents the state of a git attribute for a path.
+type Attribute struct {
+	Name  string
+	State AttributeState
+	Value string
+} // Attribute{}
+
+// String returns the value of the attribute in the form reported by
+// git check-attr (i.e. "set", "unset", "unspecified", or the attribute
+// value).
+func (a Attribute) String() string {
+	switch a.State {
+	case AttributeSet:
+		return "set"
+	case AttributeUnset:
+		return "unset"
+	case AttributeValue:
+		return a.Value
+	}
+	return "unspecified"
+} // String()
+
+// IsSet returns true if the attribute is set for the path.
+func (a Attribute) IsSet() bool { return a.State == AttributeSet }
+
+// IsUnset returns true if the attribute is unset for the path.
+func (a Attribute) IsUnset() bool { return a.State == AttributeUnset }
+
+// IsSpecified returns true if the attribute is set, unset or has a value
+// for the path.
+func (a Attribute) IsSpecified() bool { return a.State != AttributeUnspecified }
+
+// attribute parses the attribute assignment s (i.e. "attr", "-attr", "!attr"
+// or "attr=value"), returning false if s does not name a valid attribute.
+func attribute(s string) (Attribute, bool) {
+	_attribute := Attribute{State: AttributeSet}
+	switch {
+	case strings.HasPrefix(s, "-"):
+		_attribute.State = AttributeUnset
+		s = s[1:]
+	case strings.HasPrefix(s, "!"):
+		_attribute.State = AttributeUnspecified
+		s = s[1:]
+	default:
+		_equals := strings.Index(s, "=")
+		if _equals != -1 {
+			_attribute.State = AttributeValue
+			_attribute.Value = s[_equals+1:]
+			s = s[:_equals]
+		}
+	}
+
+	// ensure we have a valid attribute name
+	_attribute.Name = s
+	return _attribute, attributename(s)
+} // attribute()
+
+// attributename returns true if name is a valid attribute name, consisting of
+// letters, digits, '-', '_' and '.', and not starting with '-'.
+func attributename(name string) bool {
+	if name == "" || name[0] == '-' {
+		return false
+	}
+	for _, _r := range name {
+		switch {
+		case _r >= 'a' && _r <= 'z':
+		case _r >= 'A' && _r <= 'Z':
+		case _r >= '0' && _r <= '9':
+		case _r == '-' || _r == '_' || _r == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+} // attributename()
@@ -0,0 +1,528 @@
+package gitignore
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// define the name of the per-directory attributes files, and the prefix of
+// attribute macro definitions
+const (
+	AttributesFile = ".gitattributes"
+	_MACROPREFIX   = "[attr]"
+)
+
+// Attributes is the interface to the git attributes of a repository,
+// determined by the .gitattributes files within the repository, together with
+// $GIT_DIR/info/attributes, the global attributes file and the system
+// attributes file.
+type Attributes interface {
+	// Base returns the root directory of the repository.
+	Base() string
+
+	// Relative returns the attributes specified for the path relative to the
+	// repository base directory, sorted by name. isdir is used to indicate
+	// whether the path represents a file or a directory. Unspecified
+	// attributes are omitted.
+	Relative(path string, isdir bool) []Attribute
+
+	// Attribute returns the named attribute for the path relative to the
+	// repository base directory. isdir is used to indicate whether the path
+	// represents a file or a directory.
+	Attribute(path string, isdir bool, name string) Attribute
+} // Attributes{}
+
+// attributes is the implementation of the git attributes of a repository
+type attributes struct {
+	_base   string
+	_info   *attributefile
+	_global *attributefile
+	_system *attributefile
+	_macros map[string][]Attribute
+	_errors func(Error) bool
+
+	// the per-directory attributes files within the repository
+	_files map[string]*attributefile
+	_lock  sync.Mutex
+} // attributes{}
+
+// attributefile represents the assignments of a single attributes file
+type attributefile struct {
+	_lines []*assignment
+} // attributefile{}
+
+// assignment represents a single line of an attributes file, assigning
+// attributes to the paths matching a pattern, or defining a macro
+type assignment struct {
+	_pattern    Pattern
+	_macro      string
+	_attributes []Attribute
+} // assignment{}
+
+// frame represents an attributes file to consider for a path, with the path
+// expressed relative to the directory of the attributes file
+type frame struct {
+	_file *attributefile
+	_path string
+} // frame{}
+
+// NewAttributes returns the Attributes of the git repository with root
+// directory base. If base is not a directory, or the attributes files of the
+// repository cannot be read, NewAttributes will return an error.
+//
+// Internally, NewAttributes uses NewAttributesWithErrors.
+func NewAttributes(base string) (Attributes, error) {
+	return NewAttributesWithErrors(base, nil)
+} // NewAttributes()
+
+// NewAttributesWithErrors returns the Attributes of the git repository with
+// root directory base. Attributes are taken from, in decreasing order of
+// precedence:
+//
+//   - $GIT_DIR/info/attributes
+//   - the .gitattributes file in the directory of the path, followed by
+//     the .gitattributes files of its parent directories, up to the root
+//     of the repository
+//   - the global attributes file, given by the core.attributesFile git
+//     configuration variable, or $XDG_CONFIG_HOME/git/attributes (or
+//     $HOME/.config/git/attributes if $XDG_CONFIG_HOME is not set)
+//   - the system attributes file /etc/gitattributes, unless
+//     $GIT_ATTR_NOSYSTEM is set
+//
+// Within a file, later lines take precedence over earlier lines. Attribute
+// macros (i.e. "[attr]name ...") may be defined in any of these files except
+// .gitattributes files below the root of the repository, and are expanded
+// when the macro attribute is set for a path. The built-in "binary" macro
+// is always defined.
+//
+// If errors is given, it will be invoked for every error encountered when
+// parsing the attributes files. An error is returned if base is not a
+// directory, or if $GIT_DIR/info/attributes, the global attributes file or
+// the system attributes file cannot be read.
+//
+// The GIT_DIR of the repository is located using the environment variables
+// of the current process. Internally, NewAttributesWithErrors uses
+// NewAttributesWithEnvironment.
+func NewAttributesWithErrors(base string, errors func(Error) bool) (Attributes, error) {
+	return NewAttributesWithEnvironment(base, nil, errors)
+} // NewAttributesWithErrors()
+
+// NewAttributesWithEnvironment returns the Attributes of the git repository
+// with root directory base, as with NewAttributesWithErrors, where the GIT_DIR
+// of the repository, and its common directory, are located using environment
+// rather than the environment variables of the current process. If
+// environment is nil, the environment variables of the current process are
+// used (see NewEnvironment).
+func NewAttributesWithEnvironment(base string, environment *Environment, errors func(Error) bool) (Attributes, error) {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	// ensure the given base is a directory
+	_base, _err := filepath.Abs(base)
+	if _err != nil {
+		return nil, _err
+	}
+	_info, _err := os.Stat(_base)
+	if _err != nil {
+		return nil, _err
+	} else if !_info.IsDir() {
+		return nil, InvalidDirectoryError
+	}
+
+	// locate the GIT_DIR for this repository
+	//		- relative paths of the environment are interpreted relative
+	//		  to the base directory
+	_options := Options{Environment: environment}
+	_environment := _options.environment().resolve(_base)
+	_gitdir, _err := gitdir(_base, _environment)
+	if _err != nil {
+		return nil, _err
+	}
+	_common, _err := _environment.common(_gitdir)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// load the repository-wide attributes files
+	_attributes := &attributes{_base: _base, _errors: _errors}
+	if _common != "" {
+		_file := filepath.Join(_common, "info", "attributes")
+		_attributes._info, _err = readAttributes(_file, true, _errors)
+		if _err != nil {
+			return nil, _err
+		}
+	}
+	_attributes._global, _err = _attributes.global(_common)
+	if _err != nil {
+		return nil, _err
+	}
+	if !boolean(os.Getenv("GIT_ATTR_NOSYSTEM")) {
+		_file := filepath.Join(string(filepath.Separator), "etc", "gitattributes")
+		_attributes._system, _err = readAttributes(_file, true, _errors)
+		if _err != nil {
+			return nil, _err
+		}
+	}
+
+	// collect the attribute macros
+	_attributes.macros()
+	return _attributes, nil
+} // NewAttributesWithEnvironment()
+
+// Base returns the root directory of the repository.
+func (a *attributes) Base() string {
+	return a._base
+} // Base()
+
+// Relative returns the attributes specified for the path relative to the
+// repository base directory, sorted by name. Unspecified attributes are
+// omitted.
+func (a *attributes) Relative(path string, isdir bool) []Attribute {
+	_values := a.resolve(path, isdir)
+
+	// return the specified attributes in name order
+	_attributes := make([]Attribute, 0, len(_values))
+	for _, _attribute := range _values {
+		if _attribute.IsSpecified() {
+			_attributes = append(_attributes, _attribute)
+		}
+	}
+	sort.Slice(_attributes, func(i, j int) bool {
+		return _attributes[i].Name < _attributes[j].Name
+	})
+
+	return _attributes
+} // Relative()
+
+// Attribute returns the named attribute for the path relative to the
+// repository base directory.
+func (a *attributes) Attribute(path string, isdir bool, name string) Attribute {
+	_attribute, _ok := a.resolve(path, isdir)[name]
+	if !_ok {
+		return Attribute{Name: name}
+	}
+
+	return _attribute
+} // Attribute()
+
+// resolve returns the attributes assigned to the path relative to the
+// repository base directory, keyed by name. For each attribute, the first
+// assignment encountered in order of decreasing precedence is used.
+func (a *attributes) resolve(path string, isdir bool) map[string]Attribute {
+	_values := make(map[string]Attribute)
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return _values
+	}
+
+	// consider each attributes file in order of precedence, and each line
+	// of the file in reverse order
+	for _, _frame := range a.stack(_path) {
+		if _frame._file == nil {
+			continue
+		}
+		_lines := _frame._file._lines
+		for _i := len(_lines) - 1; _i >= 0; _i-- {
+			_line := _lines[_i]
+			if _line._pattern == nil {
+				continue
+			} else if _line._pattern.Match(_frame._path, isdir) {
+				a.fill(_values, _line._attributes)
+			}
+		}
+	}
+
+	return _values
+} // resolve()
+
+// fill records the attributes that have not already been assigned, in
+// reverse order, expanding attribute macros that are set.
+func (a *attributes) fill(values map[string]Attribute, attributes []Attribute) {
+	for _i := len(attributes) - 1; _i >= 0; _i-- {
+		_attribute := attributes[_i]
+		if _, _ok := values[_attribute.Name]; _ok {
+			continue
+		}
+		values[_attribute.Name] = _attribute
+
+		// expand any macro
+		if _attribute.State == AttributeSet {
+			_macro, _ok := a._macros[_attribute.Name]
+			if _ok {
+				a.fill(values, _macro)
+			}
+		}
+	}
+} // fill()
+
+// stack returns the attributes files to consider for path, in order of
+// decreasing precedence.
+func (a *attributes) stack(path string) []frame {
+	_stack := []frame{{a._info, path}}
+
+	// consider the directories containing the path, deepest first
+	_parts := strings.Split(path, string(_SEPARATOR))
+	for _i := len(_parts) - 1; _i >= 0; _i-- {
+		_dir := strings.Join(_parts[:_i], string(_SEPARATOR))
+		_rel := strings.Join(_parts[_i:], string(_SEPARATOR))
+		_stack = append(_stack, frame{a.file(_dir), _rel})
+	}
+
+	return append(_stack, frame{a._global, path}, frame{a._system, path})
+} // stack()
+
+// file returns the attributes file for the directory dir relative to the
+// repository base, or nil if there is no attributes file in dir.
+func (a *attributes) file(dir string) *attributefile {
+	a._lock.Lock()
+	defer a._lock.Unlock()
+
+	// have we seen this directory before?
+	if _file, _ok := a._files[dir]; _ok {
+		return _file
+	}
+
+	// attempt to load the attributes file
+	//		- only the top-level file may define macros
+	_name := filepath.Join(a._base, filepath.FromSlash(dir), AttributesFile)
+	_file, _err := readAttributes(_name, dir == "", a._errors)
+	if _err != nil {
+		a._errors(NewError(_err, Position{}))
+	}
+
+	// record this file for subsequent lookups
+	if a._files == nil {
+		a._files = make(map[string]*attributefile)
+	}
+	a._files[dir] = _file
+
+	return _file
+} // file()
+
+// macros collects the attribute macros defined by the repository-wide
+// attributes files and the top-level .gitattributes file. Where a macro is
+// defined more than once, the definition with the highest precedence is used.
+func (a *attributes) macros() {
+	a._macros = make(map[string][]Attribute)
+	_files := []*attributefile{a._info, a.file(""), a._global, a._system}
+	for _, _file := range _files {
+		if _file == nil {
+			continue
+		}
+		for _i := len(_file._lines) - 1; _i >= 0; _i-- {
+			_line := _file._lines[_i]
+			if _line._macro == "" {
+				continue
+			} else if _, _ok := a._macros[_line._macro]; !_ok {
+				a._macros[_line._macro] = _line._attributes
+			}
+		}
+	}
+
+	// finally, add the built-in macros
+	for _name, _definition := range _MACROS {
+		if _, _ok := a._macros[_name]; _ok {
+			continue
+		}
+		_attributes := make([]Attribute, 0)
+		for _, _field := range strings.Fields(_definition) {
+			_attribute, _ := attribute(_field)
+			_attributes = append(_attributes, _attribute)
+		}
+		a._macros[_name] = _attributes
+	}
+} // macros()
+
+// global returns the global attributes file, given by the
+// core.attributesFile configuration variable, and defaulting to
+// $XDG_CONFIG_HOME/git/attributes.
+func (a *attributes) global(common string) (*attributefile, error) {
+	_config, _err := newConfig(common)
+	if _err != nil {
+		return nil, _err
+	}
+	_file, _ok := _config.get("core.attributesfile")
+	if _ok {
+		_file = expand(_file)
+		if !filepath.IsAbs(_file) {
+			_file = filepath.Join(a._base, _file)
+		}
+	} else {
+		_file = xdg("attributes")
+	}
+	if _file == "" {
+		return nil, nil
+	}
+
+	return readAttributes(_file, true, a._errors)
+} // global()
+
+// readAttributes returns the attributefile for file. If macros is false,
+// macro definitions in file are reported as errors and ignored. If file does
+// not exist, or is a directory, readAttributes returns nil.
+func readAttributes(file string, macros bool, errors func(Error) bool) (*attributefile, error) {
+	_fh, _err := os.Open(file)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return nil, nil
+		}
+		return nil, _err
+	}
+	defer _fh.Close()
+	_info, _err := _fh.Stat()
+	if _err != nil {
+		return nil, _err
+	} else if _info.IsDir() {
+		return nil, nil
+	}
+
+	return parseAttributes(_fh, file, macros, errors)
+} // readAttributes()
+
+// parseAttributes parses the attributes read from r, with positions reported
+// against file. If macros is false, macro definitions are reported as errors
+// and ignored. Lines with invalid patterns or attributes are reported as
+// errors and ignored.
+func parseAttributes(r io.Reader, file string, macros bool, errors func(Error) bool) (*attributefile, error) {
+	_file := &attributefile{_lines: make([]*assignment, 0)}
+	_reader := bufio.NewReader(r)
+	_position := Position{File: file, Line: 0}
+	_next := 0
+	for {
+		_text, _err := _reader.ReadString('\n')
+		if _err != nil && _err != io.EOF {
+			return nil, _err
+		} else if _text == "" && _err == io.EOF {
+			return _file, nil
+		}
+
+		// track the position of the start of this line
+		_position.Line++
+		_position.Offset = _next
+		_next += len([]rune(_text))
+
+		// parse this line
+		_line := strings.TrimRight(_text, "\r\n")
+		_assignment, _error := assign(_line, _position, macros, errors)
+		if _error != nil {
+			if !errors(_error) {
+				return _file, nil
+			}
+		} else if _assignment != nil {
+			_file._lines = append(_file._lines, _assignment)
+		}
+
+		// are we at the end of the file?
+		if _err == io.EOF {
+			return _file, nil
+		}
+	}
+} // parseAttributes()
+
+// assign parses a single line of an attributes file, starting at position.
+// assign returns nil if the line is blank or a comment, and an Error if the
+// line is not a valid attribute assignment or macro definition.
+func assign(line string, position Position, macros bool, errors func(Error) bool) (*assignment, Error) {
+	// skip leading whitespace, blank lines and comments
+	_text := strings.TrimLeft(line, " \t")
+	_position := position
+	_position.Column = len([]rune(line)) - len([]rune(_text)) + 1
+	_position.Offset += _position.Column - 1
+	if _text == "" || strings.HasPrefix(_text, "#") {
+		return nil, nil
+	}
+
+	// do we have a macro definition?
+	_assignment := &assignment{}
+	var _rest string
+	if strings.HasPrefix(_text, _MACROPREFIX) {
+		if !macros {
+			return nil, NewError(MacroAttributeError, _position)
+		}
+		_text = strings.TrimPrefix(_text, _MACROPREFIX)
+		_end := strings.IndexAny(_text, " \t")
+		if _end == -1 {
+			_end = len(_text)
+		}
+		_assignment._macro, _rest = _text[:_end], _text[_end:]
+		if !attributename(_assignment._macro) {
+			return nil, NewError(InvalidAttributeError, _position)
+		}
+	} else {
+		// extract the pattern, which may be quoted
+		_pattern := ""
+		if strings.HasPrefix(_text, "\"") {
+			_end := quoted(_text)
+			if _end == -1 {
+				return nil, NewError(InvalidPatternError, _position)
+			}
+			_unquoted, _err := strconv.Unquote(_text[:_end+1])
+			if _err != nil {
+				return nil, NewError(InvalidPatternError, _position)
+			}
+			_pattern, _rest = _unquoted, _text[_end+1:]
+		} else {
+			_end := strings.IndexAny(_text, " \t")
+			if _end == -1 {
+				_end = len(_text)
+			}
+			_pattern, _rest = _text[:_end], _text[_end:]
+		}
+
+		// negated patterns are not permitted
+		if strings.HasPrefix(_pattern, string(_NEGATION)) {
+			return nil, NewError(NegativeAttributeError, _position)
+		}
+
+		// parse the pattern, reporting errors relative to this line
+		_lexer := &offset{Lexer: NewLexer(strings.NewReader(_pattern))}
+		_lexer._position = _position
+		_parser := &parser{_lexer: _lexer, _error: errors}
+		_assignment._pattern = _parser.Next()
+		if _assignment._pattern == nil {
+			return nil, nil
+		}
+	}
+
+	// extract the attribute assignments
+	_assignment._attributes = make([]Attribute, 0)
+	for _, _field := range strings.Fields(_rest) {
+		_attribute, _ok := attribute(_field)
+		if !_ok {
+			return nil, NewError(InvalidAttributeError, _position)
+		}
+		_assignment._attributes = append(_assignment._attributes, _attribute)
+	}
+
+	return _assignment, nil
+} // assign()
+
+// quoted returns the index of the closing quote of the quoted string at the
+// start of s, or -1 if the string is not terminated.
+func quoted(s string) int {
+	_escaped := false
+	for _i := 1; _i < len(s); _i++ {
+		switch {
+		case _escaped:
+			_escaped = false
+		case s[_i] == '\\':
+			_escaped = true
+		case s[_i] == '"':
+			return _i
+		}
+	}
+
+	return -1
+} // quoted()
+
+// ensure attributes satisfies the Attributes interface
+var _ Attributes = &attributes{}
@@ -0,0 +1,110 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestAttributes(t *testing.T) {
+	// create the repository with its attributes files
+	_dir, _err := dir(map[string]string{
+		".git/info/attributes":    _ATTRIBUTESINFO,
+		".gitattributes":          _ATTRIBUTESROOT,
+		"docs/.gitattributes":     _ATTRIBUTESDOCS,
+		"config/git/attributes":   _ATTRIBUTESGLOBAL,
+		"docs/api/.gitattributes": "",
+	})
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+		"GIT_ATTR_NOSYSTEM":   "1",
+		"GIT_DIR":             "",
+		"XDG_CONFIG_HOME":     filepath.Join(_dir, "config"),
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// record the errors encountered parsing the attributes files
+	_errors := make([]gitignore.Error, 0)
+	_handler := func(e gitignore.Error) bool {
+		_errors = append(_errors, e)
+		return true
+	}
+	_attributes, _err := gitignore.NewAttributesWithErrors(_dir, _handler)
+	if _err != nil {
+		t.Fatalf("unable to read attributes: %s", _err.Error())
+	} else if _attributes.Base() != _dir {
+		t.Errorf("attributes base mismatch; expected %q, got %q",
+			_dir, _attributes.Base(),
+		)
+	}
+
+	// ensure the attributes of each path are as expected
+	for _, _test := range _ATTRIBUTES {
+		_relative := _attributes.Relative(_test.Path, false)
+		_got := make([]string, 0, len(_relative))
+		for _, _attribute := range _relative {
+			_got = append(_got, _attribute.Name+"="+_attribute.String())
+		}
+		if len(_got) != len(_test.Attributes) {
+			t.Errorf("attributes mismatch for %q; expected %v, got %v",
+				_test.Path, _test.Attributes, _got,
+			)
+			continue
+		}
+		for _i := range _got {
+			if _got[_i] != _test.Attributes[_i] {
+				t.Errorf("attributes mismatch for %q; expected %v, got %v",
+					_test.Path, _test.Attributes, _got,
+				)
+				break
+			}
+		}
+	}
+
+	// ensure individual attributes may be retrieved
+	_text := _attributes.Attribute("README.md", false, "text")
+	if !_text.IsSet() {
+		t.Errorf("attribute mismatch for %q; expected text set, got %s",
+			"README.md", _text,
+		)
+	}
+	_text = _attributes.Attribute("docs/notes.txt", false, "text")
+	if _text.IsSpecified() {
+		t.Errorf("attribute mismatch for %q; expected text unspecified, got %s",
+			"docs/notes.txt", _text,
+		)
+	}
+
+	// ensure the invalid lines of the attributes files were reported
+	if len(_errors) != len(_ATTRIBUTESERRORS) {
+		t.Fatalf("attributes errors mismatch; expected %d, got %d",
+			len(_ATTRIBUTESERRORS), len(_errors),
+		)
+	}
+	for _i, _error := range _errors {
+		if _error.Underlying() != _ATTRIBUTESERRORS[_i] {
+			t.Errorf("attributes error mismatch; expected %q, got %q",
+				_ATTRIBUTESERRORS[_i], _error.Underlying(),
+			)
+		}
+	}
+	_position := _errors[len(_errors)-1].Position()
+	_file := filepath.Join(_dir, "docs", gitignore.AttributesFile)
+	if _position.File != _file || _position.Line != 2 {
+		t.Errorf("attributes error position mismatch; expected %s:2, got %s",
+			_file, _position,
+		)
+	}
+} // TestAttributes()
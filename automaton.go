@@ -0,0 +1,476 @@
+package gitignore
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// define the size of the cached DFA states (as the total number of NFA
+// states they represent) before the cache is flushed
+const _AUTOMATONCACHE = 1 << 21
+
+// automaton is the compiled form of the patterns of an ignore file, allowing
+// a path to be matched against every pattern in a single pass over the path.
+// The patterns are compiled into a nondeterministic finite automaton (NFA),
+// where each pattern has its own accepting state, and the deterministic
+// states (DFA) are constructed from the NFA as paths are matched. Patterns
+// that cannot be compiled are matched individually, as with the patterns of
+// an uncompiled ignore file.
+type automaton struct {
+	_patterns []Pattern
+	_fallback []int
+	_nfa      []*nfastate
+	_classes  [256]byte
+	_bytes    []byte
+	_lock     sync.RWMutex
+	_states   map[string]*dfastate
+	_size     int
+	_start    *dfastate
+	_seen     []uint32
+	_visit    uint32
+} // automaton{}
+
+// byteset is a set of bytes
+type byteset [4]uint64
+
+// nfaedge is a transition of the NFA that consumes a byte of the set
+type nfaedge struct {
+	_set  *byteset
+	_next int
+} // nfaedge{}
+
+// nfastate is a state of the NFA, accepting the pattern with index _accept
+// (or -1 for non-accepting states), for directories only if _dir is true
+type nfastate struct {
+	_edges   []nfaedge
+	_epsilon []int
+	_accept  int
+	_dir     bool
+} // nfastate{}
+
+// dfastate is a state of the DFA, given by a set of NFA states, recording the
+// highest index of the patterns accepted for files and for directories
+type dfastate struct {
+	_nfa  []int
+	_next []*dfastate
+	_file int
+	_dir  int
+} // dfastate{}
+
+// define the byte sets of wildmatch patterns (with WM_PATHNAME)
+var (
+	_ANYBYTE  = newbyteset(func(b byte) bool { return true })
+	_NOTSLASH = newbyteset(func(b byte) bool { return b != byte(_SEPARATOR) })
+	_SLASH    = newbyteset(func(b byte) bool { return b == byte(_SEPARATOR) })
+)
+
+// define the NFA states common to all automata: _ROOT is the initial state,
+// leading to anchored patterns, _COMPONENT is the start of each path
+// component, leading to unanchored patterns, and _SKIP consumes the path
+// components preceding unanchored patterns
+const (
+	_ROOT = iota
+	_COMPONENT
+	_SKIP
+)
+
+// newAutomaton returns the automaton for the patterns of an ignore file with
+// the given indices, in increasing order.
+func newAutomaton(patterns []Pattern, indices []int) *automaton {
+	_automaton := &automaton{_patterns: patterns}
+	for _i := _ROOT; _i <= _SKIP; _i++ {
+		_automaton.state()
+	}
+	_automaton.epsilon(_ROOT, _COMPONENT)
+	_automaton.epsilon(_COMPONENT, _SKIP)
+	_automaton.edge(_SKIP, _NOTSLASH, _SKIP)
+	_automaton.edge(_SKIP, _SLASH, _COMPONENT)
+
+	// compile each pattern, falling back to the Pattern for the patterns
+	// that cannot be compiled
+	for _, _i := range indices {
+		if !_automaton.add(_i, patterns[_i]) {
+			_automaton._fallback = append(_automaton._fallback, _i)
+		}
+	}
+
+	_automaton.partition()
+	_automaton.flush()
+	return _automaton
+} // newAutomaton()
+
+// match returns the index of the highest precedence pattern (i.e. the last
+// pattern) of the automaton matching the path, or floor if no pattern with
+// a higher index than floor matches the path. The path is assumed to be
+// relative to the base of the ignore file, using "/" separators.
+func (a *automaton) match(path string, isdir bool, floor int) int {
+	// follow the DFA for the path, constructing its missing states
+	a._lock.RLock()
+	_state := a._start
+	for _i := 0; _i < len(path) && len(_state._nfa) != 0; _i++ {
+		_class := a._classes[path[_i]]
+		_next := _state._next[_class]
+		if _next == nil {
+			a._lock.RUnlock()
+			_next = a.next(_state, _class)
+			a._lock.RLock()
+		}
+		_state = _next
+	}
+	a._lock.RUnlock()
+
+	_index := _state._file
+	if isdir {
+		_index = _state._dir
+	}
+	if _index < floor {
+		_index = floor
+	}
+
+	// patterns that could not be compiled take precedence if they follow
+	// the matched pattern
+	for _i := len(a._fallback) - 1; _i >= 0; _i-- {
+		_fallback := a._fallback[_i]
+		if _fallback < _index {
+			break
+		} else if a._patterns[_fallback].Match(path, isdir) {
+			return _fallback
+		}
+	}
+
+	return _index
+} // match()
+
+// add compiles the pattern with the given index into the NFA, returning
+// false if the pattern cannot be compiled.
+func (a *automaton) add(index int, p Pattern) bool {
+	var _pattern *pattern
+	switch _p := p.(type) {
+	case *name:
+		_pattern = &_p.pattern
+	case *path:
+		_pattern = &_p.pattern
+	case *any:
+		_pattern = &_p.pattern
+	default:
+		return false
+	}
+
+	// unanchored patterns may start at any path component
+	_from := _COMPONENT
+	if _pattern._anchored {
+		_from = _ROOT
+	}
+
+	// unanchored name and path patterns match a fixed number of trailing
+	// path components, given by the separators of the pattern, so the
+	// separators matched by the compiled pattern must agree
+	_length := len(a._nfa)
+	//		- as with pattern.pathname(), the literal prefix of anchored
+	//		  patterns delimits a "**" that follows it
+	_begin := 0
+	if _pattern._anchored {
+		_begin = prefixlen(_pattern._expression)
+	}
+	_separators, _ok := a.compile(_pattern._expression, _begin, _pattern._flags, _from, index, _pattern._directory)
+	switch _p := p.(type) {
+	case *name:
+		_ok = _ok && (_pattern._anchored || _separators == 0)
+	case *path:
+		_ok = _ok && (_pattern._anchored || _separators == _p._depth)
+	}
+
+	// discard the states of patterns that cannot be compiled
+	if !_ok {
+		a._nfa = a._nfa[:_length]
+		for _, _state := range a._nfa[:_SKIP] {
+			_last := len(_state._epsilon) - 1
+			if _last >= 0 && _state._epsilon[_last] >= _length {
+				_state._epsilon = _state._epsilon[:_last]
+			}
+		}
+	}
+
+	return _ok
+} // add()
+
+// compile adds the states for the wildmatch pattern to the NFA, starting
+// from the state from, and accepting the pattern with index accept. The
+// pattern is matched as by Wildmatch with WM_PATHNAME and the given flags,
+// where a "**" following the first begin bytes of the pattern is matched as
+// if it starts the pattern. compile returns the number of literal
+// separators of the pattern, and false if the pattern cannot be compiled.
+func (a *automaton) compile(pattern string, begin int, flags WildmatchFlag, from int, accept int, dir bool) (int, bool) {
+	// fold returns the byte as compared by Wildmatch with the given flags
+	fold := func(b byte) byte {
+		if flags&WM_CASEFOLD != 0 {
+			return wmlower(b)
+		}
+		return b
+	}
+
+	_start := a.state()
+	_current := _start
+	_separators := 0
+	for _p := 0; _p < len(pattern); _p++ {
+		switch pattern[_p] {
+		case '\\':
+			// match the following character literally
+			//		- a trailing "\" never matches
+			//		- as with git, the escaped character is not folded
+			_p++
+			if _p == len(pattern) {
+				return 0, false
+			} else if pattern[_p] == byte(_SEPARATOR) {
+				_separators++
+			}
+			_current = a.step(_current, newbyteset(func(b byte) bool { return fold(b) == pattern[_p] }))
+
+		case '?':
+			_current = a.step(_current, _NOTSLASH)
+
+		case '*':
+			// "**" only matches across separators as a complete path
+			// component, following the rules of wildmatch()
+			_first := _p
+			for _p+1 < len(pattern) && pattern[_p+1] == '*' {
+				_p++
+			}
+			_rest := pattern[_p+1:]
+			if _p == _first || (_first > begin && pattern[_first-1] != '/') ||
+				!(_rest == "" || _rest[0] == '/' || strings.HasPrefix(_rest, "\\/")) {
+				_current = a.loop(_current, _NOTSLASH)
+			} else if _rest != "" && _rest[0] == '/' {
+				// "**/" matches zero or more directories
+				_p++
+				_next := a.state()
+				_loop := a.loop(_current, _ANYBYTE)
+				a.edge(_loop, _SLASH, _next)
+				a.epsilon(_current, _next)
+				_current = _next
+			} else {
+				_current = a.loop(_current, _ANYBYTE)
+			}
+
+		case '[':
+			// evaluate the class for every byte
+			//		- a malformed class never matches
+			_set := &byteset{}
+			_end := _p
+			for _b := 0; _b < 256; _b++ {
+				_e, _matched, _abort := wmclass(pattern, _p+1, fold(byte(_b)), WM_PATHNAME|flags)
+				if _abort {
+					return 0, false
+				} else if _matched && _b != '/' {
+					_set.add(byte(_b))
+				}
+				_end = _e
+			}
+			_p = _end
+			_current = a.step(_current, _set)
+
+		default:
+			if pattern[_p] == byte(_SEPARATOR) {
+				_separators++
+			}
+			_current = a.step(_current, newbyteset(func(b byte) bool { return fold(b) == fold(pattern[_p]) }))
+		}
+	}
+
+	_state := a.state()
+	a.epsilon(_current, _state)
+	a._nfa[_state]._accept = accept
+	a._nfa[_state]._dir = dir
+	a.epsilon(from, _start)
+	return _separators, true
+} // compile()
+
+// state adds a new state to the NFA, returning its index.
+func (a *automaton) state() int {
+	a._nfa = append(a._nfa, &nfastate{_accept: -1})
+	return len(a._nfa) - 1
+} // state()
+
+// edge adds a transition from state from to state to, consuming a byte of set.
+func (a *automaton) edge(from int, set *byteset, to int) {
+	a._nfa[from]._edges = append(a._nfa[from]._edges, nfaedge{set, to})
+} // edge()
+
+// epsilon adds a transition from state from to state to, consuming nothing.
+func (a *automaton) epsilon(from, to int) {
+	a._nfa[from]._epsilon = append(a._nfa[from]._epsilon, to)
+} // epsilon()
+
+// step adds a state following state from by consuming a byte of set,
+// returning the new state.
+func (a *automaton) step(from int, set *byteset) int {
+	_state := a.state()
+	a.edge(from, set, _state)
+	return _state
+} // step()
+
+// loop adds a state following state from that consumes any number of bytes
+// of set, returning the new state.
+func (a *automaton) loop(from int, set *byteset) int {
+	_state := a.state()
+	a.epsilon(from, _state)
+	a.edge(_state, set, _state)
+	return _state
+} // loop()
+
+// partition divides the bytes into the classes of bytes that are treated
+// identically by every transition of the NFA, so that the transitions of
+// the DFA are given per class rather than per byte.
+func (a *automaton) partition() {
+	_seen := make(map[byteset]bool)
+	for _, _state := range a._nfa {
+		for _, _edge := range _state._edges {
+			if _seen[*_edge._set] {
+				continue
+			}
+			_seen[*_edge._set] = true
+
+			// split the classes by membership of the set
+			//		- the new class of a byte is given by its current class,
+			//		  and whether it is a member of the set
+			var _split [2 * 256]int
+			_classes := 0
+			for _b := 0; _b < 256; _b++ {
+				_key := 2 * int(a._classes[_b])
+				if _edge._set.has(byte(_b)) {
+					_key++
+				}
+				if _split[_key] == 0 {
+					_classes++
+					_split[_key] = _classes
+				}
+				a._classes[_b] = byte(_split[_key] - 1)
+			}
+		}
+	}
+
+	// record a representative byte of each class
+	for _b := 255; _b >= 0; _b-- {
+		_class := int(a._classes[_b])
+		for len(a._bytes) <= _class {
+			a._bytes = append(a._bytes, 0)
+		}
+		a._bytes[_class] = byte(_b)
+	}
+} // partition()
+
+// flush discards the cached states of the DFA, leaving only its start state.
+func (a *automaton) flush() {
+	a._states = make(map[string]*dfastate)
+	a._size = 0
+	a._start = a.dfa(a.closure([]int{_ROOT}))
+} // flush()
+
+// next returns the DFA state following state on consuming a byte of the
+// given class, constructing the state if necessary.
+func (a *automaton) next(state *dfastate, class byte) *dfastate {
+	a._lock.Lock()
+	defer a._lock.Unlock()
+
+	if _next := state._next[class]; _next != nil {
+		return _next
+	} else if a._size >= _AUTOMATONCACHE {
+		a.flush()
+	}
+
+	// find the NFA states reached by consuming the class
+	_byte := a._bytes[class]
+	_states := make([]int, 0)
+	for _, _nfa := range state._nfa {
+		for _, _edge := range a._nfa[_nfa]._edges {
+			if _edge._set.has(_byte) {
+				_states = append(_states, _edge._next)
+			}
+		}
+	}
+
+	_next := a.dfa(a.closure(_states))
+	state._next[class] = _next
+	return _next
+} // next()
+
+// closure returns the sorted NFA states reachable from states without
+// consuming any bytes. Since the states with only epsilon transitions do not
+// affect the DFA, they are omitted.
+func (a *automaton) closure(states []int) []int {
+	// mark the visited states with the number of this visit, to avoid
+	// clearing the marks of previous visits
+	if len(a._seen) != len(a._nfa) || a._visit == ^uint32(0) {
+		a._seen = make([]uint32, len(a._nfa))
+		a._visit = 0
+	}
+	a._visit++
+
+	_closure := make([]int, 0, len(states))
+	for len(states) > 0 {
+		_state := states[len(states)-1]
+		states = states[:len(states)-1]
+		if a._seen[_state] == a._visit {
+			continue
+		}
+		a._seen[_state] = a._visit
+		_nfa := a._nfa[_state]
+		if len(_nfa._edges) != 0 || _nfa._accept >= 0 {
+			_closure = append(_closure, _state)
+		}
+		states = append(states, _nfa._epsilon...)
+	}
+	sort.Ints(_closure)
+
+	return _closure
+} // closure()
+
+// dfa returns the DFA state for the given set of NFA states.
+func (a *automaton) dfa(states []int) *dfastate {
+	_key := make([]byte, 0, 4*len(states))
+	for _, _state := range states {
+		_key = append(_key, byte(_state>>24), byte(_state>>16), byte(_state>>8), byte(_state))
+	}
+	if _state, _ok := a._states[string(_key)]; _ok {
+		return _state
+	}
+
+	// determine the patterns accepted by this state
+	_state := &dfastate{
+		_nfa:  states,
+		_next: make([]*dfastate, len(a._bytes)),
+		_file: -1,
+		_dir:  -1,
+	}
+	for _, _nfa := range states {
+		_accept := a._nfa[_nfa]._accept
+		if _accept > _state._dir {
+			_state._dir = _accept
+		}
+		if _accept > _state._file && !a._nfa[_nfa]._dir {
+			_state._file = _accept
+		}
+	}
+	a._states[string(_key)] = _state
+	a._size += len(states)
+
+	return _state
+} // dfa()
+
+// newbyteset returns the set of bytes for which member returns true.
+func newbyteset(member func(byte) bool) *byteset {
+	_set := &byteset{}
+	for _b := 0; _b < 256; _b++ {
+		if member(byte(_b)) {
+			_set.add(byte(_b))
+		}
+	}
+	return _set
+} // newbyteset()
+
+// add adds the byte b to the set.
+func (s *byteset) add(b byte) { s[b>>6] |= 1 << (b & 63) }
+
+// has returns true if the byte b is in the set.
+func (s *byteset) has(b byte) bool { return s[b>>6]&(1<<(b&63)) != 0 }
@@ -0,0 +1,71 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+// relative returns the match for path using the patterns in reverse order,
+// as an uncompiled ignore file would
+func relative(patterns []gitignore.Pattern, path string, isdir bool) gitignore.Match {
+	for _i := len(patterns) - 1; _i >= 0; _i-- {
+		if patterns[_i].Match(path, isdir) {
+			return patterns[_i]
+		}
+	}
+
+	return nil
+} // relative()
+
+func TestAutomaton(t *testing.T) {
+	// include patterns that cannot be compiled, such as malformed classes
+	_content, _paths := generated(200, 2000)
+	_content += "file1[\n"
+	_paths = append(_paths, "file1[", "a/b/file1[", "dir3", "a/dir3", "build9")
+
+	_ignore := gitignore.New(strings.NewReader(_content), "/base", nil)
+	_patterns := gitignore.NewParser(strings.NewReader(_content), nil).Parse()
+
+	// ensure the compiled patterns match as the individual patterns
+	for _, _path := range _paths {
+		for _, _isdir := range []bool{false, true} {
+			_match := _ignore.Relative(_path, _isdir)
+			_expected := relative(_patterns, _path, _isdir)
+			if _match == nil && _expected == nil {
+				continue
+			} else if _match == nil || _expected == nil ||
+				_match.String() != _expected.String() ||
+				_match.Position() != _expected.Position() {
+				t.Errorf(
+					"%q: unexpected match (directory %v); expected %v, got %v",
+					_path, _isdir, _expected, _match,
+				)
+			}
+		}
+	}
+} // TestAutomaton()
+
+func BenchmarkRelative(b *testing.B) {
+	_content, _paths := generated(5000, 1000)
+	_ignore := gitignore.New(strings.NewReader(_content), "/base", nil)
+	for _, _path := range _paths {
+		_ignore.Relative(_path, false)
+	}
+
+	b.ResetTimer()
+	for _i := 0; _i < b.N; _i++ {
+		_ignore.Relative(_paths[_i%len(_paths)], false)
+	}
+} // BenchmarkRelative()
+
+func BenchmarkPatterns(b *testing.B) {
+	_content, _paths := generated(5000, 1000)
+	_patterns := gitignore.NewParser(strings.NewReader(_content), nil).Parse()
+
+	b.ResetTimer()
+	for _i := 0; _i < b.N; _i++ {
+		relative(_patterns, _paths[_i%len(_paths)], false)
+	}
+} // BenchmarkPatterns()
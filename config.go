@@ -0,0 +1,329 @@
+package gitignore
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// define the maximum depth of nested include.path directives
+const _INCLUDEDEPTH = 10
+
+// config represents the variables defined in one or more git configuration
+// files. Variables are keyed by their canonical name (i.e. the lower-case
+// section and variable names, and the case-sensitive subsection, such as
+// "core.excludesfile"), with each key holding every value assigned to that
+// variable, in the order the values were read.
+type config map[string][]string
+
+// newConfig returns a config instance containing the variables defined in
+// the system, global and (if gitdir is given) repository configuration files,
+// read in increasing order of precedence. If extensions.worktreeConfig is
+// enabled, $GIT_DIR/config.worktree of the work tree of gitdir takes
+// precedence over the repository configuration. An error is returned if any
+// of these files exists, but cannot be read.
+func newConfig(gitdir string) (config, error) {
+	_config := make(config)
+
+	// consider the system, global and repository configuration files
+	_files, _err := configFiles(gitdir)
+	if _err != nil {
+		return nil, _err
+	}
+	for _, _file := range _files {
+		_err := _config.read(_file, 0)
+		if _err != nil {
+			return nil, _err
+		}
+	}
+
+	// consider the configuration specific to this work tree
+	//		- config.worktree is not shared by linked worktrees, so is
+	//		  located in gitdir rather than the common git directory
+	if gitdir != "" {
+		if _worktree, _ := _config.bool("extensions.worktreeconfig"); _worktree {
+			_file := filepath.Join(gitdir, "config.worktree")
+			_err := _config.read(_file, 0)
+			if _err != nil {
+				return nil, _err
+			}
+		}
+	}
+
+	return _config, nil
+} // newConfig()
+
+// configFiles returns the list of git configuration files to consider for
+// the repository with the given gitdir, in increasing order of precedence.
+// An error is returned if the common git directory cannot be determined.
+func configFiles(gitdir string) ([]string, error) {
+	_files := make([]string, 0)
+
+	// the system configuration may be disabled, or relocated
+	if !boolean(os.Getenv("GIT_CONFIG_NOSYSTEM")) {
+		_system := os.Getenv("GIT_CONFIG_SYSTEM")
+		if _system == "" {
+			_system = filepath.Join(string(filepath.Separator), "etc", "gitconfig")
+		}
+		_files = append(_files, _system)
+	}
+
+	// GIT_CONFIG_GLOBAL replaces both of the user configuration files
+	_global := os.Getenv("GIT_CONFIG_GLOBAL")
+	if _global != "" {
+		_files = append(_files, _global)
+	} else {
+		_xdg := xdg("config")
+		if _xdg != "" {
+			_files = append(_files, _xdg)
+		}
+		_home, _err := os.UserHomeDir()
+		if _err == nil && _home != "" {
+			_files = append(_files, filepath.Join(_home, ".gitconfig"))
+		}
+	}
+
+	// finally, consider the repository configuration
+	//		- linked worktrees share the configuration of the common
+	//		  git directory
+	if gitdir != "" {
+		_common, _err := commondir(gitdir)
+		if _err != nil {
+			return nil, _err
+		}
+		_files = append(_files, filepath.Join(_common, "config"))
+	}
+
+	return _files, nil
+} // configFiles()
+
+// xdg returns the path of the named file within the git XDG configuration
+// directory (i.e. $XDG_CONFIG_HOME/git/, or $HOME/.config/git/ if
+// $XDG_CONFIG_HOME is not set). If neither environment variable is set, xdg
+// returns the empty string.
+func xdg(name string) string {
+	_base := os.Getenv("XDG_CONFIG_HOME")
+	if _base == "" {
+		_home, _err := os.UserHomeDir()
+		if _err != nil || _home == "" {
+			return ""
+		}
+		_base = filepath.Join(_home, ".config")
+	}
+
+	return filepath.Join(_base, "git", name)
+} // xdg()
+
+// read parses the git configuration file, adding its variables to this
+// config. If file does not exist, read does nothing. include.path directives
+// are followed to a maximum nesting depth of _INCLUDEDEPTH.
+func (c config) read(file string, depth int) error {
+	_fh, _err := os.Open(file)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return nil
+		}
+		return _err
+	}
+	defer _fh.Close()
+
+	return c.parse(_fh, filepath.Dir(file), depth)
+} // read()
+
+// parse reads the git configuration from r, adding its variables to this
+// config. Relative include.path directives are resolved against dir.
+// Malformed lines are skipped.
+func (c config) parse(r io.Reader, dir string, depth int) error {
+	_section := ""
+	_reader := bufio.NewReader(r)
+	for {
+		_line, _err := line(_reader)
+		if _err != nil && _err != io.EOF {
+			return _err
+		}
+
+		// is this a section header?
+		//		- a header may be followed by a variable on the same line
+		_rest := strings.TrimSpace(_line)
+		if strings.HasPrefix(_rest, "[") {
+			_section, _rest = header(_rest)
+		}
+
+		// extract the variable name & value (if any)
+		_name, _value, _ok := variable(_rest)
+		if _ok && _section != "" {
+			_key := _section + "." + _name
+			c[_key] = append(c[_key], _value)
+
+			// should we include another configuration file?
+			if _key == "include.path" && depth < _INCLUDEDEPTH {
+				_include := expand(_value)
+				if !filepath.IsAbs(_include) {
+					_include = filepath.Join(dir, _include)
+				}
+				_err := c.read(_include, depth+1)
+				if _err != nil {
+					return _err
+				}
+			}
+		}
+
+		// are we at the end of the file?
+		if _err == io.EOF {
+			return nil
+		}
+	}
+} // parse()
+
+// get returns the last value assigned to the variable key, and true if the
+// variable is defined in this config.
+func (c config) get(key string) (string, bool) {
+	_values := c[key]
+	if len(_values) == 0 {
+		return "", false
+	}
+	return _values[len(_values)-1], true
+} // get()
+
+// bool returns the boolean value of the variable key, and true if the
+// variable is defined in this config.
+func (c config) bool(key string) (bool, bool) {
+	_value, _ok := c.get(key)
+	if !_ok {
+		return false, false
+	}
+	return boolean(_value), true
+} // bool()
+
+// line returns the next logical line from r, joining lines ending in a
+// backslash continuation.
+func line(r *bufio.Reader) (string, error) {
+	_line := ""
+	for {
+		_next, _err := r.ReadString('\n')
+		_next = strings.TrimRight(_next, "\r\n")
+		if strings.HasSuffix(_next, "\\") && !strings.HasSuffix(_next, "\\\\") {
+			_line = _line + strings.TrimSuffix(_next, "\\")
+			if _err == nil {
+				continue
+			}
+		} else {
+			_line = _line + _next
+		}
+		return _line, _err
+	}
+} // line()
+
+// header parses the section header at the start of s, returning the
+// canonical section name, and the remainder of s following the header.
+// If the header is malformed, the empty section name is returned.
+func header(s string) (string, string) {
+	_end := strings.Index(s, "]")
+	if _end == -1 {
+		return "", ""
+	}
+	_header, _rest := strings.TrimSpace(s[1:_end]), s[_end+1:]
+
+	// do we have a subsection (i.e. [section "subsection"])?
+	_quote := strings.Index(_header, "\"")
+	if _quote == -1 {
+		// the deprecated [section.subsection] form has a lower-case
+		// subsection
+		return strings.ToLower(_header), _rest
+	}
+
+	// extract the section and the (unescaped) subsection
+	_section := strings.ToLower(strings.TrimSpace(_header[:_quote]))
+	_subsection := strings.TrimSuffix(_header[_quote+1:], "\"")
+	_subsection = strings.NewReplacer("\\\"", "\"", "\\\\", "\\").
+		Replace(_subsection)
+
+	return _section + "." + _subsection, _rest
+} // header()
+
+// variable parses the "name = value" assignment in s, returning the
+// canonical name and the unquoted value. variable returns false if s does not
+// contain a variable. A variable with no value is interpreted as "true".
+func variable(s string) (string, string, bool) {
+	_s := strings.TrimSpace(s)
+	if _s == "" || _s[0] == '#' || _s[0] == ';' {
+		return "", "", false
+	}
+
+	// extract the variable name
+	_name, _value := _s, ""
+	_equals := strings.Index(_s, "=")
+	if _equals == -1 {
+		// strip any trailing comment from a value-less variable
+		_end := strings.IndexAny(_name, "#;")
+		if _end != -1 {
+			_name = _name[:_end]
+		}
+		return strings.ToLower(strings.TrimSpace(_name)), "true", true
+	}
+	_name = strings.ToLower(strings.TrimSpace(_s[:_equals]))
+	_value = strings.TrimLeft(_s[_equals+1:], " \t")
+
+	// unquote the value, stopping at the first unquoted comment
+	_rtn := make([]rune, 0, len(_value))
+	_quoted := false
+	_escaped := false
+	_trailing := 0
+	for _, _r := range _value {
+		if _escaped {
+			switch _r {
+			case 'n':
+				_r = '\n'
+			case 't':
+				_r = '\t'
+			case 'b':
+				_r = '\b'
+			}
+			_rtn = append(_rtn, _r)
+			_escaped = false
+			_trailing = len(_rtn)
+			continue
+		}
+
+		switch {
+		case _r == '\\':
+			_escaped = true
+		case _r == '"':
+			_quoted = !_quoted
+			_trailing = len(_rtn)
+		case !_quoted && (_r == '#' || _r == ';'):
+			return _name, string(_rtn[:_trailing]), true
+		case !_quoted && (_r == ' ' || _r == '\t'):
+			_rtn = append(_rtn, _r)
+		default:
+			_rtn = append(_rtn, _r)
+			_trailing = len(_rtn)
+		}
+	}
+
+	// remove unquoted trailing whitespace
+	return _name, string(_rtn[:_trailing]), true
+} // variable()
+
+// boolean returns the git interpretation of s as a boolean value.
+func boolean(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+} // boolean()
+
+// expand returns path with a leading "~/" replaced by the user's home
+// directory.
+func expand(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		_home, _err := os.UserHomeDir()
+		if _err == nil {
+			return filepath.Join(_home, path[1:])
+		}
+	}
+	return path
+} // expand()
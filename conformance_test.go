@@ -0,0 +1,39 @@
+package gitignore_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestConformance(t *testing.T) {
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	for _, _test := range _CONFORMANCE {
+		// create the repository with the .gitignore of this test
+		_dir, _err := dir(map[string]string{gitignore.File: _test.Ignore})
+		if _err != nil {
+			t.Fatalf("unable to create temporary directory: %s", _err.Error())
+		}
+		defer os.RemoveAll(_dir)
+
+		// ensure the paths are matched as git matches them
+		_options := gitignore.Options{NoGlobal: true}
+		_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+		if _repository == nil {
+			t.Fatalf("unable to create repository for %q", _dir)
+		}
+		for _, _match := range _test.Matches {
+			do(t, _repository.Relative, _match)
+		}
+	}
+} // TestConformance()
@@ -38,6 +38,38 @@ func (m match) IsDir() bool {
 	return strings.HasSuffix(m.Path, "/")
 } // IsDir()
 
+type inclusion struct {
+	Path    string // test path
+	Include bool   // whether the path is included
+} // inclusion{}
+
+func (i inclusion) Local() string {
+	return match{Path: i.Path}.Local()
+} // Local()
+
+func (i inclusion) IsDir() bool {
+	return match{Path: i.Path}.IsDir()
+} // IsDir()
+
+type origin struct {
+	Path    string // test path
+	Pattern string // matching pattern
+	Ignore  bool   // whether the path is ignored or included
+	File    string // ignore file containing the pattern
+} // origin{}
+
+type provenance struct {
+	Path    string // test path
+	Pattern string // matching pattern (if any)
+	File    string // ignore file containing the pattern
+	Line    int    // line of the pattern within the ignore file
+} // provenance{}
+
+type attribution struct {
+	Path       string   // test path
+	Attributes []string // expected attributes (i.e. "name=value")
+} // attribution{}
+
 type position struct {
 	File   string
 	Line   int
@@ -46,6 +78,37 @@ type position struct {
 	String string
 } // position{}
 
+type wildmatchtest struct {
+	Text    string // text to match
+	Pattern string // wildmatch pattern
+	Glob    bool   // whether matched with WM_PATHNAME
+	IGlob   bool   // whether matched with WM_PATHNAME and WM_CASEFOLD
+	Path    bool   // whether matched without flags
+	IPath   bool   // whether matched with WM_CASEFOLD
+} // wildmatchtest{}
+
+type worktree struct {
+	Ignores map[string][]string // lines of the .gitignore files, by directory
+	Paths   []string            // paths of the tree ("/" suffix for directories)
+} // worktree{}
+
+type trace struct {
+	Path     string // path matched by the pattern
+	Pattern  string // matching pattern
+	File     string // ignore file containing the pattern
+	Decisive bool   // whether the pattern determines the match
+} // trace{}
+
+type tracetest struct {
+	Path   string  // test path ("/" suffix for directories)
+	Traces []trace // expected traces, in increasing order of precedence
+} // tracetest{}
+
+type conformancetest struct {
+	Ignore  string  // content of the .gitignore of the repository
+	Matches []match // expected matches, as reported by git check-ignore
+} // conformancetest{}
+
 // define the constants for the unit tests
 const (
 	// define the example .gitignore file contents
@@ -76,6 +139,7 @@ but \this\ is / valid\#
 so	is this#
 and this is #3 ok too
  / //
+!
 `
 
 	// define the example .gitignore file contents for the Match tests
@@ -131,8 +195,8 @@ Documentation/**/p*.pdf
 `
 
 	// define the number of good & bad patterns in the .gitignore above
-	_GITPATTERNS    = 12
-	_GITBADPATTERNS = 4
+	_GITPATTERNS    = 16
+	_GITBADPATTERNS = 1
 
 	// define the number of good & bad patterns in the match .gitignore above
 	_GITMATCHPATTERNS    = 24
@@ -140,7 +204,7 @@ Documentation/**/p*.pdf
 
 	// define the number of good and bad patterns returned when the
 	// gitignore.Parser error handler returns false upon receiving an error
-	_GITPATTERNSFALSE    = 7
+	_GITPATTERNSFALSE    = 16
 	_GITBADPATTERNSFALSE = 1
 
 	// define the base path for a git repository
@@ -161,9 +225,9 @@ Documentation/**/p*.pdf
 		"/\r"
 
 	// define the number of invalid patterns and errors
-	_GITINVALIDERRORS        = 10
+	_GITINVALIDERRORS        = 9
 	_GITINVALIDERRORSFALSE   = 1
-	_GITINVALIDPATTERNS      = 1
+	_GITINVALIDPATTERNS      = 2
 	_GITINVALIDPATTERNSFALSE = 0
 
 	// define the expected number of errors during repository matching
@@ -177,15 +241,18 @@ Documentation/**/p*.pdf
  
   		  	
 `
+
+	// define the parameters of the differential tests against git
+	//		- each tree is generated from its own seed
+	_DIFFERENTIALTREES      = 100
+	_DIFFERENTIALSHORTTREES = 10
+	_DIFFERENTIALDEPTH      = 3
 )
 
 var (
 	// define the positions of the bad patterns
 	_GITBADPOSITION = []gitignore.Position{
-		gitignore.Position{File: "", Line: 17, Column: 19, Offset: 189},
-		gitignore.Position{File: "", Line: 18, Column: 14, Offset: 219},
-		gitignore.Position{File: "", Line: 19, Column: 8, Offset: 233},
-		gitignore.Position{File: "", Line: 20, Column: 8, Offset: 248},
+		gitignore.Position{File: "", Line: 28, Column: 2, Offset: 324},
 	}
 
 	// define the positions of the good patterns
@@ -197,6 +264,10 @@ var (
 		gitignore.Position{File: "", Line: 12, Column: 1, Offset: 104},
 		gitignore.Position{File: "", Line: 13, Column: 1, Offset: 132},
 		gitignore.Position{File: "", Line: 15, Column: 1, Offset: 150},
+		gitignore.Position{File: "", Line: 17, Column: 1, Offset: 171},
+		gitignore.Position{File: "", Line: 18, Column: 1, Offset: 206},
+		gitignore.Position{File: "", Line: 19, Column: 1, Offset: 226},
+		gitignore.Position{File: "", Line: 20, Column: 1, Offset: 241},
 		gitignore.Position{File: "", Line: 22, Column: 1, Offset: 256},
 		gitignore.Position{File: "", Line: 23, Column: 1, Offset: 280},
 		gitignore.Position{File: "", Line: 25, Column: 1, Offset: 283},
@@ -362,8 +433,11 @@ var (
 		{gitignore.SEPARATOR, "SEPARATOR", "/", 27, 4, 320, 346},
 		{gitignore.SEPARATOR, "SEPARATOR", "/", 27, 5, 321, 347},
 		{gitignore.EOL, "EOL", "\n", 27, 6, 322, 348},
+		// 28: !
+		{gitignore.NEGATION, "NEGATION", "!", 28, 1, 323, 350},
+		{gitignore.EOL, "EOL", "\n", 28, 2, 324, 351},
 
-		{gitignore.EOF, "EOF", "", 28, 1, 323, 350},
+		{gitignore.EOF, "EOF", "", 29, 1, 325, 353},
 	}
 
 	// define match tests and their expected results
@@ -385,7 +459,7 @@ var (
 		{"Documentation/foo.html", "!foo*.html", false, false},
 		{"Documentation/gitignore.html", "*.html", true, false},
 		{"Documentation/test.a.html", "*.html", true, false},
-		{"exclude/", "exclude/**", true, false},
+		{"exclude/", "", false, false},
 		{"exclude/dir1/", "exclude/**", true, false},
 		{"exclude/dir1/dir2/", "exclude/**", true, false},
 		{"exclude/dir1/dir2/dir3/", "exclude/**", true, false},
@@ -415,7 +489,7 @@ var (
 		{"src/findthis.o", "!findthis*", false, false},
 		{"src/internal.o", "*.[oa]", true, false},
 		{"subdir/", "", false, false},
-		{"subdir/hide/", "**/hide/**", true, false},
+		{"subdir/hide/", "", false, false},
 		{"subdir/hide/foo", "**/hide/**", true, false},
 		{"subdir/logdir/", "", false, false},
 		{"subdir/logdir/log/", "**/logdir/log", true, false},
@@ -528,6 +602,756 @@ hidden/
 		{"a/b/e/c/exclude.me", "!**/e/**", false, false},
 	}
 
+	// define the patterns for the global excludes file
+	_GITGLOBAL = `
+# ignore every file with a .global extension
+*.global
+
+# ignore every file named "excluded" (overridden by GIT_DIR/info/exclude)
+excluded
+`
+
+	// define the repository containing the global excludes file tests
+	_GLOBALREPOSITORY = map[string]string{
+		gitignore.File:      "!keep.global\n",
+		".git/info/exclude": "!excluded\n",
+	}
+
+	// define the global excludes file match tests and their expected results
+	_GLOBALMATCHES = []match{
+		{"file.global", "*.global", true, false},
+		{"a/file.global", "*.global", true, false},
+		{"keep.global", "!keep.global", false, false},
+		{"a/keep.global", "!keep.global", false, false},
+		{"excluded", "!excluded", false, true},
+		{"file.txt", "", false, false},
+	}
+
+	// define the global excludes file match tests and their expected results
+	// when the global excludes file is disabled
+	_GLOBALMATCHESNONE = []match{
+		{"file.global", "", false, false},
+		{"a/file.global", "", false, false},
+		{"keep.global", "!keep.global", false, false},
+		{"excluded", "!excluded", false, true},
+		{"file.txt", "", false, false},
+	}
+
+	// define a repository with a linked worktree and a submodule
+	//		- the .git file of the linked worktree is created by the tests,
+	//		  since it must reference the absolute path of its gitdir
+	_GITLINKED = map[string]string{
+		".git/info/exclude":               _GITEXCLUDE,
+		".git/worktrees/linked/commondir": "../..\n",
+		".git/worktrees/linked/HEAD":      "ref: refs/heads/linked\n",
+		".git/modules/sub/info/exclude":   "*.sub\n",
+		".git/modules/sub/HEAD":           "ref: refs/heads/master\n",
+		"sub/.git":                        "gitdir: ../.git/modules/sub\n",
+		"linked/" + gitignore.File:        "*.bak\n",
+		"sub/" + gitignore.File:           "*.bak\n",
+	}
+
+	// define the linked worktree match tests and their expected results
+	_LINKEDMATCHES = []match{
+		{"exclude.me", "*exclude*", true, true},
+		{"a/exclude.me", "*exclude*", true, true},
+		{"file.bak", "*.bak", true, false},
+		{"file.sub", "", false, false},
+	}
+
+	// define the submodule match tests and their expected results
+	_SUBMODULEMATCHES = []match{
+		{"exclude.me", "", false, false},
+		{"file.bak", "*.bak", true, false},
+		{"file.sub", "*.sub", true, true},
+		{"a/file.sub", "*.sub", true, true},
+	}
+
+	// define a repository for the repository discovery tests
+	_GITDISCOVER = map[string]string{
+		".git/HEAD":         "ref: refs/heads/master\n",
+		".git/objects/":     " ",
+		".git/refs/":        " ",
+		".git/info/exclude": _GITEXCLUDE,
+		gitignore.File:      "*.bak\n",
+		"a/b/c/":            " ",
+		"bare/HEAD":         "ref: refs/heads/master\n",
+		"bare/objects/":     " ",
+		"bare/refs/":        " ",
+		"bare/config":       "[core]\n\tbare = true\n",
+	}
+
+	// define the repository for the environment tests
+	//		- linked is a git directory without a commondir file
+	_GITENVIRONMENT = map[string]string{
+		".git/HEAD":         "ref: refs/heads/master\n",
+		".git/objects/":     " ",
+		".git/refs/":        " ",
+		".git/info/exclude": _GITEXCLUDE,
+		gitignore.File:      "*.bak\n",
+		"linked/HEAD":       "ref: refs/heads/master\n",
+		"work/":             " ",
+	}
+
+	// define the repository discovery match tests and their expected results
+	_DISCOVERMATCHES = []match{
+		{"exclude.me", "*exclude*", true, true},
+		{"a/b/exclude.me", "*exclude*", true, true},
+		{"a/b/c/file.bak", "*.bak", true, false},
+		{"a/b/c/file.txt", "", false, false},
+	}
+
+	// define a repository containing nested repositories
+	_GITNESTED = map[string]string{
+		gitignore.File:                        "*.log\nignored/\n",
+		"nested/" + gitignore.File:            "*.out\n",
+		"nested/.git/info/exclude":            "*.tmp\n",
+		"nested/deeper/.git/info/exclude":     "*.deeper\n",
+		"ignored/inner/.git/info/exclude":     "*.tmp\n",
+		"submodule/.git":                      "gitdir: ../.git/modules/submodule\n",
+		".git/modules/submodule/info/exclude": "*.sub\n",
+	}
+
+	// define the nested repository match tests and their expected results
+	_NESTEDMATCHES = []match{
+		{"file.log", "*.log", true, false},
+		{"nested/", "", false, false},
+		{"nested/file.log", "", false, false},
+		{"nested/file.out", "*.out", true, false},
+		{"nested/a/file.out", "*.out", true, false},
+		{"nested/file.tmp", "*.tmp", true, true},
+		{"nested/deeper/file.tmp", "", false, false},
+		{"nested/deeper/file.deeper", "*.deeper", true, true},
+		{"ignored/inner/file.txt", "ignored/", true, false},
+		{"submodule/file.sub", "*.sub", true, true},
+		{"submodule/file.log", "", false, false},
+	}
+
+	// define the nested repository match tests and their expected results
+	// when reporting nested repository boundaries
+	_NESTEDBOUNDARIES = []match{
+		{"file.log", "*.log", true, false},
+		{"nested/", "", false, false},
+		{"nested/file.log", "nested", false, false},
+		{"nested/file.out", "nested", false, false},
+		{"nested/deeper/file.tmp", "nested", false, false},
+		{"ignored/inner/file.txt", "ignored/", true, false},
+		{"submodule/file.sub", "submodule", false, false},
+	}
+
+	// define the nested repository match tests and their expected results
+	// when nested repositories are not detected
+	_NESTEDNONE = []match{
+		{"file.log", "*.log", true, false},
+		{"nested/file.log", "*.log", true, false},
+		{"nested/file.out", "*.out", true, false},
+		{"nested/file.tmp", "", false, false},
+		{"submodule/file.sub", "", false, false},
+	}
+
+	// define the paths of the index tests
+	_INDEXPATHS = []string{
+		"build/keep.o",
+		"main.o",
+		"src/a/b.go",
+		"src/c.go",
+	}
+
+	// define the index tracking tests and their expected results
+	_INDEXTRACKED = []match{
+		{"build/", "", true, false},
+		{"build/keep.o", "", true, false},
+		{"build/other.o", "", false, false},
+		{"main.o", "", true, false},
+		{"main", "", false, false},
+		{"src/", "", true, false},
+		{"src/a/", "", true, false},
+		{"src/a", "", false, false},
+		{"src/c.go", "", true, false},
+		{"src/d.go", "", false, false},
+		{"srcs/", "", false, false},
+	}
+
+	// define the sparse index tracking tests and their expected results
+	//		- all paths beneath a sparse directory entry are tracked
+	_INDEXSPARSE = []match{
+		{"build/", "", true, false},
+		{"build/other.o", "", false, false},
+		{"main.o", "", true, false},
+		{"src/", "", true, false},
+		{"src/a", "", true, false},
+		{"src/d.go", "", true, false},
+		{"srcs/", "", false, false},
+	}
+
+	// define the .gitignore of the repository index tests
+	_INDEXIGNORE = "build/\n*.o\n"
+
+	// define the repository index match tests and their expected results
+	_INDEXMATCHES = []match{
+		{"build/", "build", false, false},
+		{"build/keep.o", "build/keep.o", false, false},
+		{"build/other.o", "build/", true, false},
+		{"main.o", "main.o", false, false},
+		{"other.o", "*.o", true, false},
+		{"src/", "src", false, false},
+		{"src/c.go", "src/c.go", false, false},
+		{"src/d.o", "*.o", true, false},
+	}
+
+	// define the repository index match tests and their expected results
+	// when the index is not consulted
+	_INDEXMATCHESNONE = []match{
+		{"build/", "build/", true, false},
+		{"build/keep.o", "build/", true, false},
+		{"main.o", "*.o", true, false},
+		{"src/c.go", "", false, false},
+	}
+
+	// define the ignore files of the revision tests
+	_REVISIONIGNORE = "*.log\n/build/\n"
+	_REVISIONSRC    = "*.log\n!keep.log\n*.tmp\n"
+	_REVISIONDOCS   = "*.log\n*.md\n"
+
+	// define the revision match tests and their expected results
+	_REVISIONMATCHES = []match{
+		{"a.log", "*.log", true, false},
+		{"build/", "/build/", true, false},
+		{"build/a.go", "/build/", true, false},
+		{"src/build/", "", false, false},
+		{"src/keep.log", "!keep.log", false, false},
+		{"src/debug.log", "*.log", true, false},
+		{"src/a.tmp", "*.tmp", true, false},
+		{"src/a.go", "", false, false},
+		{"docs/a.md", "*.md", true, false},
+		{"a.md", "", false, false},
+		{"vendor/", "", false, false},
+		{"vendor/lib/a.log", "vendor/lib", false, false},
+	}
+
+	// define the revision match tests and their expected results when the
+	// paths of the revision are regarded as tracked
+	_REVISIONTRACKED = []match{
+		{"src/", "src", false, false},
+		{"src/debug.log", "src/debug.log", false, false},
+		{"src/other.log", "*.log", true, false},
+		{"a.log", "*.log", true, false},
+	}
+
+	// define the cone mode sparse checkout patterns
+	_SPARSECONE = "/*\n!/*/\n/A/\n!/A/*/\n/A/B/\n/E/F/\n"
+
+	// define the cone mode sparse checkout tests and their expected results
+	_SPARSECONEINCLUDES = []inclusion{
+		{"a.txt", true},
+		{"A/", true},
+		{"A/a.txt", true},
+		{"A/B/", true},
+		{"A/B/b.txt", true},
+		{"A/B/C/", true},
+		{"A/B/C/c.txt", true},
+		{"A/D/", false},
+		{"A/D/d.txt", false},
+		{"E/", true},
+		{"E/e.txt", false},
+		{"E/F/", true},
+		{"E/F/f.c", true},
+		{"G/", false},
+		{"G/H/h.c", false},
+	}
+
+	// define the non-cone mode sparse checkout patterns
+	_SPARSENONCONE = "/A/\n!/A/B/\n*.c\n!G/\n"
+
+	// define the non-cone mode sparse checkout tests and their expected
+	// results
+	_SPARSENONCONEINCLUDES = []inclusion{
+		{"a.txt", false},
+		{"A/", true},
+		{"A/a.txt", true},
+		{"A/B/", false},
+		{"A/B/b.txt", false},
+		{"A/B/C/c.txt", false},
+		{"A/D/d.txt", true},
+		{"E/e.txt", false},
+		{"E/F/f.c", true},
+		{"E/F/g.txt", false},
+		{"G/", false},
+		{"G/H/h.c", true},
+	}
+
+	// define the cone mode sparse checkout tests and their expected results
+	// when cone mode is disabled
+	_SPARSECONEDISABLED = []inclusion{
+		{"a.txt", true},
+		{"A/a.txt", true},
+		{"A/B/C/c.txt", true},
+		{"A/D/", false},
+		{"A/D/d.txt", false},
+		{"E/e.txt", false},
+		{"E/F/g.txt", true},
+		{"G/H/h.c", false},
+	}
+
+	// define the work tree of the sparse checkout tests against git
+	_SPARSETREE = map[string]string{
+		"a.txt":     "a\n",
+		"A/a.txt":   "a\n",
+		"A/B/b.txt": "b\n",
+		"A/D/d.txt": "d\n",
+		"E/e.txt":   "e\n",
+		"E/F/f.c":   "f\n",
+		"G/H/h.c":   "h\n",
+	}
+
+	// define the directories of the cone mode sparse checkout created by
+	// git, and the expected results
+	_SPARSEGITCONE         = []string{"A/B", "E/F"}
+	_SPARSEGITCONEINCLUDES = []inclusion{
+		{"a.txt", true},
+		{"A/", true},
+		{"A/a.txt", true},
+		{"A/B/b.txt", true},
+		{"A/D/", false},
+		{"A/D/d.txt", false},
+		{"E/e.txt", true},
+		{"E/F/f.c", true},
+		{"G/", false},
+		{"G/H/h.c", false},
+	}
+
+	// define the repository with several ignore files in each directory
+	_GITFILES = map[string]string{
+		gitignore.File:    "*.log\n!keep.*\n",
+		".ignore":         "keep.log\n*.tmp\n",
+		".toolignore":     "!debug.tmp\n",
+		"sub/.gitignore":  "*.txt\n",
+		"sub/.ignore":     "!notes.txt\n",
+		"sub/.toolignore": "# nothing to see here\n",
+	}
+
+	// define the names of the ignore files, in increasing order of
+	// precedence
+	_GITFILENAMES = []string{gitignore.File, ".ignore", ".toolignore"}
+
+	// define the ignore file tests and their expected results
+	_GITFILEMATCHES = []origin{
+		{"a.log", "*.log", true, gitignore.File},
+		{"keep.log", "keep.log", true, ".ignore"},
+		{"keep.txt", "!keep.*", false, gitignore.File},
+		{"a.tmp", "*.tmp", true, ".ignore"},
+		{"debug.tmp", "!debug.tmp", false, ".toolignore"},
+		{"sub/a.txt", "*.txt", true, "sub/.gitignore"},
+		{"sub/notes.txt", "!notes.txt", false, "sub/.ignore"},
+		{"sub/a.tmp", "*.tmp", true, ".ignore"},
+		{"sub/debug.tmp", "!debug.tmp", false, ".toolignore"},
+	}
+
+	// define the .dockerignore of the Docker tests
+	_DOCKERIGNORE = "\xef\xbb\xbf# build context\n" +
+		"*.log\n" +
+		"!keep.log\n" +
+		"  /build/  \n" +
+		"!build/keep\n" +
+		"docs/**/*.md\n" +
+		"**/tmp\n" +
+		"vendor/**\n" +
+		"[a-c]x\n" +
+		"foo.bar\n" +
+		"Dockerfile\n" +
+		".dockerignore\n" +
+		"!\n"
+
+	// define the Docker tests and their expected results
+	_DOCKERMATCHES = []match{
+		{"a.log", "*.log", true, false},
+		{"keep.log", "!keep.log", false, false},
+		{"sub/a.log", "", false, false},
+		{"build/", "build", true, false},
+		{"build/other", "build", true, false},
+		{"build/keep", "!build/keep", false, false},
+		{"build/keep/x", "!build/keep", false, false},
+		{"docs/a.md", "docs/**/*.md", true, false},
+		{"docs/x/y/a.md", "docs/**/*.md", true, false},
+		{"x/docs/a.md", "", false, false},
+		{"tmp/", "**/tmp", true, false},
+		{"a/tmp/b", "**/tmp", true, false},
+		{"vendor/", "", false, false},
+		{"vendor/x", "vendor/**", true, false},
+		{"ax", "[a-c]x", true, false},
+		{"dx", "", false, false},
+		{"foo.bar", "foo.bar", true, false},
+		{"fooxbar", "", false, false},
+		{"Dockerfile", "!Dockerfile", false, false},
+		{".dockerignore", "!.dockerignore", false, false},
+	}
+
+	// define the Docker context of the Docker tests
+	_DOCKERCONTEXT = map[string]string{
+		gitignore.DockerIgnoreFile:                      "*.md\n",
+		"build/Dockerfile":                              "FROM scratch\n",
+		"build/Dockerfile" + gitignore.DockerIgnoreFile: "*.go\nbuild\n",
+		"README.md": "# README\n",
+		"main.go":   "package main\n",
+	}
+
+	// define the Docker context tests and their expected results, when
+	// using the Dockerfile build/Dockerfile
+	_DOCKERCONTEXTMATCHES = []match{
+		{"README.md", "", false, false},
+		{"main.go", "*.go", true, false},
+		{"build/", "build", true, false},
+		{"build/Dockerfile", "!build/Dockerfile", false, false},
+	}
+
+	// define the repository of the Mercurial tests
+	_HGREPOSITORY = map[string]string{
+		gitignore.HgIgnoreFile: "# Mercurial ignore file\n" +
+			"\\.orig$\n" +
+			"^build/\n" +
+			"syntax: glob\n" +
+			"*.o\n" +
+			"rootglob:dist\n" +
+			"re:^cache\\d+$\n" +
+			"syntax: rootglob\n" +
+			"tmp/*.log\n" +
+			"syntax: unknown\n" +
+			"docs/**/*.{html,css}\n" +
+			"foo\\#bar # comment\n" +
+			"subinclude:sub/.hgignore\n" +
+			"include:common.hgignore\n" +
+			"re:a(b\n",
+		"sub/.hgignore":   "syntax: glob\n*.txt\n",
+		"common.hgignore": "\\.bak$\n",
+	}
+
+	// define the Mercurial tests and their expected results
+	_HGMATCHES = []provenance{
+		{"a.orig", "\\.orig$", gitignore.HgIgnoreFile, 2},
+		{"x/b.orig", "\\.orig$", gitignore.HgIgnoreFile, 2},
+		{"build/out", "^build/", gitignore.HgIgnoreFile, 3},
+		{"src/build/out", "", "", 0},
+		{"a.o", "*.o", gitignore.HgIgnoreFile, 5},
+		{"x/y/a.o/z", "*.o", gitignore.HgIgnoreFile, 5},
+		{"dist/x", "rootglob:dist", gitignore.HgIgnoreFile, 6},
+		{"x/dist", "", "", 0},
+		{"cache12", "re:^cache\\d+$", gitignore.HgIgnoreFile, 7},
+		{"cache12x", "", "", 0},
+		{"cache12/x.c", "re:^cache\\d+$", gitignore.HgIgnoreFile, 7},
+		{"x/cache12/y", "", "", 0},
+		{"tmp/a.log", "tmp/*.log", gitignore.HgIgnoreFile, 9},
+		{"tmp/x/a.log", "", "", 0},
+		{"x/tmp/a.log", "", "", 0},
+		{"docs/a.html", "docs/**/*.{html,css}", gitignore.HgIgnoreFile, 11},
+		{"docs/a/b.css", "docs/**/*.{html,css}", gitignore.HgIgnoreFile, 11},
+		{"docs/a/b.js", "", "", 0},
+		{"foo#bar", "foo#bar", gitignore.HgIgnoreFile, 12},
+		{"sub/a.txt", "*.txt", "sub/.hgignore", 2},
+		{"sub/x/a.txt", "*.txt", "sub/.hgignore", 2},
+		{"a.txt", "", "", 0},
+		{"x.bak", "\\.bak$", "common.hgignore", 1},
+	}
+
+	// define the transfer of the rsync tests
+	_RSYNCTRANSFER = map[string]string{
+		".rsync-filter":     "- *.tmp\n",
+		"sub/.rsync-filter": "+ keep.tmp\n- /local\n",
+		"common.rules":      "- *.bak\n",
+	}
+
+	// define the filter rules of the rsync tests
+	_RSYNCFILTER = "# rsync filter\n" +
+		"- *.o\n" +
+		"+ /build/\n" +
+		"+ /build/keep/***\n" +
+		"- /build/*\n" +
+		"include **/important.log\n" +
+		"- *.log\n" +
+		"dir-merge,e .rsync-filter\n" +
+		"merge common.rules\n" +
+		"- data/***\n" +
+		"- doc/*.txt\n" +
+		"- [[:digit:]]*.out\n" +
+		"x bogus\n" +
+		"-C foo\n" +
+		"P *.keep\n" +
+		"- /src/**.gen\n"
+
+	// define the rsync tests and their expected results
+	_RSYNCMATCHES = []match{
+		{"a.o", "- *.o", true, false},
+		{"x/y/a.o", "- *.o", true, false},
+		{"build/", "+ /build/", false, false},
+		{"build/keep/", "+ /build/keep/***", false, false},
+		{"build/keep/x", "+ /build/keep/***", false, false},
+		{"build/other", "- /build/*", true, false},
+		{"build/other/x", "- /build/*", true, false},
+		{"important.log", "- *.log", true, false},
+		{"a/important.log", "include **/important.log", false, false},
+		{"a.log", "- *.log", true, false},
+		{".rsync-filter", "- .rsync-filter", true, false},
+		{"a.tmp", "- *.tmp", true, false},
+		{"sub/x/a.tmp", "- *.tmp", true, false},
+		{"sub/keep.tmp", "+ keep.tmp", false, false},
+		{"sub/x/keep.tmp", "+ keep.tmp", false, false},
+		{"keep.tmp", "- *.tmp", true, false},
+		{"sub/local", "- /local", true, false},
+		{"local", "", false, false},
+		{"sub/x/local", "", false, false},
+		{"a.bak", "- *.bak", true, false},
+		{"data/", "- data/***", true, false},
+		{"data/x/y", "- data/***", true, false},
+		{"x/data/z", "- data/***", true, false},
+		{"doc/a.txt", "- doc/*.txt", true, false},
+		{"x/doc/a.txt", "- doc/*.txt", true, false},
+		{"doc/x/a.txt", "", false, false},
+		{"1.out", "- [[:digit:]]*.out", true, false},
+		{"a.out", "", false, false},
+		{"src/a.gen", "- /src/**.gen", true, false},
+		{"src/a/b/c.gen", "- /src/**.gen", true, false},
+		{"x/src/a.gen", "", false, false},
+		{"z.keep", "", false, false},
+	}
+
+	// define the exclude file of the rsync tests
+	_RSYNCEXCLUDE = "+ keep.o\n*.o\n/tmp/\n"
+
+	// define the rsync exclude file tests and their expected results
+	_RSYNCEXCLUDEMATCHES = []match{
+		{"keep.o", "+ keep.o", false, false},
+		{"x/a.o", "- *.o", true, false},
+		{"tmp/", "- /tmp/", true, false},
+		{"tmp", "", false, false},
+		{"x/tmp/", "", false, false},
+	}
+
+	// define the repository exported as rsync filter rules
+	_RSYNCREPOSITORY = map[string]string{
+		gitignore.File:              "*.log\n!keep.log\n/build/\nignored/\n**/cache\n",
+		"sub/" + gitignore.File:     "*.txt\n!notes.txt\ndocs/**/*.md\n",
+		"ignored/" + gitignore.File: "!*\n",
+	}
+
+	// define the rsync filter rules exported for the repository
+	_RSYNCEXPORT = "# sub/.gitignore\n" +
+		"- /sub/docs/**/*.md\n" +
+		"- /sub/docs/*.md\n" +
+		"+ /sub/**/notes.txt\n" +
+		"+ /sub/notes.txt\n" +
+		"- /sub/**/*.txt\n" +
+		"- /sub/*.txt\n" +
+		"# .gitignore\n" +
+		"- /**/cache\n" +
+		"- /cache\n" +
+		"- ignored/\n" +
+		"- /build/\n" +
+		"+ keep.log\n" +
+		"- *.log\n"
+
+	// define the paths matched against the repository and its exported
+	// rsync filter rules
+	_RSYNCEXPORTPATHS = []string{
+		"a.log", "keep.log", "x/keep.log", "build/", "build/x", "x/build/",
+		"ignored/a", "a.txt", "sub/a.txt", "sub/x/a.txt", "sub/notes.txt",
+		"sub/x/notes.txt", "sub/docs/a.md", "sub/docs/x/a.md", "docs/a.md",
+		"cache/", "x/cache/y", "sub/cache/",
+	}
+
+	// define the ignore files of the include tests
+	_INCLUDEFILES = map[string]string{
+		".toolignore": "*.tool\n" +
+			"#!include:.gitignore\n" +
+			"#!include: shared/company.ignore\n" +
+			"!keep.log\n" +
+			"#!include:missing.ignore\n" +
+			"#!include:cycle.ignore\n",
+		gitignore.File:          "*.log\n/build/\n",
+		"shared/company.ignore": "*.bak\n#!include:../.gitignore\n",
+		"cycle.ignore":          "*.cyc\n#!include:cycle.ignore\n",
+	}
+
+	// define the include tests and their expected results
+	_INCLUDEMATCHES = []provenance{
+		{"a.tool", "*.tool", ".toolignore", 1},
+		{"a.log", "*.log", gitignore.File, 1},
+		{"keep.log", "!keep.log", ".toolignore", 4},
+		{"build/", "/build/", gitignore.File, 2},
+		{"a.bak", "*.bak", "shared/company.ignore", 1},
+		{"a.cyc", "*.cyc", "cycle.ignore", 1},
+		{"a.txt", "", "", 0},
+	}
+
+	// define the ignore file of the dialect tests
+	_DIALECTIGNORE = "!literal\n" +
+		"/root.txt\n" +
+		"a/b\n" +
+		"build/\n" +
+		"src/**/*.gen\n"
+
+	// define the dialect tests and their expected results
+	//		- "!" is literal, patterns without a leading separator match
+	//		  trailing path components, and trailing separators are ignored
+	_DIALECTMATCHES = []match{
+		{"!literal", "!literal", true, false},
+		{"literal", "", false, false},
+		{"root.txt", "/root.txt", true, false},
+		{"x/root.txt", "", false, false},
+		{"a/b", "a/b", true, false},
+		{"x/a/b", "a/b", true, false},
+		{"x/a/b/", "a/b", true, false},
+		{"x/b", "", false, false},
+		{"build", "build/", true, false},
+		{"x/build/", "build/", true, false},
+		{"src/x/y.gen", "src/**/*.gen", true, false},
+		{"lib/src/y.gen", "src/**/*.gen", true, false},
+		{"lib/y.gen", "", false, false},
+	}
+
+	// define the repository of the dialect tests
+	//		- the dialect only reads the ignore file of the root directory
+	_DIALECTREPOSITORY = map[string]string{
+		".rootignore":     _DIALECTIGNORE,
+		"sub/.rootignore": "*.txt\n",
+	}
+	_DIALECTREPOSITORYMATCHES = []match{
+		{"sub/a.txt", "", false, false},
+		{"sub/a/b", "a/b", true, false},
+		{"sub/build/file", "build/", true, false},
+		{"root.txt", "/root.txt", true, false},
+	}
+
+	// define the npm package listing its files in package.json
+	_NPMPACKAGE = map[string]string{
+		"package.json": `{"name": "pkg", "main": "index.js", ` +
+			`"bin": {"pkg": "bin/cli.js"}, ` +
+			`"files": ["lib/", "dist/*.js", "!dist/secret.js"]}`,
+		".npmignore":                  "lib/\n",
+		".npmrc":                      "npmrc\n",
+		"package-lock.json":           "lock\n",
+		"README.md":                   "readme\n",
+		"LICENSE":                     "license\n",
+		"index.js":                    "index\n",
+		"bin/cli.js":                  "cli\n",
+		"bin/other.js":                "other\n",
+		"docs/README.md":              "docs\n",
+		"src/index.ts":                "source\n",
+		"lib/.npmignore":              "*.test.js\n",
+		"lib/.gitignore":              "util.js\n",
+		"lib/.DS_Store":               "store\n",
+		"lib/index.js":                "lib\n",
+		"lib/util.js":                 "util\n",
+		"lib/util.test.js":            "test\n",
+		"lib/node_modules/dep/dep.js": "dep\n",
+		"dist/a.js":                   "dist\n",
+		"dist/a.js.map":               "map\n",
+		"dist/secret.js":              "secret\n",
+		"dist/sub/b.js":               "nested\n",
+	}
+
+	// define the files of the npm package that are packed
+	_NPMPACKED = []string{
+		"LICENSE",
+		"README.md",
+		"bin/cli.js",
+		"dist/a.js",
+		"index.js",
+		"lib/index.js",
+		"lib/util.js",
+		"package.json",
+	}
+
+	// define the npm package matching tests and their expected results
+	_NPMMATCHES = []match{
+		{"package.json", "package.json", false, false},
+		{"README.md", "README*", false, false},
+		{"docs/README.md", "files", true, false},
+		{"bin/cli.js", "bin/cli.js", false, false},
+		{"bin/other.js", "files", true, false},
+		{"src/", "files", true, false},
+		{"lib/util.js", "lib/", false, false},
+		{"lib/util.test.js", "*.test.js", true, false},
+		{"lib/node_modules/", "node_modules", true, false},
+		{"lib/node_modules/dep/dep.js", "node_modules", true, false},
+		{"dist/", "", false, false},
+		{"dist/a.js", "dist/*.js", false, false},
+		{"dist/secret.js", "!dist/secret.js", true, false},
+		{"dist/sub/", "files", true, false},
+		{".npmrc", ".npmrc", true, false},
+		{"package-lock.json", "/package-lock.json", true, false},
+	}
+
+	// define the npm package without a "files" list
+	//		- the .npmignore of a directory is used in preference to its
+	//		  .gitignore
+	_NPMPACKAGEIGNORE = map[string]string{
+		"package.json":              `{"name": "pkg"}`,
+		".gitignore":                "*.log\nbuild/\n",
+		"a.js":                      "a\n",
+		"debug.log":                 "log\n",
+		"build/out.js":              "out\n",
+		"sub/.npmignore":            "*.tmp\n",
+		"sub/.gitignore":            "*.js\n",
+		"sub/x.js":                  "x\n",
+		"sub/x.tmp":                 "tmp\n",
+		"sub/x.log":                 "log\n",
+		".svn/entries":              "svn\n",
+		"node_modules/dep/index.js": "dep\n",
+	}
+	_NPMPACKEDIGNORE = []string{
+		"a.js",
+		"package.json",
+		"sub/x.js",
+	}
+
+	// define the attributes files of the attributes tests
+	_ATTRIBUTESINFO = "*.bin binary\n*.sh eol=lf\n"
+	_ATTRIBUTESROOT = "# attributes\n" +
+		"[attr]doc text diff=markdown\n" +
+		"* text=auto\n" +
+		"*.md doc\n" +
+		"*.png -text -diff\n" +
+		"docs/*.txt linguist-documentation\n" +
+		"!*.c text\n" +
+		"\"quoted name.txt\" custom=1\n" +
+		"*.sh eol=crlf executable\n" +
+		"src/ vendored\n" +
+		"*.go bad@attr\n"
+	_ATTRIBUTESDOCS = "*.md -diff\n" +
+		"[attr]bad text\n" +
+		"*.txt !text eol=crlf\n" +
+		"api/** generated\n"
+	_ATTRIBUTESGLOBAL = "[attr]web diff=html\n" +
+		"*.html web\n" +
+		"*.md text=false\n"
+
+	// define the attributes tests and their expected results
+	_ATTRIBUTES = []attribution{
+		{"README.md", []string{"diff=markdown", "doc=set", "text=set"}},
+		{"image.png", []string{"diff=unset", "text=unset"}},
+		{"data.bin", []string{
+			"binary=set", "diff=unset", "merge=unset", "text=unset",
+		}},
+		{"run.sh", []string{"eol=lf", "executable=set", "text=auto"}},
+		{"main.c", []string{"text=auto"}},
+		{"quoted name.txt", []string{"custom=1", "text=auto"}},
+		{"main.go", []string{"text=auto"}},
+		{"src", []string{"text=auto"}},
+		{"src/main.go", []string{"text=auto"}},
+		{"docs/guide.md", []string{"diff=unset", "doc=set", "text=set"}},
+		{"docs/notes.txt", []string{"eol=crlf", "linguist-documentation=set"}},
+		{"docs/api/index.html", []string{
+			"diff=html", "generated=set", "text=auto", "web=set",
+		}},
+		{"docs/api/notes.txt", []string{"eol=crlf", "generated=set"}},
+	}
+
+	// define the errors expected when parsing the attributes files
+	_ATTRIBUTESERRORS = []error{
+		gitignore.NegativeAttributeError,
+		gitignore.InvalidAttributeError,
+		gitignore.MacroAttributeError,
+	}
+
 	// define the repository match tests and their expected results when the
 	// error handler returns false
 	_REPOSITORYMATCHESFALSE = []match{
@@ -631,7 +1455,7 @@ hidden/
 	}
 
 	// define the patterns & errors expected during invalid content parsing
-	_GITINVALIDPATTERN = []string{"/my/valid/pattern"}
+	_GITINVALIDPATTERN = []string{"/my/valid/pattern", "** *"}
 	_GITINVALIDERROR   = []error{
 		gitignore.CarriageReturnError,
 		gitignore.CarriageReturnError,
@@ -641,7 +1465,686 @@ hidden/
 		gitignore.CarriageReturnError,
 		gitignore.CarriageReturnError,
 		gitignore.InvalidPatternError,
-		gitignore.InvalidPatternError,
 		gitignore.CarriageReturnError,
 	}
+
+	// define the .gitignore for testing the lookup of literal patterns
+	_LOOKUPIGNORE = "node_modules\n" +
+		"*.o\n" +
+		"/build/\n" +
+		"!keep.o\n" +
+		"build\n" +
+		"*.tar.gz\n" +
+		"docs/*.md\n" +
+		"!docs/README.md\n" +
+		"t?mp\n"
+
+	// define the lookup tests and their expected results
+	//		- the last matching pattern wins, regardless of its bucket
+	_LOOKUPMATCHES = []match{
+		{"node_modules/", "node_modules", true, false},
+		{"a/node_modules/", "node_modules", true, false},
+		{"node_modules.txt", "", false, false},
+		{"x.o", "*.o", true, false},
+		{"a/x.o", "*.o", true, false},
+		{"keep.o", "!keep.o", false, false},
+		{"a/keep.o", "!keep.o", false, false},
+		{"build/", "build", true, false},
+		{"a/build", "build", true, false},
+		{"x.tar.gz", "*.tar.gz", true, false},
+		{"x.gz", "", false, false},
+		{"docs/a.md", "docs/*.md", true, false},
+		{"docs/README.md", "!docs/README.md", false, false},
+		{"a/docs/a.md", "", false, false},
+		{"a/temp", "t?mp", true, false},
+	}
+
+	// define the .gitignore for testing case-insensitive matching
+	_IGNORECASEIGNORE = "Build/\n" +
+		"*.LOG\n" +
+		"/Docs/**/*.Md\n" +
+		"\u212aelvin\n" +
+		"\u00c4rger.txt\n" +
+		"[A-C]ache\n" +
+		"\\Abc\n"
+
+	// define the case-insensitive tests and their expected results
+	//		- as with git, escaped characters are not folded
+	_IGNORECASEMATCHES = []match{
+		{"build/", "Build/", true, false},
+		{"BUILD/", "Build/", true, false},
+		{"src/bUiLd/", "Build/", true, false},
+		{"build", "", false, false},
+		{"debug.log", "*.LOG", true, false},
+		{"a/Debug.Log", "*.LOG", true, false},
+		{"docs/x/readme.md", "/Docs/**/*.Md", true, false},
+		{"DOCS/README.MD", "/Docs/**/*.Md", true, false},
+		{"a/docs/readme.md", "", false, false},
+		{"kelvin", "\u212aelvin", true, false},
+		{"KELVIN", "\u212aelvin", true, false},
+		{"\u00e4rger.txt", "\u00c4rger.txt", true, false},
+		{"\u00c4RGER.TXT", "\u00c4rger.txt", true, false},
+		{"cache", "[A-C]ache", true, false},
+		{"CACHE", "[A-C]ache", true, false},
+		{"abc", "", false, false},
+		{"Abc", "", false, false},
+	}
+
+	// define the case-sensitive tests for the same .gitignore
+	_IGNORECASESENSITIVE = []match{
+		{"Build/", "Build/", true, false},
+		{"build/", "", false, false},
+		{"debug.log", "", false, false},
+		{"kelvin", "", false, false},
+		{"Abc", "\\Abc", true, false},
+	}
+
+	// define the repository for testing case-insensitive matching
+	_IGNORECASEREPOSITORY = map[string]string{
+		".git/config":       "[core]\n\tignoreCase = true\n",
+		".git/info/exclude": "Secret\n",
+		".gitignore":        "Build/\n*.LOG\n",
+		"sub/.gitignore":    "Temp\n",
+		"sub/TEMP":          "",
+	}
+
+	// define the case-insensitive repository tests
+	_IGNORECASEREPOSITORYMATCHES = []match{
+		{"BUILD/", "Build/", true, false},
+		{"sub/debug.log", "*.LOG", true, false},
+		{"sub/TEMP", "Temp", true, false},
+		{"secret", "Secret", true, true},
+		{"other", "", false, false},
+	}
+
+	// define the .gitignore for testing Unicode normalization
+	//		- patterns are given in both precomposed (NFC) and decomposed
+	//		  (NFD) forms
+	_NORMALIZEIGNORE = "Caf\u00e9/\n" +
+		"na\u0308ive.txt\n" +
+		"*.r\u00e9sum\u00e9\n"
+
+	// define the NFC normalization tests and their expected results
+	_NORMALIZENFCMATCHES = []match{
+		{"Caf\u00e9/", "Caf\u00e9/", true, false},
+		{"Cafe\u0301/", "Caf\u00e9/", true, false},
+		{"src/Cafe\u0301/", "Caf\u00e9/", true, false},
+		{"Cafe/", "", false, false},
+		{"n\u00e4ive.txt", "n\u00e4ive.txt", true, false},
+		{"na\u0308ive.txt", "n\u00e4ive.txt", true, false},
+		{"cv.r\u00e9sum\u00e9", "*.r\u00e9sum\u00e9", true, false},
+		{"cv.re\u0301sume\u0301", "*.r\u00e9sum\u00e9", true, false},
+		{"cv.resume", "", false, false},
+	}
+
+	// define the NFD normalization tests for the same .gitignore
+	_NORMALIZENFDMATCHES = []match{
+		{"Caf\u00e9/", "Cafe\u0301/", true, false},
+		{"Cafe\u0301/", "Cafe\u0301/", true, false},
+		{"n\u00e4ive.txt", "na\u0308ive.txt", true, false},
+		{"cv.r\u00e9sum\u00e9", "*.re\u0301sume\u0301", true, false},
+	}
+
+	// define the tests without normalization for the same .gitignore
+	_NORMALIZENONEMATCHES = []match{
+		{"Caf\u00e9/", "Caf\u00e9/", true, false},
+		{"Cafe\u0301/", "", false, false},
+		{"n\u00e4ive.txt", "", false, false},
+		{"na\u0308ive.txt", "na\u0308ive.txt", true, false},
+	}
+
+	// define the repository for testing Unicode normalization
+	_NORMALIZEREPOSITORY = map[string]string{
+		".git/config":          "[core]\n\tprecomposeUnicode = true\n",
+		".git/info/exclude":    "Re\u0301sume\u0301\n",
+		".gitignore":           "Caf\u00e9/\n",
+		"sub/.gitignore":       "Cafe\u0301.txt\n",
+		"r\u00e9po/.gitignore": "*.txt\n",
+	}
+
+	// define the normalized repository tests
+	_NORMALIZEREPOSITORYMATCHES = []match{
+		{"Cafe\u0301/", "Caf\u00e9/", true, false},
+		{"sub/Caf\u00e9.txt", "Caf\u00e9.txt", true, false},
+		{"sub/Cafe\u0301.txt", "Caf\u00e9.txt", true, false},
+		{"R\u00e9sum\u00e9", "R\u00e9sum\u00e9", true, true},
+		{"other", "", false, false},
+	}
+
+	// define the .gitignore for testing file names that are not valid UTF-8
+	//		- the patterns and names are in Latin-1
+	_BYTESIGNORE = "caf\xe9/\n" +
+		"*.r\xe9sum\xe9\n" +
+		"[\xe0-\xef]t\xe9\n" +
+		"/\xff*\n" +
+		"!\xffkeep\n"
+
+	// define the byte-exact tests and their expected results
+	//		- the UTF-8 encodings of the Latin-1 names do not match
+	_BYTESMATCHES = []match{
+		{"caf\xe9/", "caf\xe9/", true, false},
+		{"src/caf\xe9/", "caf\xe9/", true, false},
+		{"caf\u00e9/", "", false, false},
+		{"caf\ufffd/", "", false, false},
+		{"cv.r\xe9sum\xe9", "*.r\xe9sum\xe9", true, false},
+		{"\xe9t\xe9", "[\xe0-\xef]t\xe9", true, false},
+		{"\xf0t\xe9", "", false, false},
+		{"\xff\xfe", "/\xff*", true, false},
+		{"a/\xff\xfe", "", false, false},
+		{"\xffkeep", "!\xffkeep", false, false},
+	}
+
+	// define the git conformance tests, following the semantics of git's
+	// t0008-ignores and t3070-wildmatch
+	//		- the expected results are those of git check-ignore for a
+	//		  repository with the given .gitignore
+	//		- patterns with a slash at the start or in the middle are
+	//		  anchored to the directory of the .gitignore
+	//		- "foo/**" matches the content of foo, but not foo itself
+	//		- other consecutive asterisks (e.g. "foo**" or "***") are
+	//		  regular asterisks
+	_CONFORMANCE = []conformancetest{
+		{"doc/*.txt\n", []match{
+			{"doc/x.txt", "doc/*.txt", true, false},
+			{"a/doc/x.txt", "", false, false},
+			{"doc/sub/x.txt", "", false, false},
+			{"a/b/doc/x.txt", "", false, false},
+		}},
+		{"/doc/*.txt\n", []match{
+			{"doc/x.txt", "/doc/*.txt", true, false},
+			{"a/doc/x.txt", "", false, false},
+		}},
+		{"foo/**\n", []match{
+			{"foo/", "", false, false},
+			{"foo/x", "foo/**", true, false},
+			{"foo/a/b", "foo/**", true, false},
+			{"a/foo/x", "", false, false},
+		}},
+		{"foo/**/\n", []match{
+			{"foo/", "", false, false},
+			{"foo/x", "", false, false},
+			{"foo/a/", "foo/**/", true, false},
+			{"foo/a/b", "foo/**/", true, false},
+		}},
+		{"**/foo\n", []match{
+			{"foo/", "**/foo", true, false},
+			{"a/foo", "**/foo", true, false},
+			{"a/b/foo", "**/foo", true, false},
+			{"xfoo", "", false, false},
+		}},
+		{"**/foo/bar\n", []match{
+			{"foo/bar", "**/foo/bar", true, false},
+			{"a/foo/bar", "**/foo/bar", true, false},
+			{"a/b/foo/bar", "**/foo/bar", true, false},
+			{"foo/x/bar", "", false, false},
+		}},
+		{"a/**/b\n", []match{
+			{"a/b", "a/**/b", true, false},
+			{"a/x/b", "a/**/b", true, false},
+			{"a/x/y/b", "a/**/b", true, false},
+			{"x/a/b", "", false, false},
+			{"a/bb", "", false, false},
+		}},
+		{"***\n", []match{
+			{"x", "***", true, false},
+			{"a/x", "***", true, false},
+			{"a/", "***", true, false},
+		}},
+		{"foo***\n", []match{
+			{"foobar", "foo***", true, false},
+			{"a/foobar", "foo***", true, false},
+			{"foo/x", "foo***", true, false},
+			{"foo/", "foo***", true, false},
+		}},
+		{"a/***/b\n", []match{
+			{"a/b", "a/***/b", true, false},
+			{"a/x/b", "a/***/b", true, false},
+			{"a/x/y/b", "a/***/b", true, false},
+		}},
+		{"**foo\n", []match{
+			{"foo", "**foo", true, false},
+			{"xfoo", "**foo", true, false},
+			{"a/xfoo", "**foo", true, false},
+			{"a/x/foo", "**foo", true, false},
+		}},
+		{"foo**\n", []match{
+			{"foo", "foo**", true, false},
+			{"foox", "foo**", true, false},
+			{"a/foox", "foo**", true, false},
+			{"foo/x", "foo**", true, false},
+		}},
+		{"a**b\n", []match{
+			{"ab", "a**b", true, false},
+			{"axb", "a**b", true, false},
+			{"c/axb", "a**b", true, false},
+			{"a/b", "", false, false},
+		}},
+		{"a/**b\n", []match{
+			{"a/b", "a/**b", true, false},
+			{"a/xb", "a/**b", true, false},
+			{"a/x/b", "", false, false},
+			{"c/a/xb", "", false, false},
+		}},
+		{"a/b**\n", []match{
+			{"a/b", "a/b**", true, false},
+			{"a/bx", "a/b**", true, false},
+			{"a/b/x", "a/b**", true, false},
+			{"c/a/bx", "", false, false},
+		}},
+		{"/**\n", []match{
+			{"x", "/**", true, false},
+			{"a/x", "/**", true, false},
+			{"a/", "/**", true, false},
+		}},
+		{"**\n", []match{
+			{"x", "**", true, false},
+			{"a/x", "**", true, false},
+		}},
+		{"**/\n", []match{
+			{"x", "", false, false},
+			{"a/", "**/", true, false},
+			{"a/b/", "**/", true, false},
+		}},
+		{"a/*/b\n", []match{
+			{"a/x/b", "a/*/b", true, false},
+			{"a/x/y/b", "", false, false},
+			{"c/a/x/b", "", false, false},
+		}},
+		{"*/b\n", []match{
+			{"a/b", "*/b", true, false},
+			{"x/a/b", "", false, false},
+		}},
+		{"foo/\n", []match{
+			{"foo/", "foo/", true, false},
+			{"a/foo/", "foo/", true, false},
+		}},
+		{"*.o\n!keep.o\n", []match{
+			{"x.o", "*.o", true, false},
+			{"a/keep.o", "!keep.o", false, false},
+			{"keep.o", "!keep.o", false, false},
+			{"a.o/", "*.o", true, false},
+		}},
+		{"/\n", []match{
+			{"x", "", false, false},
+			{"a/", "", false, false},
+		}},
+		{"\\!foo\n\\#bar\n", []match{
+			{"!foo", "\\!foo", true, false},
+			{"#bar", "\\#bar", true, false},
+		}},
+		{"foo  \nbar\\ \n", []match{
+			{"foo", "foo", true, false},
+			{"bar ", "bar\\ ", true, false},
+			{"bar", "", false, false},
+		}},
+		{"a/b/\n", []match{
+			{"a/b/", "a/b/", true, false},
+			{"x/a/b/", "", false, false},
+		}},
+		{"*\n!*/\n!*.c\n", []match{
+			{"x", "*", true, false},
+			{"a/", "!*/", false, false},
+			{"a/x.c", "!*.c", false, false},
+			{"a/x.h", "*", true, false},
+		}},
+		{"abc/**/*.txt\n", []match{
+			{"abc/x.txt", "abc/**/*.txt", true, false},
+			{"abc/a/b/x.txt", "abc/**/*.txt", true, false},
+			{"x/abc/x.txt", "", false, false},
+		}},
+		{"**/abc/**\n", []match{
+			{"abc/x", "**/abc/**", true, false},
+			{"a/abc/x", "**/abc/**", true, false},
+			{"abc/", "", false, false},
+			{"a/abc/", "", false, false},
+		}},
+		{"a/**/**/b\n", []match{
+			{"a/b", "a/**/**/b", true, false},
+			{"a/x/b", "a/**/**/b", true, false},
+			{"a/x/y/b", "a/**/**/b", true, false},
+		}},
+		{"data/**\n!data/**/\n!data/keep\n", []match{
+			{"data/x", "data/**", true, false},
+			{"data/keep", "!data/keep", false, false},
+			{"data/a/", "!data/**/", false, false},
+			{"data/a/b", "data/**", true, false},
+		}},
+		{"foo\n", []match{
+			{"foo/", "foo", true, false},
+			{"a/foo", "foo", true, false},
+			{"a/foo/x", "foo", true, false},
+		}},
+		{"[a-c]/x\n", []match{
+			{"a/x", "[a-c]/x", true, false},
+			{"d/x", "", false, false},
+			{"z/a/x", "", false, false},
+		}},
+		{"a\\*b\n", []match{
+			{"a*b", "a\\*b", true, false},
+			{"axb", "", false, false},
+		}},
+		{"a[/]b\n", []match{
+			{"a/b", "", false, false},
+		}},
+		{"?\n", []match{
+			{"a", "?", true, false},
+			{"ab", "", false, false},
+			{"x/a", "?", true, false},
+		}},
+		{"/a?c\n", []match{
+			{"abc", "/a?c", true, false},
+			{"a/c", "", false, false},
+			{"x/abc", "", false, false},
+		}},
+		{"*\n!trail  \n!keep\\ \n", []match{
+			{"trail", "!trail", false, false},
+			{"trail ", "*", true, false},
+			{"trail  ", "*", true, false},
+			{"keep", "*", true, false},
+			{"keep ", "!keep\\ ", false, false},
+		}},
+		{"/foo**/*\n", []match{
+			{"foo/", "/foo**/*", true, false},
+			{"foo/a", "/foo**/*", true, false},
+			{"foox", "/foo**/*", true, false},
+			{"a/foo/", "", false, false},
+		}},
+		{"/ba**r/x\n", []match{
+			{"bar/x", "/ba**r/x", true, false},
+			{"barx/x", "", false, false},
+			{"ba/r/x", "", false, false},
+		}},
+		{"foo**/bar\n", []match{
+			{"foo/bar", "foo**/bar", true, false},
+			{"foox/bar", "foo**/bar", true, false},
+			{"foo/x/bar", "foo**/bar", true, false},
+			{"foox/y/bar", "foo**/bar", true, false},
+			{"a/foo/bar", "", false, false},
+			{"foo/barx", "", false, false},
+		}},
+	}
+
+	// define the .gitignore for the MatchAll tests
+	_TRACEIGNORE = "*.log\n!important.log\n/*.log\ndebug/\n"
+
+	// define the MatchAll tests and their expected results
+	_TRACEMATCHES = []tracetest{
+		{"a.log", []trace{
+			{"a.log", "*.log", "", false},
+			{"a.log", "/*.log", "", true},
+		}},
+		{"important.log", []trace{
+			{"important.log", "*.log", "", false},
+			{"important.log", "!important.log", "", false},
+			{"important.log", "/*.log", "", true},
+		}},
+		{"x/important.log", []trace{
+			{"x/important.log", "*.log", "", false},
+			{"x/important.log", "!important.log", "", true},
+		}},
+		{"debug/", []trace{
+			{"debug", "debug/", "", true},
+		}},
+		{"debug", nil},
+		{"a.txt", nil},
+	}
+
+	// define the repository for the MatchAll tests
+	_TRACEREPOSITORY = map[string]string{
+		".git/HEAD":         "ref: refs/heads/master\n",
+		".git/objects/":     " ",
+		".git/refs/":        " ",
+		".git/info/exclude": "*.log\n",
+		gitignore.File:      "build/\n*.log\n",
+		"src/.gitignore":    "!*.log\nbuild/\n",
+	}
+
+	// define the repository MatchAll tests and their expected results
+	_TRACEREPOSITORYMATCHES = []tracetest{
+		{"a.log", []trace{
+			{"a.log", "*.log", ".git/info/exclude", false},
+			{"a.log", "*.log", gitignore.File, true},
+		}},
+		{"src/a.log", []trace{
+			{"src/a.log", "*.log", ".git/info/exclude", false},
+			{"src/a.log", "*.log", gitignore.File, false},
+			{"src/a.log", "!*.log", "src/.gitignore", true},
+		}},
+		{"build/a.log", []trace{
+			{"build", "build/", gitignore.File, true},
+			{"build/a.log", "*.log", ".git/info/exclude", false},
+			{"build/a.log", "*.log", gitignore.File, false},
+		}},
+		{"src/build/x", []trace{
+			{"src/build", "build/", gitignore.File, false},
+			{"src/build", "build/", "src/.gitignore", true},
+		}},
+		{"src/x", nil},
+	}
+
+	// define the names of the paths of the differential tests
+	_DIFFERENTIALNAMES = []string{
+		"a", "b", "foo", "Foo", "bar", "doc", "x.o", "y.txt", "a.b.c",
+		"sp ace", "#hash", "!bang", "br[ack]et", "st*r", "q?", "ba\\ck",
+		"trail ", "\xe9t\xe9",
+	}
+
+	// define the pattern atoms of the differential tests
+	_DIFFERENTIALATOMS = []string{
+		"*", "**", "***", "?", "[a-c]", "[!a]", "[]]", "*.o", "f*", "*o*",
+		"\\*", "\\ ", "[[:alpha:]]*", "foo**", "**o", "a?b", "\\", "[",
+		"*.[oc]", "\\#hash", "\\!bang", "trail\\ ",
+	}
+
+	// define the wildmatch tests, taken from git's t3070-wildmatch.sh
+	_WILDMATCHTESTS = []wildmatchtest{
+		// basic wildmatch features
+		{"foo", "foo", true, true, true, true},
+		{"foo", "bar", false, false, false, false},
+		{"", "", true, true, true, true},
+		{"foo", "???", true, true, true, true},
+		{"foo", "??", false, false, false, false},
+		{"foo", "*", true, true, true, true},
+		{"foo", "f*", true, true, true, true},
+		{"foo", "*f", false, false, false, false},
+		{"foo", "*foo*", true, true, true, true},
+		{"foobar", "*ob*a*r*", true, true, true, true},
+		{"aaaaaaabababab", "*ab", true, true, true, true},
+		{"foo*", "foo\\*", true, true, true, true},
+		{"foobar", "foo\\*bar", false, false, false, false},
+		{"f\\oo", "f\\\\oo", true, true, true, true},
+		{"ball", "*[al]?", true, true, true, true},
+		{"ten", "[ten]", false, false, false, false},
+		{"ten", "**[!te]", true, true, true, true},
+		{"ten", "**[!ten]", false, false, false, false},
+		{"ten", "t[a-g]n", true, true, true, true},
+		{"ten", "t[!a-g]n", false, false, false, false},
+		{"ton", "t[!a-g]n", true, true, true, true},
+		{"ton", "t[^a-g]n", true, true, true, true},
+		{"a]b", "a[]]b", true, true, true, true},
+		{"a-b", "a[]-]b", true, true, true, true},
+		{"a]b", "a[]-]b", true, true, true, true},
+		{"aab", "a[]-]b", false, false, false, false},
+		{"aab", "a[]a-]b", true, true, true, true},
+		{"]", "]", true, true, true, true},
+
+		// extended slash-matching features
+		{"foo/baz/bar", "foo*bar", false, false, true, true},
+		{"foo/baz/bar", "foo**bar", false, false, true, true},
+		{"foobazbar", "foo**bar", true, true, true, true},
+		{"foo/baz/bar", "foo/**/bar", true, true, true, true},
+		{"foo/baz/bar", "foo/**/**/bar", true, true, false, false},
+		{"foo/b/a/z/bar", "foo/**/bar", true, true, true, true},
+		{"foo/b/a/z/bar", "foo/**/**/bar", true, true, true, true},
+		{"foo/bar", "foo/**/bar", true, true, false, false},
+		{"foo/bar", "foo/**/**/bar", true, true, false, false},
+		{"foo/bar", "foo?bar", false, false, true, true},
+		{"foo/bar", "foo[/]bar", false, false, true, true},
+		{"foo/bar", "foo[^a-z]bar", false, false, true, true},
+		{"foo/bar", "f[^eiu][^eiu][^eiu][^eiu][^eiu]r", false, false, true, true},
+		{"foo-bar", "f[^eiu][^eiu][^eiu][^eiu][^eiu]r", true, true, true, true},
+		{"foo", "**/foo", true, true, false, false},
+		{"XXX/foo", "**/foo", true, true, true, true},
+		{"bar/baz/foo", "**/foo", true, true, true, true},
+		{"bar/baz/foo", "*/foo", false, false, true, true},
+		{"foo/bar/baz", "**/bar*", false, false, true, true},
+		{"deep/foo/bar/baz", "**/bar/*", true, true, true, true},
+		{"deep/foo/bar/baz/", "**/bar/*", false, false, true, true},
+		{"deep/foo/bar/baz/", "**/bar/**", true, true, true, true},
+		{"deep/foo/bar", "**/bar/*", false, false, false, false},
+		{"deep/foo/bar/", "**/bar/**", true, true, true, true},
+		{"foo/bar/baz", "**/bar**", false, false, true, true},
+		{"foo/bar/baz/x", "*/bar/**", true, true, true, true},
+		{"deep/foo/bar/baz/x", "*/bar/**", false, false, true, true},
+		{"deep/foo/bar/baz/x", "**/bar/*/*", true, true, true, true},
+
+		// various additional tests
+		{"acrt", "a[c-c]st", false, false, false, false},
+		{"acrt", "a[c-c]rt", true, true, true, true},
+		{"]", "[!]-]", false, false, false, false},
+		{"a", "[!]-]", true, true, true, true},
+		{"", "\\", false, false, false, false},
+		{"\\", "\\", false, false, false, false},
+		{"XXX/\\", "*/\\", false, false, false, false},
+		{"XXX/\\", "*/\\\\", true, true, true, true},
+		{"foo", "foo", true, true, true, true},
+		{"@foo", "@foo", true, true, true, true},
+		{"foo", "@foo", false, false, false, false},
+		{"[ab]", "\\[ab]", true, true, true, true},
+		{"[ab]", "[[]ab]", true, true, true, true},
+		{"[ab]", "[[:]ab]", true, true, true, true},
+		{"[ab]", "[[::]ab]", false, false, false, false},
+		{"[ab]", "[[:digit]ab]", true, true, true, true},
+		{"[ab]", "[\\[:]ab]", true, true, true, true},
+		{"?a?b", "\\??\\?b", true, true, true, true},
+		{"abc", "\\a\\b\\c", true, true, true, true},
+		{"foo", "", false, false, false, false},
+		{"foo/bar/baz/to", "**/t[o]", true, true, true, true},
+
+		// character class tests
+		{"a1B", "[[:alpha:]][[:digit:]][[:upper:]]", true, true, true, true},
+		{"a", "[[:digit:][:upper:][:space:]]", false, true, false, true},
+		{"A", "[[:digit:][:upper:][:space:]]", true, true, true, true},
+		{"1", "[[:digit:][:upper:][:space:]]", true, true, true, true},
+		{"1", "[[:digit:][:upper:][:spaci:]]", false, false, false, false},
+		{" ", "[[:digit:][:upper:][:space:]]", true, true, true, true},
+		{".", "[[:digit:][:upper:][:space:]]", false, false, false, false},
+		{".", "[[:digit:][:punct:][:space:]]", true, true, true, true},
+		{"5", "[[:xdigit:]]", true, true, true, true},
+		{"f", "[[:xdigit:]]", true, true, true, true},
+		{"D", "[[:xdigit:]]", true, true, true, true},
+		{"_", "[[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:graph:][:lower:][:print:][:punct:][:space:][:upper:][:xdigit:]]", true, true, true, true},
+		{".", "[^[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:lower:][:space:][:upper:][:xdigit:]]", true, true, true, true},
+		{"5", "[a-c[:digit:]x-z]", true, true, true, true},
+		{"b", "[a-c[:digit:]x-z]", true, true, true, true},
+		{"y", "[a-c[:digit:]x-z]", true, true, true, true},
+		{"q", "[a-c[:digit:]x-z]", false, false, false, false},
+
+		// additional tests, including some malformed wildmatch patterns
+		{"]", "[\\\\-^]", true, true, true, true},
+		{"[", "[\\\\-^]", false, false, false, false},
+		{"-", "[\\-_]", true, true, true, true},
+		{"]", "[\\]]", true, true, true, true},
+		{"\\]", "[\\]]", false, false, false, false},
+		{"\\", "[\\]]", false, false, false, false},
+		{"ab", "a[]b", false, false, false, false},
+		{"a[]b", "a[]b", false, false, false, false},
+		{"ab[", "ab[", false, false, false, false},
+		{"ab", "[!", false, false, false, false},
+		{"ab", "[-", false, false, false, false},
+		{"-", "[-]", true, true, true, true},
+		{"-", "[a-", false, false, false, false},
+		{"-", "[!a-", false, false, false, false},
+		{"-", "[--A]", true, true, true, true},
+		{"5", "[--A]", true, true, true, true},
+		{" ", "[ --]", true, true, true, true},
+		{"$", "[ --]", true, true, true, true},
+		{"-", "[ --]", true, true, true, true},
+		{"0", "[ --]", false, false, false, false},
+		{"-", "[---]", true, true, true, true},
+		{"-", "[------]", true, true, true, true},
+		{"j", "[a-e-n]", false, false, false, false},
+		{"-", "[a-e-n]", true, true, true, true},
+		{"a", "[!------]", true, true, true, true},
+		{"[", "[]-a]", false, false, false, false},
+		{"^", "[]-a]", true, true, true, true},
+		{"^", "[!]-a]", false, false, false, false},
+		{"[", "[!]-a]", true, true, true, true},
+		{"^", "[a^bc]", true, true, true, true},
+		{"-b]", "[a-]b]", true, true, true, true},
+		{"\\", "[\\]", false, false, false, false},
+		{"\\", "[\\\\]", true, true, true, true},
+		{"\\", "[!\\\\]", false, false, false, false},
+		{"G", "[A-\\\\]", true, true, true, true},
+		{"aaabbb", "b*a", false, false, false, false},
+		{"aabcaa", "*ba*", false, false, false, false},
+		{",", "[,]", true, true, true, true},
+		{",", "[\\\\,]", true, true, true, true},
+		{"\\", "[\\\\,]", true, true, true, true},
+		{"-", "[,-.]", true, true, true, true},
+		{"+", "[,-.]", false, false, false, false},
+		{"-.]", "[,-.]", false, false, false, false},
+		{"2", "[\\1-\\3]", true, true, true, true},
+		{"3", "[\\1-\\3]", true, true, true, true},
+		{"4", "[\\1-\\3]", false, false, false, false},
+		{"\\", "[[-\\]]", true, true, true, true},
+		{"[", "[[-\\]]", true, true, true, true},
+		{"]", "[[-\\]]", true, true, true, true},
+		{"-", "[[-\\]]", false, false, false, false},
+		{"-adobe-courier-bold-o-normal--12-120-75-75-m-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*", true, true, true, true},
+		{"-adobe-courier-bold-o-normal--12-120-75-75-X-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*", false, false, false, false},
+		{"-adobe-courier-bold-o-normal--12-120-75-75-/-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*", false, false, false, false},
+
+		// test recursion
+		{"XXX/adobe/courier/bold/o/normal//12/120/75/75/m/70/iso8859/1", "XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*", true, true, true, true},
+		{"XXX/adobe/courier/bold/o/normal//12/120/75/75/X/70/iso8859/1", "XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*", false, false, false, false},
+		{"abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txt", "**/*a*b*g*n*t", true, true, true, true},
+		{"abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txtz", "**/*a*b*g*n*t", false, false, false, false},
+		{"foo", "*/*/*", false, false, false, false},
+		{"foo/bar", "*/*/*", false, false, false, false},
+		{"foo/bba/arr", "*/*/*", true, true, true, true},
+		{"foo/bb/aa/rr", "*/*/*", false, false, true, true},
+		{"foo/bb/aa/rr", "**/**/**", true, true, true, true},
+		{"abcXdefXghi", "*X*i", true, true, true, true},
+		{"ab/cXd/efXg/hi", "*X*i", false, false, true, true},
+		{"ab/cXd/efXg/hi", "*/*X*/*/*i", true, true, true, true},
+		{"ab/cXd/efXg/hi", "**/*X*/**/*i", true, true, true, true},
+		{"foo", "fo", false, false, false, false},
+		{"foo/bar", "foo/bar", true, true, true, true},
+		{"foo/bar", "foo/*", true, true, true, true},
+		{"foo/bba/arr", "foo/*", false, false, true, true},
+
+		// extra pathmatch tests
+		{"foo/bba/arr", "foo/**", true, true, true, true},
+		{"foo/bba/arr", "foo*", false, false, true, true},
+		{"foo/bba/arr", "foo**", false, false, true, true},
+		{"foo/bba/arr", "foo/*arr", false, false, true, true},
+		{"foo/bba/arr", "foo/**arr", false, false, true, true},
+		{"foo/bba/arr", "foo/*z", false, false, false, false},
+		{"foo/bba/arr", "foo/**z", false, false, false, false},
+		{"foo/bar", "foo?bar", false, false, true, true},
+		{"foo/bar", "foo[/]bar", false, false, true, true},
+		{"foo/bar", "foo[^a-z]bar", false, false, true, true},
+		{"ab/cXd/efXg/hi", "*Xg*i", false, false, true, true},
+		{"a", "[A-Z]", false, true, false, true},
+		{"A", "[A-Z]", true, true, true, true},
+		{"A", "[a-z]", false, true, false, true},
+		{"a", "[a-z]", true, true, true, true},
+
+		// extra case-sensitivity tests
+		{"a", "[[:upper:]]", false, true, false, true},
+		{"A", "[[:upper:]]", true, true, true, true},
+		{"A", "[[:lower:]]", false, true, false, true},
+		{"a", "[[:lower:]]", true, true, true, true},
+		{"A", "[B-Za]", false, true, false, true},
+		{"a", "[B-Za]", true, true, true, true},
+		{"A", "[B-a]", false, true, false, true},
+		{"a", "[B-a]", true, true, true, true},
+		{"z", "[Z-y]", false, true, false, true},
+		{"Z", "[Z-y]", true, true, true, true},
+		{"A", "\\A", true, false, true, false},
+		{"a", "\\A", false, false, false, false},
+		{"a", "[A]", false, false, false, false},
+		{"B", "[b]", false, true, false, true},
+	}
 )
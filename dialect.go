@@ -0,0 +1,108 @@
+package gitignore
+
+import (
+	"io"
+	"sync"
+)
+
+// Dialect defines the syntax and semantics of a family of ignore files, such
+// as .gitignore files, allowing ignore files that are variants of .gitignore
+// (e.g. .npmignore, .helmignore or .vercelignore) to be read by the same
+// Lexer, Parser and Pattern implementations. GitDialect defines the rules of
+// .gitignore files, and may be embedded by other dialects that differ from
+// .gitignore in only some of their rules.
+type Dialect interface {
+	// Lexer returns the Lexer for the tokens of the ignore file read from r.
+	Lexer(r io.Reader) Lexer
+
+	// Anchored returns true if the pattern given by tokens matches paths
+	// relative to the directory of its ignore file, rather than matching
+	// the trailing components of paths. The tokens exclude any leading
+	// negation, but include any leading or trailing separator.
+	Anchored(tokens []*Token) bool
+
+	// Negation returns true if a leading "!" negates a pattern, otherwise
+	// "!" is treated as part of the pattern.
+	Negation() bool
+
+	// Directory returns true if a trailing "/" restricts a pattern to
+	// directories, otherwise the trailing "/" is dropped from the pattern.
+	Directory() bool
+
+	// PerDirectory returns true if a repository loads the ignore files of
+	// every directory, otherwise only the ignore file of the root directory
+	// of the repository is loaded.
+	PerDirectory() bool
+} // Dialect{}
+
+// gitdialect is the Dialect of .gitignore files
+type gitdialect struct{}
+
+// GitDialect is the Dialect of .gitignore files, and is the default Dialect
+// for ignore files that have no registered Dialect.
+var GitDialect Dialect = gitdialect{}
+
+// the registered dialects, keyed by ignore file name
+var (
+	_DIALECTS    = make(map[string]Dialect)
+	_DIALECTLOCK sync.RWMutex
+)
+
+// RegisterDialect registers dialect as the Dialect of ignore files named file
+// (e.g. ".npmignore"), so that repositories created with NewRepositoryWithFile
+// and similar functions read these files using dialect. If dialect is nil,
+// the registration for file is removed.
+func RegisterDialect(file string, dialect Dialect) {
+	_DIALECTLOCK.Lock()
+	defer _DIALECTLOCK.Unlock()
+
+	if dialect == nil {
+		delete(_DIALECTS, file)
+	} else {
+		_DIALECTS[file] = dialect
+	}
+} // RegisterDialect()
+
+// DialectFor returns the Dialect registered for ignore files named file, or
+// GitDialect if no Dialect has been registered for file.
+func DialectFor(file string) Dialect {
+	_DIALECTLOCK.RLock()
+	defer _DIALECTLOCK.RUnlock()
+
+	_dialect, _ok := _DIALECTS[file]
+	if !_ok {
+		return GitDialect
+	}
+
+	return _dialect
+} // DialectFor()
+
+// Lexer returns the .gitignore Lexer for r.
+func (g gitdialect) Lexer(r io.Reader) Lexer { return NewLexer(r) }
+
+// Anchored returns true if the pattern has a separator at its start or
+// within the pattern, since .gitignore patterns that only have a trailing
+// separator match names at any depth.
+func (g gitdialect) Anchored(tokens []*Token) bool {
+	for _i, _token := range tokens {
+		if _token.Type == SEPARATOR && _i < len(tokens)-1 {
+			return true
+		}
+	}
+
+	return false
+} // Anchored()
+
+// Negation returns true, since .gitignore patterns may be negated.
+func (g gitdialect) Negation() bool { return true }
+
+// Directory returns true, since a trailing separator restricts .gitignore
+// patterns to directories.
+func (g gitdialect) Directory() bool { return true }
+
+// PerDirectory returns true, since git reads the .gitignore files of every
+// directory.
+func (g gitdialect) PerDirectory() bool { return true }
+
+// ensure gitdialect satisfies the Dialect interface
+var _ Dialect = gitdialect{}
@@ -0,0 +1,73 @@
+package gitignore_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+// rootdialect is the Dialect of root-only ignore files, where "!" is not
+// special, patterns are only anchored by a leading separator, and trailing
+// separators are ignored
+type rootdialect struct {
+	gitignore.Dialect
+}
+
+func (r rootdialect) Anchored(tokens []*gitignore.Token) bool {
+	return tokens[0].Type == gitignore.SEPARATOR
+} // Anchored()
+
+func (r rootdialect) Negation() bool     { return false }
+func (r rootdialect) Directory() bool    { return false }
+func (r rootdialect) PerDirectory() bool { return false }
+
+func TestNewWithDialect(t *testing.T) {
+	_dialect := rootdialect{gitignore.GitDialect}
+	_reader := strings.NewReader(_DIALECTIGNORE)
+	_ignore := gitignore.NewWithDialect(_reader, "/base", _dialect, nil)
+
+	// ensure the paths are matched according to the dialect
+	for _, _match := range _DIALECTMATCHES {
+		do(t, _ignore.Relative, _match)
+	}
+
+	// ensure the default dialect follows the .gitignore rules
+	_reader = strings.NewReader(_DIALECTIGNORE)
+	_ignore = gitignore.NewWithDialect(_reader, "/base", nil, nil)
+	do(t, _ignore.Relative, match{"literal", "!literal", false, false})
+	do(t, _ignore.Relative, match{"x/a/b", "", false, false})
+	do(t, _ignore.Relative, match{"build", "", false, false})
+} // TestNewWithDialect()
+
+func TestRepositoryDialect(t *testing.T) {
+	// create the repository for the dialect tests
+	_dir, _err := dir(_DIALECTREPOSITORY)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// register the dialect for the ignore files of the repository
+	gitignore.RegisterDialect(".rootignore", rootdialect{gitignore.GitDialect})
+	defer gitignore.RegisterDialect(".rootignore", nil)
+
+	_repository, _err := gitignore.NewRepositoryWithFile(_dir, ".rootignore")
+	if _err != nil {
+		t.Fatalf("unable to create repository: %s", _err.Error())
+	}
+
+	// ensure only the ignore file of the root directory is used
+	for _, _match := range _DIALECTREPOSITORYMATCHES {
+		do(t, _repository.Relative, _match)
+	}
+
+	// once the dialect is removed, the ignore files are read as .gitignore
+	gitignore.RegisterDialect(".rootignore", nil)
+	_repository, _err = gitignore.NewRepositoryWithFile(_dir, ".rootignore")
+	if _err != nil {
+		t.Fatalf("unable to create repository: %s", _err.Error())
+	}
+	do(t, _repository.Relative, match{"sub/a.txt", "*.txt", true, false})
+} // TestRepositoryDialect()
@@ -0,0 +1,308 @@
+package gitignore_test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+// TestDifferential compares the matches of repository GitIgnore instances
+// with the output of git check-ignore for randomly generated work trees.
+// Each disagreement is minimized before it is reported. The test is skipped
+// if git is not installed.
+func TestDifferential(t *testing.T) {
+	if _, _err := exec.LookPath("git"); _err != nil {
+		t.Skip("git not found: skipping differential tests")
+	}
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	_trees := _DIFFERENTIALTREES
+	if testing.Short() {
+		_trees = _DIFFERENTIALSHORTTREES
+	}
+	for _seed := 0; _seed < _trees; _seed++ {
+		_tree := grow(rand.New(rand.NewSource(int64(_seed))))
+
+		// compare the matches of git and the repository
+		_git, _ours, _err := differences(_tree, _tree.Paths)
+		if _err != nil {
+			t.Fatalf("seed %d: %s", _seed, _err.Error())
+		}
+
+		// report the minimized disagreement for each path
+		for _, _path := range _tree.Paths {
+			if _git[_path] == _ours[_path] {
+				continue
+			}
+			_minimized := minimize(_tree, _path)
+			t.Errorf(
+				"seed %d: git check-ignore disagreement for %q\n%s"+
+					"\tgit:          %q\n\tgo-gitignore: %q",
+				_seed, _path, _minimized, _git[_path], _ours[_path],
+			)
+		}
+	}
+} // TestDifferential()
+
+// grow returns a random work tree, with a .gitignore in the root directory,
+// and in some of the subdirectories of the tree.
+func grow(r *rand.Rand) worktree {
+	_tree := worktree{Ignores: make(map[string][]string)}
+	_seen := make(map[string]bool)
+
+	var _grow func(dir string, depth int)
+	_grow = func(dir string, depth int) {
+		// add the files and subdirectories of this directory
+		_entries := 1 + r.Intn(4)
+		for _i := 0; _i < _entries; _i++ {
+			_path := dir + _DIFFERENTIALNAMES[r.Intn(len(_DIFFERENTIALNAMES))]
+			if _seen[_path] {
+				continue
+			}
+			_seen[_path] = true
+
+			if depth < _DIFFERENTIALDEPTH && r.Intn(3) == 0 {
+				_tree.Paths = append(_tree.Paths, _path+"/")
+				_grow(_path+"/", depth+1)
+			} else {
+				_tree.Paths = append(_tree.Paths, _path)
+			}
+		}
+
+		// add the .gitignore for this directory
+		if dir != "" && r.Intn(2) == 0 {
+			return
+		}
+		_lines := 1 + r.Intn(5)
+		for _i := 0; _i < _lines; _i++ {
+			_tree.Ignores[dir] = append(_tree.Ignores[dir], line(r))
+		}
+	}
+	_grow("", 0)
+
+	return _tree
+} // grow()
+
+// line returns a random .gitignore line, built from the names and pattern
+// atoms of the differential tests.
+func line(r *rand.Rand) string {
+	switch r.Intn(16) {
+	case 0:
+		return ""
+	case 1:
+		return "# comment"
+	}
+
+	_line := ""
+	if r.Intn(4) == 0 {
+		_line += "!"
+	}
+	if r.Intn(4) == 0 {
+		_line += "/"
+	}
+	_components := 1 + r.Intn(3)
+	for _i := 0; _i < _components; _i++ {
+		if _i > 0 {
+			_line += "/"
+		}
+		if r.Intn(2) == 0 {
+			_line += _DIFFERENTIALNAMES[r.Intn(len(_DIFFERENTIALNAMES))]
+		} else {
+			_line += _DIFFERENTIALATOMS[r.Intn(len(_DIFFERENTIALATOMS))]
+		}
+	}
+	if r.Intn(4) == 0 {
+		_line += "/"
+	}
+	if r.Intn(8) == 0 {
+		_line += " "
+	}
+
+	return _line
+} // line()
+
+// String returns the .gitignore files of the work tree.
+func (w worktree) String() string {
+	_dirs := make([]string, 0, len(w.Ignores))
+	for _dir := range w.Ignores {
+		_dirs = append(_dirs, _dir)
+	}
+	sort.Strings(_dirs)
+
+	_string := ""
+	for _, _dir := range _dirs {
+		_string += fmt.Sprintf("\t%s%s:\n", _dir, gitignore.File)
+		for _, _line := range w.Ignores[_dir] {
+			_string += fmt.Sprintf("\t\t%q\n", _line)
+		}
+	}
+
+	return _string
+} // String()
+
+// differences creates the work tree in a temporary directory, and returns
+// the matches of git check-ignore and of the repository GitIgnore for each of
+// the given paths. Matches are reported as "file:line:pattern", in the form
+// of git check-ignore, or as the empty string for unmatched paths.
+func differences(tree worktree, paths []string) (map[string]string, map[string]string, error) {
+	// create the work tree
+	_content := make(map[string]string)
+	for _, _path := range tree.Paths {
+		_content[_path] = "content"
+	}
+	for _dir, _lines := range tree.Ignores {
+		_content[_dir+gitignore.File] = strings.Join(_lines, "\n") + "\n"
+	}
+	_dir, _err := dir(_content)
+	if _err != nil {
+		return nil, nil, _err
+	}
+	defer os.RemoveAll(_dir)
+
+	// match the paths with git
+	_, _err = git(_dir, "", "init", "-q")
+	if _err != nil {
+		return nil, nil, _err
+	}
+	_input := make([]string, len(paths))
+	for _i, _path := range paths {
+		_input[_i] = strings.TrimSuffix(_path, "/")
+	}
+	_output, _err := git(_dir, strings.Join(_input, "\x00")+"\x00",
+		"check-ignore", "--no-index", "-v", "-n", "-z", "--stdin",
+	)
+	if _err != nil {
+		return nil, nil, _err
+	}
+	_git := make(map[string]string)
+	for _i := 0; _i+3 < len(_output); _i += 4 {
+		if _output[_i] != "" {
+			_git[_output[_i+3]] = strings.Join(_output[_i:_i+3], ":")
+		}
+	}
+
+	// match the paths with the repository
+	_options := gitignore.Options{NoGlobal: true}
+	_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		return nil, nil, fmt.Errorf("unable to create repository for %q", _dir)
+	}
+	_ours := make(map[string]string)
+	for _i, _path := range paths {
+		_match := _repository.Relative(_input[_i], strings.HasSuffix(_path, "/"))
+		if _match == nil {
+			continue
+		}
+		_position := _match.Position()
+		_file, _err := filepath.Rel(_dir, _position.File)
+		if _err != nil {
+			return nil, nil, _err
+		}
+		_ours[_path] = fmt.Sprintf(
+			"%s:%d:%s", filepath.ToSlash(_file), _position.Line, _match.String(),
+		)
+	}
+
+	// the git matches are keyed by the paths without a trailing "/"
+	for _i, _path := range paths {
+		if _match, _ok := _git[_input[_i]]; _ok && _path != _input[_i] {
+			delete(_git, _input[_i])
+			_git[_path] = _match
+		}
+	}
+
+	return _git, _ours, nil
+} // differences()
+
+// git runs git in the directory dir, isolated from the configuration of the
+// user, with the given standard input, returning the NUL-separated fields of
+// its standard output. git check-ignore exits with status 1 if no path is
+// ignored, which is not reported as an error.
+func git(dir, input string, args ...string) ([]string, error) {
+	_env := []string{"HOME=" + dir, "XDG_CONFIG_HOME=" + dir}
+	for _, _var := range os.Environ() {
+		if !strings.HasPrefix(_var, "GIT_") &&
+			!strings.HasPrefix(_var, "HOME=") &&
+			!strings.HasPrefix(_var, "XDG_CONFIG_HOME=") {
+			_env = append(_env, _var)
+		}
+	}
+	_env = append(_env, "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+
+	_cmd := exec.Command("git", args...)
+	_cmd.Dir = dir
+	_cmd.Env = _env
+	_cmd.Stdin = strings.NewReader(input)
+	_output, _err := _cmd.Output()
+	if _exit, _ok := _err.(*exec.ExitError); _ok && _exit.ExitCode() == 1 {
+		_err = nil
+	}
+	if _err != nil {
+		return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), _err.Error())
+	}
+
+	return strings.Split(string(_output), "\x00"), nil
+} // git()
+
+// minimize returns the smallest work tree found for which git and the
+// repository disagree on the match of path, by removing the paths and
+// .gitignore lines of tree that do not contribute to the disagreement.
+func minimize(tree worktree, path string) worktree {
+	// disagree returns true if the tree still exhibits the disagreement
+	disagree := func(tree worktree) bool {
+		_git, _ours, _err := differences(tree, []string{path})
+		return _err == nil && _git[path] != _ours[path]
+	}
+
+	// only the ancestors of path, and their .gitignore files, may
+	// determine the match of path
+	_minimized := worktree{Ignores: make(map[string][]string)}
+	for _, _path := range tree.Paths {
+		if _path == path || strings.HasPrefix(path, _path) {
+			_minimized.Paths = append(_minimized.Paths, _path)
+		}
+	}
+	for _dir, _lines := range tree.Ignores {
+		if strings.HasPrefix(path, _dir) {
+			_minimized.Ignores[_dir] = append([]string{}, _lines...)
+		}
+	}
+	if !disagree(_minimized) {
+		return tree
+	}
+
+	// remove .gitignore lines while the disagreement remains
+	for _removed := true; _removed; {
+		_removed = false
+		for _dir, _lines := range _minimized.Ignores {
+			for _i := len(_lines) - 1; _i >= 0; _i-- {
+				_lines := _minimized.Ignores[_dir]
+				_candidate := append(append([]string{}, _lines[:_i]...), _lines[_i+1:]...)
+				_minimized.Ignores[_dir] = _candidate
+				if disagree(_minimized) {
+					_removed = true
+				} else {
+					_minimized.Ignores[_dir] = _lines
+				}
+			}
+		}
+	}
+
+	return _minimized
+} // minimize()
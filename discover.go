@@ -0,0 +1,200 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// OpenRepository returns the Repository enclosing path, which may be any file
+// or directory within the work tree of the repository. The root of the work
+// tree (given by Repository.Base) and the repository GIT_DIR (given by
+// Repository.GitDir) are discovered by searching path and its parent
+// directories for a .git directory or file, as performed by git. The search
+// honours the GIT_DIR, GIT_WORK_TREE, GIT_COMMON_DIR and
+// GIT_CEILING_DIRECTORIES environment variables, and the core.worktree and
+// core.bare configuration variables.
+//
+// If no repository encloses path, OpenRepository returns NotRepositoryError.
+// If the enclosing repository is a bare repository, OpenRepository returns
+// NoWorkTreeError.
+//
+// Internally, OpenRepository uses OpenRepositoryWithOptions.
+func OpenRepository(path string) (Repository, error) {
+	return OpenRepositoryWithOptions(path, Options{Cache: NewCache()})
+} // OpenRepository()
+
+// OpenRepositoryWithOptions returns the Repository enclosing path, as with
+// OpenRepository, configured by options. Errors encountered while opening
+// the repository are returned, while options.Errors (if defined) will be
+// invoked for errors encountered while matching paths against the returned
+// Repository. If options.Environment is given, it is used in place of the
+// environment variables of the current process.
+func OpenRepositoryWithOptions(path string, options Options) (Repository, error) {
+	// extract the absolute path of the starting point of the search
+	_path, _err := filepath.Abs(path)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// start the search from the directory containing path
+	_dir := _path
+	_info, _err := os.Stat(_dir)
+	if _err != nil {
+		return nil, _err
+	} else if !_info.IsDir() {
+		_dir = filepath.Dir(_dir)
+	}
+
+	// attempt to discover the enclosing repository
+	//		- relative paths of the environment are interpreted relative
+	//		  to the starting directory
+	_options := options
+	_options.Environment = options.environment().resolve(_dir)
+	_base, _gitdir, _err := discover(_dir, _options.Environment)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// create the repository instance
+	_repository, _err := newRepository(_base, _gitdir, _options)
+	if _err != nil {
+		return nil, _err
+	}
+
+	return _repository, nil
+} // OpenRepositoryWithOptions()
+
+// discover returns the root of the work tree and the GIT_DIR of the
+// repository enclosing the absolute directory dir, within the git environment
+// env.
+func discover(dir string, env *Environment) (string, string, error) {
+	_dir := dir
+
+	// if GIT_DIR is defined, there is no search
+	//		- in the absence of an explicit work tree, the starting
+	//		  directory is the root of the work tree
+	_gitdir := env.GitDir
+	if _gitdir != "" {
+		// GIT_DIR may refer to a .git file
+		_info, _err := os.Stat(_gitdir)
+		if _err != nil {
+			if os.IsNotExist(_err) {
+				return "", "", NotRepositoryError
+			}
+			return "", "", _err
+		} else if !_info.IsDir() {
+			_gitdir, _err = gitfile(_gitdir)
+			if _err != nil {
+				return "", "", _err
+			}
+		}
+		if _gitdir == "" || !valid(_gitdir, env.CommonDir) {
+			return "", "", NotRepositoryError
+		}
+
+		_base, _err := worktree(_gitdir, _dir, env)
+		return _base, _gitdir, _err
+	}
+
+	// search the parent directories for the repository, stopping before
+	// entering any ceiling directory
+	_ceilings := env.ceilings()
+	for {
+		// is there a .git directory or file in this directory?
+		_dotgit := filepath.Join(_dir, ".git")
+		_info, _err := os.Stat(_dotgit)
+		if _err == nil {
+			_gitdir := _dotgit
+			if !_info.IsDir() {
+				_gitdir, _err = gitfile(_dotgit)
+				if _err != nil {
+					return "", "", _err
+				}
+			}
+			if _gitdir != "" && valid(_gitdir, env.CommonDir) {
+				_base, _err := worktree(_gitdir, _dir, env)
+				return _base, _gitdir, _err
+			}
+		} else if !os.IsNotExist(_err) {
+			return "", "", _err
+		}
+
+		// is this directory a bare repository?
+		if valid(_dir, env.CommonDir) {
+			_base, _err := worktree(_dir, "", env)
+			return _base, _dir, _err
+		}
+
+		// move to the parent directory
+		_parent := filepath.Dir(_dir)
+		if _parent == _dir || _ceilings[_parent] {
+			return "", "", NotRepositoryError
+		}
+		_dir = _parent
+	}
+} // discover()
+
+// worktree returns the root of the work tree for the given gitdir. The work
+// tree is given by the WorkTree of env, or the
+// core.worktree configuration variable, and otherwise defaults to dir. If
+// the repository is bare, or dir is empty, and no work tree has been
+// specified, worktree returns NoWorkTreeError.
+func worktree(gitdir, dir string, env *Environment) (string, error) {
+	// has the work tree been given explicitly?
+	if env.WorkTree != "" {
+		return filepath.Clean(env.WorkTree), nil
+	}
+
+	// has the work tree been configured?
+	_common, _err := env.common(gitdir)
+	if _err != nil {
+		return "", _err
+	}
+	_config, _err := newConfig(_common)
+	if _err != nil {
+		return "", _err
+	}
+	_worktree, _ok := _config.get("core.worktree")
+	if _ok && _worktree != "" {
+		if !filepath.IsAbs(_worktree) {
+			_worktree = filepath.Join(gitdir, _worktree)
+		}
+		return filepath.Clean(_worktree), nil
+	}
+
+	// is this a bare repository?
+	_bare, _ := _config.bool("core.bare")
+	if _bare || dir == "" {
+		return "", NoWorkTreeError
+	}
+
+	return dir, nil
+} // worktree()
+
+// valid returns true if gitdir appears to be a git directory (i.e. it
+// contains HEAD, and objects/ and refs/ directories, located in the common
+// directory common if given, and otherwise in the common directory of
+// gitdir).
+func valid(gitdir, common string) bool {
+	_info, _err := os.Stat(filepath.Join(gitdir, "HEAD"))
+	if _err != nil || _info.IsDir() {
+		return false
+	}
+
+	// objects/ and refs/ may be located in the common directory
+	_common := common
+	if _common == "" {
+		_common, _err = commondir(gitdir)
+		if _err != nil {
+			return false
+		}
+	}
+	for _, _name := range []string{"objects", "refs"} {
+		_info, _err := os.Stat(filepath.Join(_common, _name))
+		if _err != nil || !_info.IsDir() {
+			return false
+		}
+	}
+
+	return true
+} // valid()
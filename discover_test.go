@@ -0,0 +1,122 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestOpenRepository(t *testing.T) {
+	// create the repository for the discovery tests
+	_dir, _err := dir(_GITDISCOVER)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the current environment
+	_gitdir := filepath.Join(_dir, ".git")
+	_env := map[string]string{
+		"GIT_DIR":                 "",
+		"GIT_WORK_TREE":           "",
+		"GIT_CEILING_DIRECTORIES": "",
+	}
+	_restore, _err := setenv(_env)
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// ensure the repository is discovered from any path within the work tree
+	for _, _path := range []string{"", "a", "a/b/c", gitignore.File} {
+		_start := filepath.Join(_dir, filepath.FromSlash(_path))
+		_repository, _err := gitignore.OpenRepository(_start)
+		if _err != nil {
+			t.Fatalf("unable to open repository from %q: %s", _start, _err)
+		}
+		opened(t, _repository, _dir, _gitdir)
+	}
+
+	// ensure GIT_DIR and GIT_WORK_TREE take precedence over discovery
+	_, _err = setenv(map[string]string{
+		"GIT_DIR":       _gitdir,
+		"GIT_WORK_TREE": _dir,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	_repository, _err := gitignore.OpenRepository(os.TempDir())
+	if _err != nil {
+		t.Fatalf("unable to open repository from GIT_DIR: %s", _err)
+	}
+	opened(t, _repository, _dir, _gitdir)
+
+	// ensure GIT_DIR without GIT_WORK_TREE uses the starting directory
+	_, _err = setenv(map[string]string{"GIT_WORK_TREE": ""})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	_repository, _err = gitignore.OpenRepository(_dir)
+	if _err != nil {
+		t.Fatalf("unable to open repository from GIT_DIR: %s", _err)
+	}
+	opened(t, _repository, _dir, _gitdir)
+
+	// ensure the search stops at ceiling directories
+	_, _err = setenv(map[string]string{
+		"GIT_DIR":                 "",
+		"GIT_CEILING_DIRECTORIES": _dir,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	_repository, _err = gitignore.OpenRepository(filepath.Join(_dir, "a"))
+	if _err != gitignore.NotRepositoryError {
+		t.Errorf(
+			"ceiling directory error mismatch; expected %q, got %v",
+			gitignore.NotRepositoryError, _err,
+		)
+	} else if _repository != nil {
+		t.Errorf("unexpected repository beyond ceiling directory")
+	}
+
+	// the ceiling does not prevent discovery from the work tree root
+	_repository, _err = gitignore.OpenRepository(_dir)
+	if _err != nil {
+		t.Fatalf("unable to open repository at ceiling: %s", _err)
+	}
+	opened(t, _repository, _dir, _gitdir)
+
+	// ensure bare repositories are reported as having no work tree
+	_repository, _err = gitignore.OpenRepository(filepath.Join(_dir, "bare"))
+	if _err != gitignore.NoWorkTreeError {
+		t.Errorf(
+			"bare repository error mismatch; expected %q, got %v",
+			gitignore.NoWorkTreeError, _err,
+		)
+	}
+} // TestOpenRepository()
+
+func opened(t *testing.T, r gitignore.Repository, base, gitdir string) {
+	// ensure the discovered root and GIT_DIR are as expected
+	if r.Base() != base {
+		t.Errorf("repository.Base() mismatch; expected %q, got %q",
+			base, r.Base(),
+		)
+	}
+	if r.GitDir() != gitdir {
+		t.Errorf("repository.GitDir() mismatch; expected %q, got %q",
+			gitdir, r.GitDir(),
+		)
+	}
+
+	// ensure the repository matches as expected
+	_cb := func(path string, isdir bool) gitignore.Match {
+		return r.Relative(path, isdir)
+	}
+	for _, _match := range _DISCOVERMATCHES {
+		do(t, _cb, _match)
+	}
+} // opened()
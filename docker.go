@@ -0,0 +1,569 @@
+package gitignore
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// define the names of the Docker ignore file, which is also the suffix of
+// per-Dockerfile ignore files (e.g. Dockerfile.dockerignore), and the default
+// Dockerfile
+const (
+	DockerIgnoreFile = ".dockerignore"
+	Dockerfile       = "Dockerfile"
+)
+
+// define the regular expression characters escaped when compiling Docker
+// patterns, since they have no meaning in Docker patterns. As with Docker,
+// other regular expression syntax (such as "^" or "[a-z]") is passed through.
+const _DOCKERESCAPE = ".+()|{}$"
+
+// define the UTF-8 byte order mark, which is stripped from the start of
+// Docker ignore files
+var _BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// DockerOptions defines the configuration of a Docker ignore matcher created
+// by NewDockerIgnore or NewDockerIgnoreFromContext.
+type DockerOptions struct {
+	// Dockerfile is the path of the Dockerfile, relative to the root of the
+	// build context. If Dockerfile is the empty string, "Dockerfile" is
+	// used.
+	Dockerfile string
+
+	// Stdin indicates the Dockerfile is not read from the build context
+	// (e.g. "docker build -f - ."), so the Dockerfile is not exempt from
+	// the ignore patterns.
+	Stdin bool
+
+	// Errors, if defined, will be invoked for each error encountered while
+	// reading the ignore patterns, or while matching a path.
+	Errors func(Error) bool
+} // DockerOptions{}
+
+// docker is the implementation of a GitIgnore following the semantics of
+// Docker ignore files
+type docker struct {
+	ignore
+	_patterns []*dockerpattern
+} // docker{}
+
+// dockerpattern represents a single pattern of a Docker ignore file
+type dockerpattern struct {
+	_pattern   string
+	_exclusion bool
+	_kind      int
+	_regexp    *regexp.Regexp
+	_position  Position
+} // dockerpattern{}
+
+// define the ways in which a Docker pattern may be matched
+const (
+	_DOCKEREXACT = iota
+	_DOCKERPREFIX
+	_DOCKERSUFFIX
+	_DOCKERREGEXP
+)
+
+// NewDockerIgnore returns a GitIgnore for the Docker ignore patterns read
+// from r, for the build context with root directory base. Docker ignore files
+// differ from .gitignore files:
+//
+//   - every pattern is anchored at the root of the build context, and
+//     leading and trailing whitespace, and leading "/", are removed
+//   - patterns are cleaned (i.e. "a//b/../c" is "a/c"), so a trailing "/"
+//     has no meaning
+//   - "*" and "?" do not match "/", "**" matches any number of directories,
+//     and "[...]" character classes follow Go regular expression syntax
+//   - a path is matched if the pattern matches the path or any of its parent
+//     directories
+//   - patterns are applied in order, with "!" exceptions able to include
+//     paths within an excluded directory
+//   - lines starting with "#" are comments, and there are no per-directory
+//     ignore files
+//
+// As with the docker CLI, the .dockerignore file and the Dockerfile (given
+// by options.Dockerfile) are never ignored, unless options.Stdin is set, in
+// which case only the .dockerignore file is exempt.
+//
+// If options.Errors is given, it will be invoked for every invalid pattern,
+// which is otherwise ignored (where Docker would fail the build).
+func NewDockerIgnore(r io.Reader, base string, options DockerOptions) GitIgnore {
+	// do we have an error handler?
+	_errors := options.Errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	_docker := &docker{ignore: ignore{_base: base, _errors: _errors}}
+	_err := _docker.read(r, "")
+	if _err != nil {
+		_errors(NewError(_err, Position{}))
+	}
+	_docker.exempt(options)
+
+	return _docker
+} // NewDockerIgnore()
+
+// NewDockerIgnoreFromContext returns a GitIgnore for the build context with
+// root directory context. As with BuildKit, if there is an ignore file named
+// after the Dockerfile, located alongside the Dockerfile (e.g.
+// "build/Dockerfile.dockerignore" for the Dockerfile "build/Dockerfile"),
+// it is used in preference to the .dockerignore file at the root of the
+// context. If neither file exists, no paths are ignored.
+//
+// An error is returned if context is not a directory, or the ignore file
+// cannot be read.
+func NewDockerIgnoreFromContext(context string, options DockerOptions) (GitIgnore, error) {
+	// do we have an error handler?
+	_errors := options.Errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	// ensure the given context is a directory
+	_base, _err := filepath.Abs(context)
+	if _err != nil {
+		return nil, _err
+	}
+	_info, _err := os.Stat(_base)
+	if _err != nil {
+		return nil, _err
+	} else if !_info.IsDir() {
+		return nil, InvalidDirectoryError
+	}
+
+	// prefer the ignore file of the Dockerfile
+	_dockerfile := options.Dockerfile
+	if _dockerfile == "" {
+		_dockerfile = Dockerfile
+	}
+	if !filepath.IsAbs(_dockerfile) {
+		_dockerfile = filepath.Join(_base, _dockerfile)
+	}
+	_files := []string{
+		_dockerfile + DockerIgnoreFile,
+		filepath.Join(_base, DockerIgnoreFile),
+	}
+
+	_docker := &docker{ignore: ignore{_base: _base, _errors: _errors}}
+	for _, _file := range _files {
+		_fh, _err := os.Open(_file)
+		if _err != nil {
+			if os.IsNotExist(_err) {
+				continue
+			}
+			return nil, _err
+		}
+		defer _fh.Close()
+
+		_err = _docker.read(_fh, _file)
+		if _err != nil {
+			return nil, _err
+		}
+		break
+	}
+
+	// the Dockerfile is identified relative to the context
+	_options := options
+	_options.Dockerfile = ""
+	_rel, _err := filepath.Rel(_base, _dockerfile)
+	if _err == nil && !strings.HasPrefix(_rel, "..") {
+		_options.Dockerfile = _rel
+	} else {
+		_options.Stdin = true
+	}
+	_docker.exempt(_options)
+
+	return _docker, nil
+} // NewDockerIgnoreFromContext()
+
+// read parses the Docker ignore patterns from r, with positions reported
+// against file.
+func (d *docker) read(r io.Reader, file string) error {
+	_scanner := bufio.NewScanner(r)
+	_position := Position{File: file}
+	_next := 0
+	for _scanner.Scan() {
+		_bytes := _scanner.Bytes()
+		_position.Line++
+		_position.Column = 1
+		_position.Offset = _next
+		_next += len([]rune(string(_bytes))) + 1
+
+		// strip the byte order mark from the start of the file
+		if _position.Line == 1 {
+			_bytes = bytes.TrimPrefix(_bytes, _BOM)
+		}
+
+		// lines starting with "#" are comments
+		_line := string(_bytes)
+		if strings.HasPrefix(_line, string(_COMMENT)) {
+			continue
+		}
+		_pattern := normalise(_line)
+		if _pattern == "" {
+			continue
+		}
+
+		// compile the pattern
+		_compiled, _err := compile(_pattern, _position)
+		if _err != nil {
+			if !d._errors(NewError(_err, _position)) {
+				return nil
+			}
+			continue
+		}
+		d._patterns = append(d._patterns, _compiled)
+	}
+
+	return _scanner.Err()
+} // read()
+
+// normalise returns the normalised form of the Docker ignore pattern line, as
+// performed by Docker when reading ignore files, or the empty string if the
+// line contains no pattern.
+func normalise(line string) string {
+	_pattern := strings.TrimSpace(line)
+	if _pattern == "" {
+		return ""
+	}
+
+	// normalise the pattern relative to the build context
+	//		- taking care of any "!" prefix
+	_invert := _pattern[0] == byte(_NEGATION)
+	if _invert {
+		_pattern = strings.TrimSpace(_pattern[1:])
+	}
+	if len(_pattern) > 0 {
+		_pattern = filepath.ToSlash(filepath.Clean(_pattern))
+		if len(_pattern) > 1 && _pattern[0] == byte(_SEPARATOR) {
+			_pattern = _pattern[1:]
+		}
+	}
+	if _invert {
+		_pattern = string(_NEGATION) + _pattern
+	}
+
+	return _pattern
+} // normalise()
+
+// exempt ensures the .dockerignore file and the Dockerfile of options are
+// not ignored, as performed by the docker CLI.
+func (d *docker) exempt(options DockerOptions) {
+	_dockerfile := options.Dockerfile
+	if _dockerfile == "" {
+		_dockerfile = Dockerfile
+	}
+	_files := []string{DockerIgnoreFile}
+	if !options.Stdin {
+		_files = append(_files, filepath.ToSlash(filepath.Clean(_dockerfile)))
+	}
+
+	// append an exception for each of the files that would be ignored
+	for _, _file := range _files {
+		if !d.ignored(_file) {
+			continue
+		}
+		_pattern, _err := compile(string(_NEGATION)+_file, Position{})
+		if _err == nil {
+			d._patterns = append(d._patterns, _pattern)
+		}
+	}
+} // exempt()
+
+// ignored returns true if the path, relative to the build context, is
+// ignored by the patterns.
+func (d *docker) ignored(path string) bool {
+	_match := d.Relative(path, false)
+	return _match != nil && _match.Ignore()
+} // ignored()
+
+// Match attempts to match the path against this Docker ignore file.
+func (d *docker) Match(path string) Match {
+	// ensure we have the absolute path for the given file
+	_path, _err := filepath.Abs(path)
+	if _err != nil {
+		d._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	// is the path a file or a directory?
+	_info, _err := os.Stat(_path)
+	if _err != nil {
+		d._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	return d.Absolute(_path, _info.IsDir())
+} // Match()
+
+// Absolute attempts to match an absolute path against this Docker ignore
+// file. If the path is not located under the build context, or is not matched,
+// nil is returned.
+func (d *docker) Absolute(path string, isdir bool) Match {
+	_rel, _err := filepath.Rel(d._base, path)
+	if _err != nil || strings.HasPrefix(_rel, "..") {
+		return nil
+	}
+
+	return d.Relative(_rel, isdir)
+} // Absolute()
+
+// Relative attempts to match a path, relative to the root of the build
+// context, against this Docker ignore file. The returned Match is the pattern
+// that determined whether the path is ignored, which may have matched a
+// parent directory of the path. If no pattern applies to the path, nil is
+// returned. Since Docker patterns cannot distinguish files from directories,
+// isdir is not used.
+func (d *docker) Relative(path string, isdir bool) Match {
+	// the root of the build context cannot be ignored
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return nil
+	}
+	_parents := strings.Split(_path, string(_SEPARATOR))
+	_parents = _parents[:len(_parents)-1]
+
+	// apply the patterns in order
+	//		- a path is ignored if it, or any of its parent directories,
+	//		  is matched by a pattern, and is included again if a later
+	//		  exception matches it, or any of its parent directories
+	var _match *dockerpattern
+	_ignored := false
+	for _, _pattern := range d._patterns {
+		// only consider exceptions for paths that are ignored, and other
+		// patterns for paths that are not ignored
+		if _pattern._exclusion != _ignored {
+			continue
+		}
+
+		_matched := _pattern.match(_path)
+		for _i := range _parents {
+			if _matched {
+				break
+			}
+			_parent := strings.Join(_parents[:_i+1], string(_SEPARATOR))
+			_matched = _pattern.match(_parent)
+		}
+
+		if _matched {
+			_ignored = !_pattern._exclusion
+			_match = _pattern
+		}
+	}
+
+	if _match == nil {
+		return nil
+	}
+	return _match
+} // Relative()
+
+// MatchAll attempts to match a path, relative to the root of the build
+// context, against this Docker ignore file, returning every pattern matching
+// the path, or one of its parent directories, in the order of the patterns.
+// The Path of each Trace is the path, or the first of its parent directories,
+// matched by the pattern. Patterns that cannot change whether the path is
+// ignored (e.g. an exception for a path that is not ignored) are reported,
+// but are never decisive.
+func (d *docker) MatchAll(path string, isdir bool) []Trace {
+	// the root of the build context cannot be ignored
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return nil
+	}
+	_parents := strings.Split(_path, string(_SEPARATOR))
+	_parents = _parents[:len(_parents)-1]
+
+	// apply the patterns in order, as with Relative
+	var _traces []Trace
+	_decisive := -1
+	_ignored := false
+	for _, _pattern := range d._patterns {
+		_matched := ""
+		if _pattern.match(_path) {
+			_matched = _path
+		}
+		for _i := range _parents {
+			if _matched != "" {
+				break
+			}
+			_parent := strings.Join(_parents[:_i+1], string(_SEPARATOR))
+			if _pattern.match(_parent) {
+				_matched = _parent
+			}
+		}
+		if _matched == "" {
+			continue
+		}
+
+		// only exceptions apply to paths that are ignored, and other
+		// patterns to paths that are not ignored
+		_traces = append(_traces, Trace{Match: _pattern, Path: _matched})
+		if _pattern._exclusion == _ignored {
+			_ignored = !_pattern._exclusion
+			_decisive = len(_traces) - 1
+		}
+	}
+
+	return decide(_traces, _decisive)
+} // MatchAll()
+
+// Ignore returns true if the path is ignored by this Docker ignore file.
+func (d *docker) Ignore(path string) bool {
+	_match := d.Match(path)
+	if _match != nil {
+		return _match.Ignore()
+	}
+
+	// we didn't match this path, so we don't ignore it
+	return false
+} // Ignore()
+
+// Include returns true if the path is included by this Docker ignore file.
+func (d *docker) Include(path string) bool {
+	_match := d.Match(path)
+	if _match != nil {
+		return _match.Include()
+	}
+
+	// we didn't match this path, so we include it
+	return true
+} // Include()
+
+// compile returns the dockerpattern for the normalised pattern, defined at
+// position. An error is returned if the pattern is not valid.
+func compile(pattern string, position Position) (*dockerpattern, error) {
+	_pattern := &dockerpattern{_pattern: pattern, _position: position}
+	if strings.HasPrefix(pattern, string(_NEGATION)) {
+		if len(pattern) == 1 {
+			return nil, InvalidPatternError
+		}
+		_pattern._exclusion = true
+		_pattern._pattern = pattern[1:]
+	}
+
+	// check the syntax of the pattern as Docker does
+	_, _err := filepath.Match(_pattern._pattern, ".")
+	if _err != nil {
+		return nil, InvalidPatternError
+	}
+
+	// convert the pattern to a regular expression
+	//		- simple patterns are matched directly
+	_runes := []rune(_pattern._pattern)
+	_regexp := "^"
+	_kind := _DOCKEREXACT
+	for _i := 0; _i < len(_runes); _i++ {
+		_rune := _runes[_i]
+		switch {
+		case _rune == _WILDCARD && _i+1 < len(_runes) && _runes[_i+1] == _WILDCARD:
+			// "**" matches any number of directories
+			//		- "**/" is treated as "**"
+			_start := _i == 0
+			_i++
+			if _i+1 < len(_runes) && _runes[_i+1] == _SEPARATOR {
+				_i++
+			}
+			if _i+1 == len(_runes) {
+				if _kind == _DOCKEREXACT {
+					_kind = _DOCKERPREFIX
+				} else {
+					_regexp += ".*"
+					_kind = _DOCKERREGEXP
+				}
+			} else {
+				_regexp += "(.*" + string(_SEPARATOR) + ")?"
+				_kind = _DOCKERREGEXP
+			}
+			if _start {
+				_kind = _DOCKERSUFFIX
+			}
+		case _rune == _WILDCARD:
+			_regexp += "[^" + string(_SEPARATOR) + "]*"
+			_kind = _DOCKERREGEXP
+		case _rune == '?':
+			_regexp += "[^" + string(_SEPARATOR) + "]"
+			_kind = _DOCKERREGEXP
+		case strings.ContainsRune(_DOCKERESCAPE, _rune):
+			_regexp += string(_ESCAPE) + string(_rune)
+		case _rune == _ESCAPE:
+			// escape the next character
+			//		- a trailing "\" is left alone
+			if _i+1 < len(_runes) {
+				_i++
+				_regexp += string(_ESCAPE) + string(_runes[_i])
+				_kind = _DOCKERREGEXP
+			} else {
+				_regexp += string(_ESCAPE)
+			}
+		case _rune == '[' || _rune == ']':
+			_regexp += string(_rune)
+			_kind = _DOCKERREGEXP
+		default:
+			_regexp += string(_rune)
+		}
+	}
+
+	// compile the regular expression, if required
+	if _kind == _DOCKERREGEXP {
+		_compiled, _err := regexp.Compile(_regexp + "$")
+		if _err != nil {
+			return nil, InvalidPatternError
+		}
+		_pattern._regexp = _compiled
+	}
+	_pattern._kind = _kind
+
+	return _pattern, nil
+} // compile()
+
+// match returns true if the path, relative to the root of the build context,
+// is matched by this pattern.
+func (p *dockerpattern) match(path string) bool {
+	switch p._kind {
+	case _DOCKEREXACT:
+		return path == p._pattern
+	case _DOCKERPREFIX:
+		// strip the trailing "**"
+		return strings.HasPrefix(path, p._pattern[:len(p._pattern)-2])
+	case _DOCKERSUFFIX:
+		// strip the leading "**", where "**/a" also matches "a"
+		_suffix := p._pattern[2:]
+		if strings.HasSuffix(path, _suffix) {
+			return true
+		}
+		return _suffix[0] == byte(_SEPARATOR) && path == _suffix[1:]
+	}
+
+	return p._regexp.MatchString(path)
+} // match()
+
+// Ignore returns true if the pattern ignores the paths it matches.
+func (p *dockerpattern) Ignore() bool { return !p._exclusion }
+
+// Include returns true if the pattern is an exception, including the paths
+// it matches.
+func (p *dockerpattern) Include() bool { return p._exclusion }
+
+// String returns the normalised form of the pattern.
+func (p *dockerpattern) String() string {
+	if p._exclusion {
+		return string(_NEGATION) + p._pattern
+	}
+	return p._pattern
+} // String()
+
+// Position returns the position of the pattern within the Docker ignore
+// file. Exceptions added for the Dockerfile and .dockerignore file have a
+// zero Position.
+func (p *dockerpattern) Position() Position { return p._position }
+
+// ensure docker satisfies the GitIgnore interface, and dockerpattern
+// satisfies the Match interface
+var _ GitIgnore = &docker{}
+var _ Match = &dockerpattern{}
@@ -0,0 +1,84 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestDockerIgnore(t *testing.T) {
+	// record the errors encountered parsing the .dockerignore
+	_errors := make([]gitignore.Error, 0)
+	_options := gitignore.DockerOptions{
+		Errors: func(e gitignore.Error) bool {
+			_errors = append(_errors, e)
+			return true
+		},
+	}
+	_reader := strings.NewReader(_DOCKERIGNORE)
+	_ignore := gitignore.NewDockerIgnore(_reader, "/context", _options)
+
+	// ensure the paths are matched as Docker would
+	for _, _match := range _DOCKERMATCHES {
+		do(t, _ignore.Relative, _match)
+	}
+
+	// ensure the illegal exclusion pattern was reported
+	if len(_errors) != 1 {
+		t.Fatalf("error count mismatch; expected 1, got %d", len(_errors))
+	} else if _errors[0].Underlying() != gitignore.InvalidPatternError {
+		t.Errorf("error mismatch; expected %q, got %q",
+			gitignore.InvalidPatternError, _errors[0].Underlying(),
+		)
+	} else if _errors[0].Position().Line != 13 {
+		t.Errorf("error position mismatch; expected line 13, got %s",
+			_errors[0].Position(),
+		)
+	}
+
+	// the Dockerfile is not exempt if it is read from stdin
+	_reader = strings.NewReader(_DOCKERIGNORE)
+	_ignore = gitignore.NewDockerIgnore(
+		_reader, "/context", gitignore.DockerOptions{Stdin: true},
+	)
+	do(t, _ignore.Relative, match{"Dockerfile", "Dockerfile", true, false})
+	do(t, _ignore.Relative, match{".dockerignore", "!.dockerignore", false, false})
+} // TestDockerIgnore()
+
+func TestDockerIgnoreFromContext(t *testing.T) {
+	// create the build context
+	_dir, _err := dir(_DOCKERCONTEXT)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the ignore file of the Dockerfile is preferred
+	_options := gitignore.DockerOptions{Dockerfile: "build/Dockerfile"}
+	_ignore, _err := gitignore.NewDockerIgnoreFromContext(_dir, _options)
+	if _err != nil {
+		t.Fatalf("unable to read build context: %s", _err.Error())
+	}
+	for _, _match := range _DOCKERCONTEXTMATCHES {
+		do(t, _ignore.Relative, _match)
+	}
+
+	// ensure absolute paths are matched relative to the context
+	_path := filepath.Join(_dir, "main.go")
+	if !_ignore.Ignore(_path) {
+		t.Errorf("expected %q to be ignored", _path)
+	}
+
+	// otherwise, the .dockerignore of the context is used
+	_ignore, _err = gitignore.NewDockerIgnoreFromContext(
+		_dir, gitignore.DockerOptions{},
+	)
+	if _err != nil {
+		t.Fatalf("unable to read build context: %s", _err.Error())
+	}
+	do(t, _ignore.Relative, match{"README.md", "*.md", true, false})
+	do(t, _ignore.Relative, match{"main.go", "", false, false})
+} // TestDockerIgnoreFromContext()
@@ -0,0 +1,117 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment defines the git environment used to locate the files of a
+// repository, in place of the corresponding environment variables of the
+// current process. This allows repositories with different git environments
+// to be opened concurrently. An empty field is equivalent to the
+// corresponding environment variable being unset.
+//
+// Relative paths are interpreted relative to the base directory of the
+// repository (or, for OpenRepositoryWithOptions, relative to the path from
+// which the repository is discovered), rather than the current directory of
+// the process.
+type Environment struct {
+	// GitDir is the location of the git directory of the repository,
+	// overriding the discovery of the .git directory (i.e. GIT_DIR).
+	GitDir string
+
+	// WorkTree is the root of the work tree of the repository, overriding
+	// core.worktree (i.e. GIT_WORK_TREE).
+	WorkTree string
+
+	// CommonDir is the location of the repository-wide files (such as the
+	// configuration, info/exclude and the object database) shared by linked
+	// work trees, overriding the commondir file of the git directory
+	// (i.e. GIT_COMMON_DIR).
+	CommonDir string
+
+	// IndexFile is the location of the index, overriding $GIT_DIR/index
+	// (i.e. GIT_INDEX_FILE).
+	IndexFile string
+
+	// CeilingDirectories lists the absolute directories above which the
+	// discovery of a repository will not search (i.e.
+	// GIT_CEILING_DIRECTORIES). Relative directories are ignored.
+	CeilingDirectories []string
+} // Environment{}
+
+// NewEnvironment returns the Environment described by the GIT_DIR,
+// GIT_WORK_TREE, GIT_COMMON_DIR, GIT_INDEX_FILE and GIT_CEILING_DIRECTORIES
+// environment variables of the current process. Relative paths are resolved
+// against the current directory of the process, as git does.
+func NewEnvironment() *Environment {
+	_environment := &Environment{
+		GitDir:    os.Getenv("GIT_DIR"),
+		WorkTree:  os.Getenv("GIT_WORK_TREE"),
+		CommonDir: os.Getenv("GIT_COMMON_DIR"),
+		IndexFile: os.Getenv("GIT_INDEX_FILE"),
+	}
+
+	// resolve relative paths against the current directory
+	_cwd, _err := os.Getwd()
+	if _err == nil {
+		_environment = _environment.resolve(_cwd)
+	}
+
+	// extract the list of ceiling directories
+	_list := os.Getenv("GIT_CEILING_DIRECTORIES")
+	for _, _dir := range strings.Split(_list, string(os.PathListSeparator)) {
+		if _dir != "" {
+			_environment.CeilingDirectories = append(
+				_environment.CeilingDirectories, _dir,
+			)
+		}
+	}
+
+	return _environment
+} // NewEnvironment()
+
+// resolve returns a copy of the environment with relative paths resolved
+// against the directory dir.
+func (e *Environment) resolve(dir string) *Environment {
+	_environment := *e
+	for _, _path := range []*string{
+		&_environment.GitDir,
+		&_environment.WorkTree,
+		&_environment.CommonDir,
+		&_environment.IndexFile,
+	} {
+		if *_path != "" && !filepath.IsAbs(*_path) {
+			*_path = filepath.Join(dir, *_path)
+		}
+	}
+
+	return &_environment
+} // resolve()
+
+// common returns the common git directory of the repository with the given
+// gitdir, given by CommonDir if set, and otherwise by the commondir file of
+// gitdir.
+func (e *Environment) common(gitdir string) (string, error) {
+	if gitdir == "" {
+		return "", nil
+	} else if e.CommonDir != "" {
+		return filepath.Clean(e.CommonDir), nil
+	}
+
+	return commondir(gitdir)
+} // common()
+
+// ceilings returns the set of absolute ceiling directories of the
+// environment. Relative and empty entries are ignored.
+func (e *Environment) ceilings() map[string]bool {
+	_ceilings := make(map[string]bool)
+	for _, _dir := range e.CeilingDirectories {
+		if _dir != "" && filepath.IsAbs(_dir) {
+			_ceilings[filepath.Clean(_dir)] = true
+		}
+	}
+
+	return _ceilings
+} // ceilings()
@@ -0,0 +1,156 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestEnvironment(t *testing.T) {
+	// create the repository for the environment tests
+	_dir, _err := dir(_GITENVIRONMENT)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the process environment is not consulted when an explicit
+	// environment is given
+	_restore, _err := setenv(map[string]string{
+		"GIT_DIR":        filepath.Join(_dir, "missing"),
+		"GIT_WORK_TREE":  filepath.Join(_dir, "missing"),
+		"GIT_COMMON_DIR": filepath.Join(_dir, "missing"),
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// open the repositories concurrently with different environments
+	//		- relative paths are resolved against the starting directory
+	//		  or the base of the repository
+	_gitdir := filepath.Join(_dir, ".git")
+	_linked := filepath.Join(_dir, "linked")
+	_environments := []struct {
+		Open   func() (gitignore.Repository, error)
+		GitDir string
+	}{
+		{
+			func() (gitignore.Repository, error) {
+				_options := gitignore.Options{
+					Environment: &gitignore.Environment{
+						GitDir:   "../.git",
+						WorkTree: "..",
+					},
+				}
+				_start := filepath.Join(_dir, "work")
+				return gitignore.OpenRepositoryWithOptions(_start, _options)
+			},
+			_gitdir,
+		},
+		{
+			func() (gitignore.Repository, error) {
+				_options := gitignore.Options{
+					Environment: &gitignore.Environment{
+						GitDir:    "linked",
+						CommonDir: ".git",
+					},
+				}
+				_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+				if _repository == nil {
+					return nil, gitignore.NotRepositoryError
+				}
+				return _repository.(gitignore.Repository), nil
+			},
+			_linked,
+		},
+	}
+	_repositories := make([]gitignore.Repository, len(_environments))
+	_errors := make([]error, len(_environments))
+	var _wait sync.WaitGroup
+	for _i, _environment := range _environments {
+		_wait.Add(1)
+		go func(i int, open func() (gitignore.Repository, error)) {
+			defer _wait.Done()
+			_repositories[i], _errors[i] = open()
+		}(_i, _environment.Open)
+	}
+	_wait.Wait()
+
+	// ensure each repository was opened with its own environment
+	for _i, _environment := range _environments {
+		if _errors[_i] != nil {
+			t.Fatalf("unable to open repository %d: %s", _i, _errors[_i])
+		}
+		opened(t, _repositories[_i], _dir, _environment.GitDir)
+	}
+
+	// without its common directory, the linked git directory is not a
+	// repository
+	_options := gitignore.Options{
+		Environment: &gitignore.Environment{GitDir: _linked},
+	}
+	_, _err = gitignore.OpenRepositoryWithOptions(_dir, _options)
+	if _err != gitignore.NotRepositoryError {
+		t.Errorf("environment error mismatch; expected %q, got %v",
+			gitignore.NotRepositoryError, _err,
+		)
+	}
+} // TestEnvironment()
+
+func TestExplicitEnvironment(t *testing.T) {
+	// create a package whose git directory is not named .git
+	_dir, _err := dir(map[string]string{
+		"package.json":           `{"name": "pkg"}`,
+		"gitdir/info/attributes": "*.txt text\n",
+		"gitdir/config":          "[core]\n\tignorecase = true\n",
+		".npmignore":             "excluded.js\n",
+		"EXCLUDED.js":            "excluded\n",
+		"notes.txt":              "notes\n",
+	})
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the environment of the process is not used
+	_restore, _err := setenv(map[string]string{
+		"GIT_ATTR_NOSYSTEM": "1",
+		"GIT_DIR":           filepath.Join(_dir, "missing"),
+		"XDG_CONFIG_HOME":   filepath.Join(_dir, "config"),
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+	_environment := &gitignore.Environment{GitDir: "gitdir"}
+
+	// the attributes of the git directory should be read
+	_attributes, _err := gitignore.NewAttributesWithEnvironment(
+		_dir, _environment, nil,
+	)
+	if _err != nil {
+		t.Fatalf("unable to read attributes: %s", _err.Error())
+	}
+	_text := _attributes.Attribute("notes.txt", false, "text")
+	if !_text.IsSet() {
+		t.Errorf("attribute mismatch for %q; expected text set, got %s",
+			"notes.txt", _text,
+		)
+	}
+
+	// the configuration of the git directory should apply to the package
+	_ignore, _err := gitignore.NewNpmPackageWithEnvironment(
+		_dir, _environment, nil,
+	)
+	if _err != nil {
+		t.Fatalf("unable to read package: %s", _err.Error())
+	}
+	_match := _ignore.Relative("EXCLUDED.js", false)
+	if _match == nil || !_match.Ignore() {
+		t.Errorf("expected %q to be ignored", "EXCLUDED.js")
+	}
+} // TestExplicitEnvironment()
@@ -5,7 +5,23 @@ import (
 )
 
 var (
-	CarriageReturnError   = errors.New("unexpected carriage return '\\r'")
-	InvalidPatternError   = errors.New("invalid pattern")
-	InvalidDirectoryError = errors.New("invalid directory")
+	CarriageReturnError    = errors.New("unexpected carriage return '\\r'")
+	InvalidPatternError    = errors.New("invalid pattern")
+	InvalidDirectoryError  = errors.New("invalid directory")
+	NotRepositoryError     = errors.New("not a git repository")
+	NoWorkTreeError        = errors.New("repository has no work tree")
+	InvalidIndexError      = errors.New("invalid index")
+	UnsupportedIndexError  = errors.New("unsupported index extension")
+	MissingObjectError     = errors.New("object not found")
+	InvalidObjectError     = errors.New("invalid object")
+	InvalidPackError       = errors.New("invalid pack")
+	InvalidAttributeError  = errors.New("invalid attribute")
+	NegativeAttributeError = errors.New("negative patterns are ignored in git attributes")
+	MacroAttributeError    = errors.New("attribute macros are only allowed at the top level")
+	InvalidSyntaxError     = errors.New("invalid syntax")
+	IncludeDepthError      = errors.New("maximum include depth exceeded")
+	UnsupportedRuleError   = errors.New("unsupported filter rule")
+	IncludeCycleError      = errors.New("include cycle")
+	UnsupportedIgnoreError = errors.New("unsupported GitIgnore")
+	InvalidManifestError   = errors.New("invalid package manifest")
 )
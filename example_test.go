@@ -2,6 +2,7 @@ package gitignore_test
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/denormal/go-gitignore"
 )
@@ -53,3 +54,61 @@ func ExampleNewRepository() {
 		fmt.Println("include the service configuration")
 	}
 } // ExampleNewRepository()
+
+func ExampleOpenRepository() {
+	// open the repository enclosing the current working directory
+	repository, err := gitignore.OpenRepository(".")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf(
+		"found repository with work tree %s and GIT_DIR %s",
+		repository.Base(), repository.GitDir(),
+	)
+
+	// relative paths are matched against the root of the work tree
+	match := repository.Relative("build/output.o", false)
+	if match != nil {
+		if match.Ignore() {
+			fmt.Println("ignore build/output.o")
+		}
+	}
+} // ExampleOpenRepository()
+
+// helmignore is the Dialect of .helmignore files, which follow the .gitignore
+// rules, but are only read from the root directory of a chart
+type helmignore struct {
+	gitignore.Dialect
+}
+
+func (h helmignore) PerDirectory() bool { return false }
+
+func ExampleRegisterDialect() {
+	// read .helmignore files using the helmignore dialect
+	gitignore.RegisterDialect(".helmignore", helmignore{gitignore.GitDialect})
+
+	ignore, err := gitignore.NewRepositoryWithFile("/my/chart", ".helmignore")
+	if err != nil {
+		panic(err)
+	}
+
+	// only the .helmignore of /my/chart is used to match paths
+	if ignore.Ignore("/my/chart/templates/NOTES.txt") {
+		fmt.Println("ignore the chart notes")
+	}
+} // ExampleRegisterDialect()
+
+func ExampleGitIgnore_MatchAll() {
+	ignore := gitignore.New(strings.NewReader("*.log\n!debug.log\n/*.log\n"), "/my/project", nil)
+
+	// report why debug.log is ignored, despite its negated pattern
+	for _, trace := range ignore.MatchAll("debug.log", false) {
+		fmt.Printf("%s: %q (line %d, decisive %v)\n",
+			trace.Path, trace.String(), trace.Position().Line, trace.Decisive,
+		)
+	}
+	// Output:
+	// debug.log: "*.log" (line 1, decisive false)
+	// debug.log: "!debug.log" (line 2, decisive false)
+	// debug.log: "/*.log" (line 3, decisive true)
+} // ExampleGitIgnore_MatchAll()
@@ -1,31 +1,128 @@
 package gitignore
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-// exclude attempts to return the GitIgnore instance for the
-// $GIT_DIR/info/exclude from the working copy to which path belongs.
-func exclude(path string) (GitIgnore, error) {
+// gitdir attempts to return the GIT_DIR for the working copy with root
+// directory path, within the git environment env. If the working copy has no
+// GIT_DIR, gitdir returns the empty string. If the .git entry of the working
+// copy is a file (as used by linked worktrees and submodules), gitdir returns
+// the directory it refers to.
+func gitdir(path string, env *Environment) (string, error) {
 	// attempt to locate GIT_DIR
-	_gitdir := os.Getenv("GIT_DIR")
+	_gitdir := env.GitDir
 	if _gitdir == "" {
 		_gitdir = filepath.Join(path, ".git")
 	}
+	return locate(_gitdir)
+} // gitdir()
+
+// locate returns the GIT_DIR represented by the .git directory or file
+// gitdir. If gitdir does not exist, locate returns the empty string.
+func locate(gitdir string) (string, error) {
+	_gitdir := gitdir
 	_info, _err := os.Stat(_gitdir)
 	if _err != nil {
 		if os.IsNotExist(_err) {
-			return nil, nil
+			return "", nil
 		} else {
-			return nil, _err
+			return "", _err
+		}
+	} else if !_info.IsDir() {
+		return gitfile(_gitdir)
+	}
+
+	return _gitdir, nil
+} // locate()
+
+// gitfile attempts to return the GIT_DIR referenced by the "gitdir: <path>"
+// file. Relative paths are resolved against the directory containing file.
+// If file is not a valid gitfile, or does not reference a directory, gitfile
+// returns the empty string.
+func gitfile(file string) (string, error) {
+	_content, _err := ioutil.ReadFile(file)
+	if _err != nil {
+		return "", _err
+	}
+
+	// extract the path from the first line of the file
+	_line := strings.SplitN(string(_content), "\n", 2)[0]
+	_line = strings.TrimRight(_line, "\r")
+	if !strings.HasPrefix(_line, "gitdir:") {
+		return "", nil
+	}
+	_gitdir := strings.TrimSpace(strings.TrimPrefix(_line, "gitdir:"))
+	if _gitdir == "" {
+		return "", nil
+	} else if !filepath.IsAbs(_gitdir) {
+		_gitdir = filepath.Join(filepath.Dir(file), _gitdir)
+	}
+
+	// ensure the referenced GIT_DIR exists
+	_info, _err := os.Stat(_gitdir)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return "", nil
+		} else {
+			return "", _err
 		}
 	} else if !_info.IsDir() {
+		return "", nil
+	}
+
+	return filepath.Clean(_gitdir), nil
+} // gitfile()
+
+// commondir returns the common git directory for the given gitdir. Linked
+// worktrees have a private gitdir that shares the repository-wide files
+// (such as info/exclude and config) of the main working copy, and locate
+// the common directory in their "commondir" file. If gitdir has no
+// "commondir" file, commondir returns gitdir.
+func commondir(gitdir string) (string, error) {
+	if gitdir == "" {
+		return "", nil
+	}
+
+	// attempt to read the commondir file
+	_content, _err := ioutil.ReadFile(filepath.Join(gitdir, "commondir"))
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return gitdir, nil
+		} else {
+			return "", _err
+		}
+	}
+
+	// relative paths are resolved against the gitdir
+	_common := strings.TrimSpace(string(_content))
+	if _common == "" {
+		return gitdir, nil
+	} else if !filepath.IsAbs(_common) {
+		_common = filepath.Join(gitdir, _common)
+	}
+
+	return filepath.Clean(_common), nil
+} // commondir()
+
+// exclude attempts to return the GitIgnore instance for the
+// $GIT_DIR/info/exclude from the working copy with the given gitdir. For
+// linked worktrees, info/exclude is located in the common git directory. If
+// casefold is true, the patterns of the exclude file are case-insensitive,
+// and the patterns are normalized to form.
+func exclude(gitdir string, casefold bool, form Normalization) (GitIgnore, error) {
+	_common, _err := commondir(gitdir)
+	if _err != nil {
+		return nil, _err
+	} else if _common == "" {
 		return nil, nil
 	}
 
 	// is there an info/exclude file within this directory?
-	_file := filepath.Join(_gitdir, "info", "exclude")
+	_file := filepath.Join(_common, "info", "exclude")
 	_, _err = os.Stat(_file)
 	if _err != nil {
 		if os.IsNotExist(_err) {
@@ -36,5 +133,5 @@ func exclude(path string) (GitIgnore, error) {
 	}
 
 	// attempt to load the exclude file
-	return NewFromFile(_file)
+	return newFromFile(_file, casefold, form)
 } // exclude()
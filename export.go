@@ -0,0 +1,287 @@
+package gitignore
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// define the characters with special meaning in rsync wildcard patterns
+const _RSYNCWILDCARDS = "*?["
+
+// ExportRsync writes the rsync filter rules equivalent to source to w, such
+// that "rsync --filter='merge file'" transfers the paths not ignored by
+// source. source may be a GitIgnore returned by New, NewWithErrors,
+// NewRsyncFilter, or a repository with a work tree, in which case the
+// .gitignore files of every directory of the work tree that is not ignored,
+// $GIT_DIR/info/exclude, and the global excludes file are exported, with
+// each group of rules preceded by a comment naming its file.
+//
+// Since rsync applies the first matching rule, while git applies the last,
+// the rules of each file are written in reverse order, with the rules of
+// deeper directories written first. Patterns of per-directory files are
+// anchored to their directory. Nested repositories and the index of the
+// repository are not exported.
+//
+// ExportRsync returns UnsupportedIgnoreError if source cannot be exported.
+func ExportRsync(w io.Writer, source GitIgnore) error {
+	_writer := bufio.NewWriter(w)
+	_err := export(_writer, source, source.Base(), "")
+	if _err != nil {
+		return _err
+	}
+
+	return _writer.Flush()
+} // ExportRsync()
+
+// export writes the rsync filter rules for source, the ignore file of the
+// directory dir (relative to root, using "/" separators), to w.
+func export(w *bufio.Writer, source GitIgnore, root, dir string) error {
+	switch _source := source.(type) {
+	case *ignore:
+		// introduce the rules with the ignore file
+		if len(_source._pattern) == 0 {
+			return nil
+		}
+		_file := _source._pattern[0].Position().File
+		if _file != "" {
+			_rel, _err := filepath.Rel(root, _file)
+			if _err == nil && !strings.HasPrefix(_rel, "..") {
+				_file = filepath.ToSlash(_rel)
+			}
+			w.WriteString(string(_COMMENT) + " " + _file + "\n")
+		}
+
+		// rsync applies the first matching rule
+		for _i := len(_source._pattern) - 1; _i >= 0; _i-- {
+			_rules, _ok := rsyncrules(_source._pattern[_i], dir)
+			if !_ok {
+				return UnsupportedIgnoreError
+			}
+			for _, _rule := range _rules {
+				w.WriteString(_rule + "\n")
+			}
+		}
+
+	case *layers:
+		// the layers of a directory are in increasing precedence
+		for _i := len(_source._layers) - 1; _i >= 0; _i-- {
+			_err := export(w, _source._layers[_i], root, dir)
+			if _err != nil {
+				return _err
+			}
+		}
+
+	case *repository:
+		return exportRepository(w, _source)
+
+	case *rsync:
+		for _, _rule := range _source._rules {
+			w.WriteString(_rule.String() + "\n")
+		}
+
+	default:
+		return UnsupportedIgnoreError
+	}
+
+	return nil
+} // export()
+
+// exportRepository writes the rsync filter rules for the ignore files of the
+// repository r to w.
+func exportRepository(w *bufio.Writer, r *repository) error {
+	// only repositories with a work tree can be exported
+	if _, _ok := r._loader.(*files); !_ok {
+		return UnsupportedIgnoreError
+	}
+
+	// find the directories of the work tree that are not ignored
+	//		- git does not descend into ignored directories, nested
+	//		  repositories or the .git directory
+	_dirs := make([]string, 0)
+	_err := filepath.Walk(r.Base(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if !info.IsDir() {
+			return nil
+		}
+
+		_rel, _err := filepath.Rel(r.Base(), path)
+		if _err != nil {
+			return _err
+		} else if _rel != "." {
+			if info.Name() == ".git" || r._loader.nested(_rel) {
+				return filepath.SkipDir
+			}
+			_match := r.relative(_rel, true)
+			if _match != nil && _match.Ignore() {
+				return filepath.SkipDir
+			}
+		}
+		_dirs = append(_dirs, _rel)
+		return nil
+	})
+	if _err != nil {
+		return _err
+	}
+
+	// the rules of deeper directories take precedence
+	_depth := func(dir string) int {
+		if dir == "." {
+			return 0
+		}
+		return strings.Count(filepath.ToSlash(dir), string(_SEPARATOR)) + 1
+	}
+	sort.SliceStable(_dirs, func(i, j int) bool {
+		return _depth(_dirs[i]) > _depth(_dirs[j])
+	})
+
+	for _, _dir := range _dirs {
+		_ignore := r._loader.load(_dir)
+		if _ignore == nil {
+			continue
+		}
+		_slash := filepath.ToSlash(_dir)
+		if _slash == "." {
+			_slash = ""
+		}
+		_err := export(w, _ignore, r.Base(), _slash)
+		if _err != nil {
+			return _err
+		}
+	}
+
+	// finally, add GIT_DIR/info/exclude and the global excludes file
+	for _, _ignore := range []GitIgnore{r._exclude, r._global} {
+		if _ignore != nil {
+			_err := export(w, _ignore, r.Base(), "")
+			if _err != nil {
+				return _err
+			}
+		}
+	}
+
+	return nil
+} // exportRepository()
+
+// rsyncrules returns the rsync filter rules equivalent to the gitignore
+// pattern p, defined in the ignore file of the directory dir. Since "**/" in
+// rsync must match at least one directory, patterns containing "**/" may
+// require more than one rule. If p is not a gitignore pattern, rsyncrules
+// returns false.
+func rsyncrules(p Pattern, dir string) ([]string, bool) {
+	var _pattern *pattern
+	switch _p := p.(type) {
+	case *name:
+		_pattern = &_p.pattern
+	case *path:
+		_pattern = &_p.pattern
+	case *any:
+		_pattern = &_p.pattern
+	default:
+		return nil, false
+	}
+
+	_rule := string(_RSYNCEXCLUDE) + " "
+	if _pattern._negated {
+		_rule = string(_RSYNCINCLUDE) + " "
+	}
+	_suffix := ""
+	if _pattern._directory {
+		_suffix = string(_SEPARATOR)
+	}
+
+	// in rsync, "**" matches "/" wherever it appears, whereas in git it
+	// only has special meaning as a complete path component
+	_components := strings.Split(_pattern._wildmatch, string(_SEPARATOR))
+	for _i, _component := range _components {
+		if _component != "**" {
+			for strings.Contains(_component, "**") {
+				_component = strings.Replace(_component, "**", "*", -1)
+			}
+			_components[_i] = _component
+		}
+	}
+
+	// unanchored names match in any directory beneath the ignore file
+	if !_pattern._anchored && len(_components) == 1 && _components[0] != "**" {
+		if dir == "" {
+			return []string{_rule + rsyncliteral(_components[0]) + _suffix}, true
+		}
+		_components = []string{"**", _components[0]}
+	}
+
+	// otherwise the pattern is anchored to the directory of the ignore file
+	//		- a leading or infix "**" may match no directories
+	_prefix := string(_SEPARATOR)
+	if dir != "" {
+		_prefix += dir + string(_SEPARATOR)
+	}
+	_rules := make([]string, 0)
+	for _, _variant := range rsyncvariants(_components) {
+		if strings.ContainsAny(_variant, _RSYNCWILDCARDS) ||
+			strings.ContainsAny(_prefix, _RSYNCWILDCARDS) {
+			_variant = rsyncescape(_prefix) + _variant
+		} else {
+			_variant = _prefix + rsyncliteral(_variant)
+		}
+		_rules = append(_rules, _rule+_variant+_suffix)
+	}
+
+	return _rules, true
+} // rsyncrules()
+
+// rsyncvariants returns the rsync patterns for the gitignore path components,
+// where each "**" component other than the last may match no directories.
+func rsyncvariants(components []string) []string {
+	if len(components) == 1 {
+		return components
+	}
+
+	_variants := make([]string, 0)
+	for _, _rest := range rsyncvariants(components[1:]) {
+		_variants = append(_variants, components[0]+string(_SEPARATOR)+_rest)
+		if components[0] == "**" {
+			_variants = append(_variants, _rest)
+		}
+	}
+
+	return _variants
+} // rsyncvariants()
+
+// rsyncliteral returns the pattern with its escapes removed if it contains no
+// wildcards, since rsync matches such patterns literally.
+func rsyncliteral(pattern string) string {
+	if strings.ContainsAny(pattern, _RSYNCWILDCARDS) {
+		return pattern
+	}
+
+	_literal := make([]rune, 0, len(pattern))
+	_escaped := false
+	for _, _rune := range pattern {
+		if _rune == _ESCAPE && !_escaped {
+			_escaped = true
+			continue
+		}
+		_escaped = false
+		_literal = append(_literal, _rune)
+	}
+
+	return string(_literal)
+} // rsyncliteral()
+
+// rsyncescape returns the path with the rsync wildcards, and "\", escaped.
+func rsyncescape(path string) string {
+	_escaped := make([]rune, 0, len(path))
+	for _, _rune := range path {
+		if _rune == _ESCAPE || strings.ContainsRune(_RSYNCWILDCARDS, _rune) {
+			_escaped = append(_escaped, _ESCAPE)
+		}
+		_escaped = append(_escaped, _rune)
+	}
+
+	return string(_escaped)
+} // rsyncescape()
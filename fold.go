@@ -0,0 +1,123 @@
+package gitignore
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Case defines whether a repository GitIgnore matches paths against its
+// patterns case-insensitively, as git does when core.ignorecase is true.
+type Case int
+
+const (
+	// CaseConfig matches paths case-insensitively if core.ignorecase is
+	// true in the configuration of the repository. This is the default.
+	CaseConfig Case = iota
+
+	// CaseSensitive matches paths case-sensitively, regardless of the
+	// configuration of the repository.
+	CaseSensitive
+
+	// CaseInsensitive matches paths case-insensitively, regardless of the
+	// configuration of the repository.
+	CaseInsensitive
+)
+
+// foldpatterns makes the gitignore patterns case-insensitive.
+func foldpatterns(patterns []Pattern) {
+	for _, _pattern := range patterns {
+		switch _p := _pattern.(type) {
+		case *name:
+			_p.casefold()
+		case *path:
+			_p.casefold()
+		case *any:
+			_p.casefold()
+		}
+	}
+} // foldpatterns()
+
+// fold returns s with each character replaced by its case folded form, such
+// that strings that are equal under Unicode simple case folding (e.g. "Build"
+// and "BUILD") have the same folded form. Bytes that are not valid UTF-8 are
+// left unchanged.
+func fold(s string) string {
+	// most paths are ASCII, and many are already lower case
+	_i := 0
+	for ; _i < len(s); _i++ {
+		if s[_i] >= utf8.RuneSelf || (s[_i] >= 'A' && s[_i] <= 'Z') {
+			break
+		}
+	}
+	if _i == len(s) {
+		return s
+	}
+
+	_folded := strings.Builder{}
+	_folded.Grow(len(s))
+	_folded.WriteString(s[:_i])
+	for _i < len(s) {
+		_rune, _size := utf8.DecodeRuneInString(s[_i:])
+		if _rune == utf8.RuneError && _size == 1 {
+			_folded.WriteByte(s[_i])
+		} else {
+			_folded.WriteRune(foldrune(_rune))
+		}
+		_i += _size
+	}
+
+	return _folded.String()
+} // fold()
+
+// foldpattern returns the wildmatch pattern with each character case folded
+// as by fold, except for escaped characters and the content of character
+// classes, which, as with git, are matched case-insensitively only for
+// ASCII letters, using WM_CASEFOLD.
+func foldpattern(pattern string) string {
+	_folded := strings.Builder{}
+	_folded.Grow(len(pattern))
+	for _p := 0; _p < len(pattern); {
+		switch pattern[_p] {
+		case '\\':
+			// copy the escaped character unchanged
+			_, _size := utf8.DecodeRuneInString(pattern[_p+1:])
+			_folded.WriteString(pattern[_p : _p+1+_size])
+			_p += 1 + _size
+
+		case '[':
+			// copy the character class unchanged
+			//		- a malformed class never matches
+			_end, _, _abort := wmclass(pattern, _p+1, 0, 0)
+			if _abort {
+				_folded.WriteString(pattern[_p:])
+				return _folded.String()
+			}
+			_folded.WriteString(pattern[_p : _end+1])
+			_p = _end + 1
+
+		default:
+			_end := _p + 1
+			for _end < len(pattern) && strings.IndexByte(_WMSPECIAL, pattern[_end]) == -1 {
+				_end++
+			}
+			_folded.WriteString(fold(pattern[_p:_end]))
+			_p = _end
+		}
+	}
+
+	return _folded.String()
+} // foldpattern()
+
+// foldrune returns the case folded form of r, being the lowest of the lower
+// case forms of the runes equivalent to r under simple case folding.
+func foldrune(r rune) rune {
+	_folded := unicode.ToLower(r)
+	for _r := unicode.SimpleFold(r); _r != r; _r = unicode.SimpleFold(_r) {
+		if _lower := unicode.ToLower(_r); _lower < _folded {
+			_folded = _lower
+		}
+	}
+
+	return _folded
+} // foldrune()
@@ -0,0 +1,65 @@
+package gitignore_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestNewWithIgnoreCase(t *testing.T) {
+	_reader := strings.NewReader(_IGNORECASEIGNORE)
+	_ignore := gitignore.NewWithIgnoreCase(_reader, "/base", nil)
+
+	// ensure the paths are matched case-insensitively
+	for _, _match := range _IGNORECASEMATCHES {
+		do(t, _ignore.Relative, _match)
+	}
+
+	// ensure New remains case-sensitive
+	_reader = strings.NewReader(_IGNORECASEIGNORE)
+	_ignore = gitignore.New(_reader, "/base", nil)
+	for _, _match := range _IGNORECASESENSITIVE {
+		do(t, _ignore.Relative, _match)
+	}
+} // TestNewWithIgnoreCase()
+
+func TestRepositoryIgnoreCase(t *testing.T) {
+	// create the repository with core.ignorecase enabled
+	_dir, _err := dir(_IGNORECASEREPOSITORY)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// ensure the repository configuration is honoured
+	_options := gitignore.Options{NoGlobal: true}
+	_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _dir)
+	}
+	for _, _match := range _IGNORECASEREPOSITORYMATCHES {
+		do(t, _repository.Relative, _match)
+	}
+
+	// ensure the configuration may be overridden
+	_options.Case = gitignore.CaseSensitive
+	_repository = gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _dir)
+	}
+	do(t, _repository.Relative, match{"BUILD/", "", false, false})
+	do(t, _repository.Relative, match{"sub/TEMP", "", false, false})
+	do(t, _repository.Relative, match{"Build/", "Build/", true, false})
+} // TestRepositoryIgnoreCase()
@@ -0,0 +1,123 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+// FuzzParser parses arbitrary .gitignore content, ensuring the indexed and
+// compiled patterns of the GitIgnore match the paths derived from the
+// content as the individual patterns returned by the Parser match them.
+func FuzzParser(f *testing.F) {
+	for _, _content := range []string{_GITIGNORE, _GITINVALID, _LOOKUPIGNORE} {
+		f.Add(_content)
+	}
+	for _, _test := range _CONFORMANCE {
+		f.Add(_test.Ignore)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		_ignore := gitignore.New(strings.NewReader(content), "/base", nil)
+		_parser := gitignore.NewParser(strings.NewReader(content), nil)
+		_patterns := _parser.Parse()
+
+		// the last matching pattern takes precedence
+		for _, _path := range candidates(content) {
+			for _, _isdir := range []bool{false, true} {
+				var _expected gitignore.Pattern
+				for _i := len(_patterns) - 1; _i >= 0; _i-- {
+					if _patterns[_i].Match(_path, _isdir) {
+						_expected = _patterns[_i]
+						break
+					}
+				}
+
+				_match := _ignore.Relative(_path, _isdir)
+				if _expected == nil && _match == nil {
+					continue
+				} else if _expected == nil {
+					t.Fatalf(
+						"%q (dir %v): unexpected match %q at %s",
+						_path, _isdir, _match.String(), pos(_match.Position()),
+					)
+				} else if _match == nil {
+					t.Fatalf(
+						"%q (dir %v): expected match %q at %s",
+						_path, _isdir, _expected.String(), pos(_expected.Position()),
+					)
+				} else if !coincident(_match.Position(), _expected.Position()) {
+					t.Fatalf(
+						"%q (dir %v): expected match %q at %s, got %q at %s",
+						_path, _isdir,
+						_expected.String(), pos(_expected.Position()),
+						_match.String(), pos(_match.Position()),
+					)
+				}
+			}
+		}
+	})
+} // FuzzParser()
+
+// FuzzPattern matches an arbitrary path against an arbitrary pattern,
+// ensuring the compiled pattern of the GitIgnore matches the path as the
+// Pattern returned by the Parser matches it.
+func FuzzPattern(f *testing.F) {
+	for _, _test := range _CONFORMANCE {
+		for _, _line := range strings.Split(_test.Ignore, "\n") {
+			for _, _match := range _test.Matches {
+				_path := strings.TrimSuffix(_match.Path, "/")
+				f.Add(_line, _path, _path != _match.Path)
+			}
+		}
+	}
+	for _, _test := range _WILDMATCHTESTS {
+		f.Add(_test.Pattern, _test.Text, false)
+	}
+
+	f.Fuzz(func(t *testing.T, pattern, path string, isdir bool) {
+		// only consider single patterns
+		if strings.ContainsAny(pattern, "\r\n") {
+			t.Skip()
+		}
+
+		_ignore := gitignore.New(strings.NewReader(pattern), "/base", nil)
+		_parser := gitignore.NewParser(strings.NewReader(pattern), nil)
+		_patterns := _parser.Parse()
+
+		_expected := false
+		for _, _pattern := range _patterns {
+			_expected = _expected || _pattern.Match(path, isdir)
+		}
+		_match := _ignore.Relative(path, isdir) != nil
+		if _match != _expected {
+			t.Fatalf(
+				"pattern %q, path %q (dir %v): expected match %v, got %v",
+				pattern, path, isdir, _expected, _match,
+			)
+		}
+	})
+} // FuzzPattern()
+
+// candidates returns the paths to be matched against the patterns of the
+// .gitignore content: the patterns themselves, stripped of their negation
+// and separators, with and without their wildcards, and nested within a
+// directory.
+func candidates(content string) []string {
+	_wildcards := strings.NewReplacer("*", "x", "?", "x", "[", "", "]", "", "\\", "")
+
+	_candidates := []string{"x"}
+	for _, _line := range strings.Split(content, "\n") {
+		_line = strings.TrimPrefix(strings.TrimSpace(_line), "!")
+		_line = strings.Trim(_line, "/")
+		if _line == "" {
+			continue
+		}
+
+		_plain := _wildcards.Replace(_line)
+		_candidates = append(_candidates, _line, _plain, "a/"+_line, "a/"+_plain)
+	}
+
+	return _candidates
+} // candidates()
@@ -38,6 +38,16 @@ type GitIgnore interface {
 	// returned.
 	Relative(path string, isdir bool) Match
 
+	// MatchAll attempts to match a path relative to the GitIgnore base
+	// directory, as with Relative, returning every pattern that matches the
+	// path, rather than only the pattern that determines whether the path is
+	// ignored. Patterns are returned in increasing order of precedence,
+	// with the patterns matching the parent directories of the path (if
+	// considered) before the patterns matching the path itself. The Trace of
+	// the pattern returned by Relative is marked as Decisive. If the path is
+	// not matched by the GitIgnore, nil is returned.
+	MatchAll(path string, isdir bool) []Trace
+
 	// Ignore returns true if the path is ignored by this GitIgnore. Paths
 	// that are not matched by this GitIgnore are not ignored. Internally,
 	// Ignore uses Match, and will return false if Match() returns nil for path.
@@ -53,6 +63,8 @@ type GitIgnore interface {
 type ignore struct {
 	_base    string
 	_pattern []Pattern
+	_lookup  *lookup
+	_form    Normalization
 	_errors  func(Error) bool
 }
 
@@ -68,16 +80,99 @@ func New(r io.Reader, base string, errors func(Error) bool) GitIgnore {
 		_errors = func(e Error) bool { return true }
 	}
 
+	return parse(r, base, "", settings{}, _errors)
+} // New()
+
+// NewWithDialect creates a new GitIgnore instance from the patterns listed in
+// r, as with New, where r is an ignore file of the given dialect (see
+// Dialect). If dialect is nil, GitDialect is used.
+func NewWithDialect(r io.Reader, base string, dialect Dialect, errors func(Error) bool) GitIgnore {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	// do we have a dialect?
+	if dialect == nil {
+		dialect = GitDialect
+	}
+
+	return parse(r, base, "", settings{_dialect: dialect}, _errors)
+} // NewWithDialect()
+
+// NewWithIgnoreCase creates a new GitIgnore instance from the patterns listed
+// in r, as with New, where paths are matched case-insensitively, as git does
+// when core.ignorecase is true. Paths and patterns are compared using Unicode
+// simple case folding, with the exception of escaped characters and the
+// content of character classes (e.g. "[a-z]"), which, as with git, are only
+// matched case-insensitively for ASCII letters.
+func NewWithIgnoreCase(r io.Reader, base string, errors func(Error) bool) GitIgnore {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	return parse(r, base, "", settings{_casefold: true}, _errors)
+} // NewWithIgnoreCase()
+
+// NewWithNormalization creates a new GitIgnore instance from the patterns
+// listed in r, as with New, where the patterns, and the paths matched against
+// them, are normalized to the Unicode normalization form (see Normalization),
+// so that, for instance, a decomposed (NFD) path reported by a macOS file
+// system matches a pattern typed in the precomposed (NFC) form. Since there
+// is no repository configuration to consult, NormalizeConfig is treated as
+// NormalizeNone.
+func NewWithNormalization(r io.Reader, base string, form Normalization, errors func(Error) bool) GitIgnore {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	return parse(r, base, "", settings{_form: form}, _errors)
+} // NewWithNormalization()
+
+// parse returns the GitIgnore instance for the patterns read from r,
+// representing the ignore file in the base directory, parsed according to
+// settings. The positions of the patterns (and of any parsing errors) record
+// file as their File.
+func parse(r io.Reader, base, file string, settings settings, errors func(Error) bool) *ignore {
 	// extract the patterns from the reader
-	_parser := NewParser(r, _errors)
+	var _parser Parser
+	_dialect := settings.dialect()
+	if settings._includes {
+		_parser = newParserWithIncludes(r, file, _dialect, settings._form, errors)
+	} else {
+		_lexer := &offset{Lexer: _dialect.Lexer(settings._form.reader(r))}
+		_lexer._position = Position{File: file, Line: 1, Column: 1}
+		_parser = &parser{_lexer: _lexer, _error: errors, _dialect: _dialect}
+	}
 	_patterns := _parser.Parse()
+	if settings._casefold {
+		foldpatterns(_patterns)
+	}
 
-	return &ignore{_base: base, _pattern: _patterns, _errors: _errors}
-} // New()
+	return &ignore{
+		_base:    base,
+		_pattern: _patterns,
+		_lookup:  newLookup(_patterns, settings._casefold),
+		_form:    settings._form,
+		_errors:  errors,
+	}
+} // parse()
 
 // NewFromFile creates a GitIgnore instance from the given file. An error
 // will be returned if file cannot be opened or its absolute path determined.
 func NewFromFile(file string) (GitIgnore, error) {
+	return newFromFile(file, false, NormalizeNone)
+} // NewFromFile()
+
+// newFromFile creates a GitIgnore instance from the given file, as with
+// NewFromFile, where the patterns are case-insensitive if casefold is true,
+// and normalized to form.
+func newFromFile(file string, casefold bool, form Normalization) (GitIgnore, error) {
 	// define an error handler to catch any file access errors
 	//		- record the first encountered error
 	var _error Error
@@ -89,7 +184,8 @@ func NewFromFile(file string) (GitIgnore, error) {
 	}
 
 	// attempt to retrieve the GitIgnore represented by this file
-	_ignore := NewWithErrors(file, _errors)
+	_settings := settings{_casefold: casefold, _form: form}
+	_ignore := newWithErrors(file, _settings, _errors)
 
 	// did we encounter an error?
 	//		- if the error has a zero Position then it was encountered
@@ -102,7 +198,7 @@ func NewFromFile(file string) (GitIgnore, error) {
 
 	// otherwise, we ignore the parser errors
 	return _ignore, nil
-} // NewFromFile()
+} // newFromFile()
 
 // NewWithErrors creates a GitIgnore instance from the given file.
 // If errors is given, it will be invoked for every error encountered when
@@ -110,6 +206,28 @@ func NewFromFile(file string) (GitIgnore, error) {
 // and returns false, otherwise, parsing will continue until end of file has
 // been reached. NewWithErrors returns nil if the .gitignore could not be read.
 func NewWithErrors(file string, errors func(Error) bool) GitIgnore {
+	return newWithErrors(file, settings{}, errors)
+} // NewWithErrors()
+
+// NewWithIncludes creates a GitIgnore instance from the given file, as with
+// NewWithErrors, where comments of the form "#!include:<path>" are replaced
+// by the patterns of the file at path, so that, for example, a tool-specific
+// ignore file may include the .gitignore of its directory with
+// "#!include:.gitignore". Relative paths are resolved against the directory
+// of the including file, and the included patterns are matched relative to
+// the directory of file. The Position of each Match names the file
+// containing the matching pattern. Include cycles are reported to errors as
+// IncludeCycleError, and include files that cannot be read are reported with
+// the position of the include directive. NewWithIncludes returns nil if file
+// could not be read.
+func NewWithIncludes(file string, errors func(Error) bool) GitIgnore {
+	return newWithErrors(file, settings{_includes: true}, errors)
+} // NewWithIncludes()
+
+// newWithErrors creates a GitIgnore instance from the given file, parsed
+// according to settings. newWithErrors returns nil if the file could not be
+// read.
+func newWithErrors(file string, settings settings, errors func(Error) bool) GitIgnore {
 	var _err error
 
 	// do we have an error handler?
@@ -123,8 +241,12 @@ func NewWithErrors(file string, errors func(Error) bool) GitIgnore {
 		//		  not aware of file names
 		_errors = func(e Error) bool {
 			// augment the position with the file name
+			//		- errors within included files already name the
+			//		  included file
 			_position := e.Position()
-			_position.File = _file
+			if _position.File == "" {
+				_position.File = _file
+			}
 
 			// create a new error with the updated Position
 			_error := NewError(e.Underlying(), _position)
@@ -149,9 +271,12 @@ func NewWithErrors(file string, errors func(Error) bool) GitIgnore {
 		return nil
 	}
 
+	defer _fh.Close()
+
 	// return the GitIgnore instance
-	return New(_fh, _base, _errors)
-} // NewWithErrors()
+	//		- the positions of the patterns record the ignore file
+	return parse(_fh, _base, _file, settings, _errors)
+} // newWithErrors()
 
 // NewWithCache returns a GitIgnore instance (using NewWithErrors)
 // for the given file. If the file has been loaded before, its GitIgnore
@@ -169,6 +294,12 @@ func NewWithErrors(file string, errors func(Error) bool) GitIgnore {
 // and returns false, otherwise, parsing will continue until end of file has
 // been reached.
 func NewWithCache(file string, cache Cache, errors func(Error) bool) GitIgnore {
+	return newWithCache(file, cache, settings{}, errors)
+} // NewWithCache()
+
+// newWithCache returns a GitIgnore instance for the given file, as with
+// NewWithCache, where the file is parsed according to settings.
+func newWithCache(file string, cache Cache, settings settings, errors func(Error) bool) GitIgnore {
 	// do we have an error handler?
 	_errors := errors
 	if _errors == nil {
@@ -187,7 +318,7 @@ func NewWithCache(file string, cache Cache, errors func(Error) bool) GitIgnore {
 		_ignore = cache.Get(_abs)
 	}
 	if _ignore == nil {
-		_ignore = NewWithErrors(file, _errors)
+		_ignore = newWithErrors(file, settings, _errors)
 		if _ignore == nil {
 			// if the load failed, cache an empty GitIgnore to prevent
 			// further attempts to load this file
@@ -204,7 +335,7 @@ func NewWithCache(file string, cache Cache, errors func(Error) bool) GitIgnore {
 	} else {
 		return _ignore
 	}
-} // NewWithCache()
+} // newWithCache()
 
 // Base returns the directory containing the .gitignore file for this GitIgnore.
 func (i *ignore) Base() string {
@@ -242,13 +373,17 @@ func (i *ignore) Match(path string) Match {
 // the path is not located under the base directory of this GitIgnore, or
 // is not matched by this GitIgnore, nil is returned.
 func (i *ignore) Absolute(path string, isdir bool) Match {
+	// compare the normalized forms of the path and base directory
+	path = i._form.string(path)
+	_base := i._form.string(i._base)
+
 	// does the file share the same directory as this ignore file?
-	if !strings.HasPrefix(path, i._base) {
+	if !strings.HasPrefix(path, _base) {
 		return nil
 	}
 
 	// extract the relative path of this file
-	_prefix := len(i._base) + 1
+	_prefix := len(_base) + 1
 	_rel := string(path[_prefix:])
 	return i.Relative(_rel, isdir)
 } // Absolute()
@@ -264,6 +399,14 @@ func (i *ignore) Relative(path string, isdir bool) Match {
 		_rel = filepath.ToSlash(_rel)
 	}
 
+	// paths are matched in the normalized form of the patterns
+	_rel = i._form.string(_rel)
+
+	// use the index of the patterns if we have it
+	if i._lookup != nil {
+		return i._lookup.Match(_rel, isdir)
+	}
+
 	// iterate over the patterns for this ignore file
 	//      - iterate in reverse, since later patterns overwrite earlier
 	for _i := len(i._pattern) - 1; _i >= 0; _i-- {
@@ -277,6 +420,31 @@ func (i *ignore) Relative(path string, isdir bool) Match {
 	return nil
 } // Relative()
 
+// MatchAll attempts to match a path relative to the GitIgnore base
+// directory, returning every pattern of this GitIgnore that matches the path,
+// in the order of the patterns. The last matching pattern is decisive. If the
+// path is not matched by the GitIgnore, nil is returned.
+func (i *ignore) MatchAll(path string, isdir bool) []Trace {
+	// if we are on Windows, then translate the path to Unix form
+	_rel := path
+	if runtime.GOOS == "windows" {
+		_rel = filepath.ToSlash(_rel)
+	}
+
+	// paths are matched in the normalized form of the patterns
+	_rel = i._form.string(_rel)
+
+	// match every pattern, rather than using the index of the patterns
+	var _traces []Trace
+	for _, _pattern := range i._pattern {
+		if _pattern.Match(_rel, isdir) {
+			_traces = append(_traces, Trace{Match: _pattern, Path: _rel})
+		}
+	}
+
+	return decide(_traces, len(_traces)-1)
+} // MatchAll()
+
 // Ignore returns true if the path is ignored by this GitIgnore. Paths
 // that are not matched by this GitIgnore are not ignored. Internally,
 // Ignore uses Match, and will return false if Match() returns nil for path.
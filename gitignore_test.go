@@ -5,6 +5,7 @@ import (
 
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/denormal/go-gitignore"
 )
@@ -205,6 +206,15 @@ func TestNew(t *testing.T) {
 	}
 } // TestNew()
 
+func TestNewWithoutErrors(t *testing.T) {
+	// ensure a GitIgnore without an error handler can report errors
+	_ignore := gitignore.New(strings.NewReader(_GITIGNORE), "/base", nil)
+	_path := filepath.Join(os.TempDir(), "gitignore-missing", "file")
+	if _match := _ignore.Match(_path); _match != nil {
+		t.Errorf("unexpected match for missing path %q: %q", _path, _match)
+	}
+} // TestNewWithoutErrors()
+
 func withfile(t *testing.T, test *gitignoretest, content string) {
 	// create a temporary .gitignore
 	_file, _err := file(content)
@@ -334,3 +344,96 @@ func withfile(t *testing.T, test *gitignoretest, content string) {
 		t.Fatalf("expected nil GitIgnore, got %v", _ignore)
 	}
 } // withfile()
+
+func TestNewWithIncludes(t *testing.T) {
+	// create the ignore files for the include tests
+	_dir, _err := dir(_INCLUDEFILES)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// record the errors encountered following the includes
+	_errors := make([]gitignore.Error, 0)
+	_file := filepath.Join(_dir, ".toolignore")
+	_ignore := gitignore.NewWithIncludes(_file, func(e gitignore.Error) bool {
+		_errors = append(_errors, e)
+		return true
+	})
+	if _ignore == nil {
+		t.Fatalf("unable to read %q", _file)
+	}
+
+	// ensure the included patterns are matched, reporting their file
+	for _, _test := range _INCLUDEMATCHES {
+		_path := match{Path: _test.Path}
+		_match := _ignore.Relative(_path.Local(), _path.IsDir())
+		if _match == nil {
+			if _test.Pattern != "" {
+				t.Errorf("failed match for %q; expected %q",
+					_test.Path, _test.Pattern,
+				)
+			}
+			continue
+		} else if _match.String() != _test.Pattern {
+			t.Errorf("pattern mismatch for %q; expected %q, got %q",
+				_test.Path, _test.Pattern, _match.String(),
+			)
+			continue
+		}
+
+		_position := _match.Position()
+		_file := filepath.Join(_dir, filepath.FromSlash(_test.File))
+		if _position.File != _file || _position.Line != _test.Line {
+			t.Errorf("position mismatch for %q; expected %s:%d, got %s",
+				_test.Path, _file, _test.Line, _position,
+			)
+		}
+	}
+
+	// ensure the missing include and the include cycle were reported
+	if len(_errors) != 2 {
+		t.Fatalf("error count mismatch; expected 2, got %d", len(_errors))
+	}
+	if !os.IsNotExist(_errors[0].Underlying()) {
+		t.Errorf("error mismatch; expected missing file, got %q",
+			_errors[0].Underlying(),
+		)
+	} else if _errors[0].Position().Line != 5 {
+		t.Errorf("error position mismatch; expected line 5, got %s",
+			_errors[0].Position(),
+		)
+	}
+	_cycle := filepath.Join(_dir, "cycle.ignore")
+	if _errors[1].Underlying() != gitignore.IncludeCycleError {
+		t.Errorf("error mismatch; expected %q, got %q",
+			gitignore.IncludeCycleError, _errors[1].Underlying(),
+		)
+	} else if _position := _errors[1].Position(); _position.File != _cycle ||
+		_position.Line != 2 {
+		t.Errorf("error position mismatch; expected %s:2, got %s",
+			_cycle, _position,
+		)
+	}
+
+	// without includes, the directives are comments
+	_ignore = gitignore.NewWithErrors(_file, nil)
+	if _match := _ignore.Relative("a.log", false); _match != nil {
+		t.Errorf("unexpected match for %q by %q", "a.log", _match)
+	}
+
+	// repositories may follow the includes of their ignore files
+	_options := gitignore.Options{
+		Files:    []string{".toolignore"},
+		Includes: true,
+		NoGlobal: true,
+	}
+	_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _dir)
+	}
+	_match := _repository.Relative("a.log", false)
+	if _match == nil || _match.Position().File != filepath.Join(_dir, gitignore.File) {
+		t.Errorf("expected %q to be matched by %s", "a.log", gitignore.File)
+	}
+} // TestNewWithIncludes()
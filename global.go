@@ -0,0 +1,51 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// global attempts to return the GitIgnore instance for the global excludes
+// file of the working copy with root directory base and the given gitdir.
+// The excludes file is taken from the core.excludesFile configuration
+// variable, falling back to $XDG_CONFIG_HOME/git/ignore (or
+// $HOME/.config/git/ignore if $XDG_CONFIG_HOME is not set). If there is no
+// global excludes file, global returns nil. If casefold is true, the
+// patterns of the excludes file are case-insensitive, and the patterns are
+// normalized to form.
+func global(base, gitdir string, casefold bool, form Normalization) (GitIgnore, error) {
+	// consult the git configuration for core.excludesFile
+	_config, _err := newConfig(gitdir)
+	if _err != nil {
+		return nil, _err
+	}
+	_file, _ok := _config.get("core.excludesfile")
+	if _ok {
+		_file = expand(_file)
+		if !filepath.IsAbs(_file) {
+			// relative paths are interpreted relative to the work tree
+			_file = filepath.Join(base, _file)
+		}
+	} else {
+		_file = xdg("ignore")
+	}
+	if _file == "" {
+		return nil, nil
+	}
+
+	// does the excludes file exist?
+	//		- git silently ignores a missing excludes file
+	_info, _err := os.Stat(_file)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return nil, nil
+		} else {
+			return nil, _err
+		}
+	} else if _info.IsDir() {
+		return nil, nil
+	}
+
+	// attempt to load the excludes file
+	return newFromFile(_file, casefold, form)
+} // global()
@@ -0,0 +1,119 @@
+package gitignore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestRepositoryGlobal(t *testing.T) {
+	// create the repository & the home directory for the global tests
+	_dir, _err := dir(_GLOBALREPOSITORY)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	_home, _err := dir(nil)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_home)
+
+	// define the locations of the global excludes file to test
+	//		- each test lists the location of the excludes file relative
+	//		  to the home directory, and the environment to use
+	_config := filepath.Join(_home, "gitconfig")
+	_tests := []struct {
+		file string
+		env  map[string]string
+	}{
+		{".config/git/ignore", nil},
+		{"xdg/git/ignore", map[string]string{
+			"XDG_CONFIG_HOME": filepath.Join(_home, "xdg"),
+		}},
+		{"excludes", map[string]string{
+			"GIT_CONFIG_GLOBAL": _config,
+		}},
+	}
+	for _, _test := range _tests {
+		// establish the environment for this test
+		_env := map[string]string{
+			"HOME":                _home,
+			"XDG_CONFIG_HOME":     "",
+			"GIT_DIR":             "",
+			"GIT_CONFIG_GLOBAL":   "",
+			"GIT_CONFIG_NOSYSTEM": "1",
+		}
+		for _k, _v := range _test.env {
+			_env[_k] = _v
+		}
+		_restore, _err := setenv(_env)
+		if _err != nil {
+			t.Fatalf("unable to set environment: %s", _err.Error())
+		}
+
+		// create the global excludes file
+		_file := filepath.Join(_home, filepath.FromSlash(_test.file))
+		_err = os.MkdirAll(filepath.Dir(_file), _GITMASK)
+		if _err != nil {
+			t.Fatalf("unable to create directory: %s", _err.Error())
+		}
+		_err = ioutil.WriteFile(_file, []byte(_GITGLOBAL), _GITMASK)
+		if _err != nil {
+			t.Fatalf("unable to create excludes file: %s", _err.Error())
+		}
+
+		// reference the excludes file from the global configuration
+		//		- we use an include to ensure these are followed
+		_err = ioutil.WriteFile(
+			_config,
+			[]byte("[include]\n\tpath = included\n"),
+			_GITMASK,
+		)
+		if _err != nil {
+			t.Fatalf("unable to create configuration: %s", _err.Error())
+		}
+		_err = ioutil.WriteFile(
+			filepath.Join(_home, "included"),
+			[]byte("[core]\n\texcludesFile = \"~/excludes\" ; comment\n"),
+			_GITMASK,
+		)
+		if _err != nil {
+			t.Fatalf("unable to create configuration: %s", _err.Error())
+		}
+
+		// ensure the global excludes file is used
+		_repository, _err := gitignore.NewRepository(_dir)
+		if _err != nil {
+			t.Fatalf("unable to create repository: %s", _err.Error())
+		}
+		_cb := func(path string, isdir bool) gitignore.Match {
+			return _repository.Relative(path, isdir)
+		}
+		for _, _match := range _GLOBALMATCHES {
+			do(t, _cb, _match)
+		}
+
+		// ensure the global excludes file is not used when disabled
+		_repository = gitignore.NewRepositoryWithOptions(
+			_dir, gitignore.Options{NoGlobal: true},
+		)
+		if _repository == nil {
+			t.Fatalf("unable to create repository without global excludes")
+		}
+		_cb = func(path string, isdir bool) gitignore.Match {
+			return _repository.Relative(path, isdir)
+		}
+		for _, _match := range _GLOBALMATCHESNONE {
+			do(t, _cb, _match)
+		}
+
+		// restore the environment & remove the excludes file
+		_restore()
+		os.Remove(_file)
+	}
+} // TestRepositoryGlobal()
@@ -0,0 +1,497 @@
+package gitignore
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// define the name of the Mercurial ignore file
+const HgIgnoreFile = ".hgignore"
+
+// define the prefix of Mercurial syntax lines (e.g. "syntax: glob")
+const _HGSYNTAX = "syntax:"
+
+// define the syntaxes of Mercurial ignore patterns
+const (
+	_HGREGEXP = iota
+	_HGGLOB
+	_HGROOTGLOB
+	_HGINCLUDE
+	_HGSUBINCLUDE
+)
+
+// _HGSYNTAXES maps the names of Mercurial syntaxes, as used in syntax lines
+// and as pattern prefixes (e.g. "glob:*.o"), to their syntax
+var _HGSYNTAXES = map[string]int{
+	"re":         _HGREGEXP,
+	"regexp":     _HGREGEXP,
+	"relre":      _HGREGEXP,
+	"glob":       _HGGLOB,
+	"relglob":    _HGGLOB,
+	"rootglob":   _HGROOTGLOB,
+	"include":    _HGINCLUDE,
+	"subinclude": _HGSUBINCLUDE,
+}
+
+// hgignore is the implementation of a GitIgnore following the semantics of
+// Mercurial ignore files
+type hgignore struct {
+	ignore
+	_patterns []*hgpattern
+} // hgignore{}
+
+// hgpattern represents a single pattern of a Mercurial ignore file
+type hgpattern struct {
+	_pattern  string
+	_prefix   string
+	_regexp   *regexp.Regexp
+	_position Position
+} // hgpattern{}
+
+// NewHgIgnore returns a GitIgnore for the Mercurial ignore patterns read from
+// r, for the repository with root directory base. Mercurial ignore files
+// differ from .gitignore files:
+//
+//   - patterns are regular expressions by default, and a line of the form
+//     "syntax: glob", "syntax: regexp" or "syntax: rootglob" changes the
+//     syntax of the patterns that follow
+//   - a pattern may be given its own syntax with a "re:", "glob:" or
+//     "rootglob:" prefix
+//   - regular expressions match anywhere within the path, unless anchored
+//     with "^"
+//   - glob patterns match at any directory level, while rootglob patterns
+//     are anchored at the root of the repository
+//   - a path is matched if the pattern matches the path or any of its parent
+//     directories, and there are no "!" exceptions
+//   - "include:file" reads the patterns of another file, while
+//     "subinclude:file" reads patterns that apply only within the directory
+//     of that file, with both files located relative to the directory of the
+//     file containing the directive
+//   - comments start at any "#" not escaped as "\#"
+//
+// Regular expressions are interpreted by the Go regexp package, which accepts
+// the syntax supported by Mercurial's RE2 engine, but not the extensions of
+// Python regular expressions (such as back-references).
+//
+// Since r has no location, include and subinclude files are located relative
+// to base. If errors is given, it will be invoked for every invalid pattern,
+// syntax, and include file, which are otherwise ignored.
+func NewHgIgnore(r io.Reader, base string, errors func(Error) bool) GitIgnore {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	_hg := &hgignore{ignore: ignore{_base: base, _errors: _errors}}
+	_hg.read(r, "", base, "", 0)
+
+	return _hg
+} // NewHgIgnore()
+
+// NewHgIgnoreFromFile returns a GitIgnore for the Mercurial ignore file
+// file, for the repository with root directory given by the directory
+// containing file (as for the .hgignore file of a repository). An error is
+// returned if file cannot be opened. If errors is given, it will be invoked
+// for every invalid pattern, syntax, and include file, which are otherwise
+// ignored.
+func NewHgIgnoreFromFile(file string, errors func(Error) bool) (GitIgnore, error) {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	// the repository is rooted at the directory of the file
+	_file, _err := filepath.Abs(file)
+	if _err != nil {
+		return nil, _err
+	}
+	_fh, _err := os.Open(_file)
+	if _err != nil {
+		return nil, _err
+	}
+	defer _fh.Close()
+
+	_base := filepath.Dir(_file)
+	_hg := &hgignore{ignore: ignore{_base: _base, _errors: _errors}}
+	_hg.read(_fh, _file, _base, "", 0)
+
+	return _hg, nil
+} // NewHgIgnoreFromFile()
+
+// read parses the Mercurial ignore patterns from r, with positions reported
+// against file. Include and subinclude files are located relative to dir,
+// and the patterns read apply only to paths beneath prefix, which is either
+// empty or a directory relative to the root of the repository with a trailing
+// "/". read returns false if the error handler has requested parsing to stop.
+func (h *hgignore) read(r io.Reader, file, dir, prefix string, depth int) bool {
+	_scanner := bufio.NewScanner(r)
+	_position := Position{File: file}
+	_next := 0
+	_syntax := _HGREGEXP
+	for _scanner.Scan() {
+		_line := _scanner.Text()
+		_position.Line++
+		_position.Column = 1
+		_position.Offset = _next
+		_next += len([]rune(_line)) + 1
+
+		// remove comments and trailing whitespace
+		_line = strings.TrimRight(uncomment(_line), " \t\r\n\v\f")
+		if _line == "" {
+			continue
+		}
+
+		// does this line change the syntax of the following patterns?
+		if strings.HasPrefix(_line, _HGSYNTAX) {
+			_name := strings.TrimSpace(_line[len(_HGSYNTAX):])
+			_new, _ok := _HGSYNTAXES[_name]
+			if !_ok {
+				if !h._errors(NewError(InvalidSyntaxError, _position)) {
+					return false
+				}
+				continue
+			}
+			_syntax = _new
+			continue
+		}
+
+		// does this pattern have its own syntax?
+		_kind := _syntax
+		_pattern := _line
+		_colon := strings.IndexByte(_line, ':')
+		if _colon > 0 {
+			_new, _ok := _HGSYNTAXES[_line[:_colon]]
+			if _ok {
+				_kind = _new
+				_pattern = _line[_colon+1:]
+			}
+		}
+
+		// should we read patterns from another file?
+		if _kind == _HGINCLUDE || _kind == _HGSUBINCLUDE {
+			if !h.include(_pattern, _kind, dir, prefix, depth, _position) {
+				return false
+			}
+			continue
+		}
+
+		// compile the pattern
+		_regexp, _err := hgcompile(_pattern, _kind)
+		if _err != nil {
+			if !h._errors(NewError(_err, _position)) {
+				return false
+			}
+			continue
+		}
+		h._patterns = append(h._patterns, &hgpattern{
+			_pattern:  _line,
+			_prefix:   prefix,
+			_regexp:   _regexp,
+			_position: _position,
+		})
+	}
+
+	// were there any errors reading the file?
+	_err := _scanner.Err()
+	if _err != nil {
+		return h._errors(NewError(_err, _position))
+	}
+
+	return true
+} // read()
+
+// include reads the patterns of the include or subinclude file path, located
+// relative to dir, for the directive at position. For subinclude files, the
+// patterns apply only to paths beneath the directory containing the file.
+// include returns false if the error handler has requested parsing to stop.
+func (h *hgignore) include(path string, kind int, dir, prefix string, depth int, position Position) bool {
+	if depth >= _INCLUDEDEPTH {
+		return h._errors(NewError(IncludeDepthError, position))
+	}
+
+	// locate the file to include
+	_file := expand(os.ExpandEnv(path))
+	if !filepath.IsAbs(_file) {
+		_file = filepath.Join(dir, filepath.FromSlash(_file))
+	}
+	_dir := filepath.Dir(_file)
+
+	// subinclude patterns are relative to the directory of the file
+	//		- the directory must be within the repository
+	_prefix := prefix
+	if kind == _HGSUBINCLUDE {
+		_rel, _err := filepath.Rel(h._base, _dir)
+		if _err != nil || _rel == ".." ||
+			strings.HasPrefix(_rel, ".."+string(filepath.Separator)) {
+			return h._errors(NewError(InvalidPatternError, position))
+		}
+		_prefix = ""
+		if _rel != "." {
+			_prefix = filepath.ToSlash(_rel) + string(_SEPARATOR)
+		}
+	}
+
+	_fh, _err := os.Open(_file)
+	if _err != nil {
+		return h._errors(NewError(_err, position))
+	}
+	defer _fh.Close()
+
+	return h.read(_fh, _file, _dir, _prefix, depth+1)
+} // include()
+
+// uncomment returns line with any comment removed, where a comment starts at
+// the first "#" not preceded by an odd number of "\", and escaped "\#"
+// sequences replaced by "#".
+func uncomment(line string) string {
+	_escaped := false
+	for _i := 0; _i < len(line); _i++ {
+		switch {
+		case _escaped:
+			_escaped = false
+		case line[_i] == byte(_ESCAPE):
+			_escaped = true
+		case line[_i] == byte(_COMMENT):
+			line = line[:_i]
+		}
+	}
+
+	return strings.ReplaceAll(line, string(_ESCAPE)+string(_COMMENT), string(_COMMENT))
+} // uncomment()
+
+// hgcompile returns the regular expression for the Mercurial pattern with the
+// given syntax, as constructed by Mercurial. An error is returned if the
+// pattern is not a valid regular expression.
+func hgcompile(pattern string, kind int) (*regexp.Regexp, error) {
+	// construct the expression matched against the start of the path
+	//		- glob patterns match the path or any of its parent
+	//		  directories
+	var _regexp string
+	switch kind {
+	case _HGGLOB:
+		_regexp = "(?:|.*/)" + hgglob(pattern) + "(?:/|$)"
+	case _HGROOTGLOB:
+		_regexp = hgglob(pattern) + "(?:/|$)"
+	default:
+		_regexp = pattern
+		if !strings.HasPrefix(pattern, "^") {
+			_regexp = ".*" + pattern
+		}
+	}
+
+	_compiled, _err := regexp.Compile("^(?:" + _regexp + ")")
+	if _err != nil {
+		return nil, InvalidPatternError
+	}
+	return _compiled, nil
+} // hgcompile()
+
+// hgglob returns the regular expression equivalent of the Mercurial glob
+// pattern, where "*" does not match "/", "**" matches any number of
+// directories, "?" matches any character, "[...]" is a character class
+// (negated with "!"), and "{a,b}" matches either alternative.
+func hgglob(pattern string) string {
+	_runes := []rune(pattern)
+	_regexp := ""
+	_group := 0
+	for _i := 0; _i < len(_runes); _i++ {
+		_rune := _runes[_i]
+		switch {
+		case _rune == _WILDCARD:
+			// "**/" matches zero or more directories, while "**" matches
+			// anything
+			if _i+1 < len(_runes) && _runes[_i+1] == _WILDCARD {
+				_i++
+				if _i+1 < len(_runes) && _runes[_i+1] == _SEPARATOR {
+					_i++
+					_regexp += "(?:.*/)?"
+				} else {
+					_regexp += ".*"
+				}
+			} else {
+				_regexp += "[^/]*"
+			}
+		case _rune == '?':
+			_regexp += "."
+		case _rune == '[':
+			// find the end of the character class
+			//		- an unterminated class is a literal "["
+			_end := _i + 1
+			if _end < len(_runes) && (_runes[_end] == '!' || _runes[_end] == ']') {
+				_end++
+			}
+			for _end < len(_runes) && _runes[_end] != ']' {
+				_end++
+			}
+			if _end >= len(_runes) {
+				_regexp += `\[`
+				continue
+			}
+			_class := strings.ReplaceAll(string(_runes[_i+1:_end]), `\`, `\\`)
+			if strings.HasPrefix(_class, "!") {
+				_class = "^" + _class[1:]
+			} else if strings.HasPrefix(_class, "^") {
+				_class = `\` + _class
+			}
+			_regexp += "[" + _class + "]"
+			_i = _end
+		case _rune == '{':
+			_group++
+			_regexp += "(?:"
+		case _rune == '}' && _group > 0:
+			_group--
+			_regexp += ")"
+		case _rune == ',' && _group > 0:
+			_regexp += "|"
+		case _rune == _ESCAPE && _i+1 < len(_runes):
+			_i++
+			_regexp += regexp.QuoteMeta(string(_runes[_i]))
+		default:
+			_regexp += regexp.QuoteMeta(string(_rune))
+		}
+	}
+
+	return _regexp
+} // hgglob()
+
+// Match attempts to match the path against this Mercurial ignore file.
+func (h *hgignore) Match(path string) Match {
+	// ensure we have the absolute path for the given file
+	_path, _err := filepath.Abs(path)
+	if _err != nil {
+		h._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	// is the path a file or a directory?
+	_info, _err := os.Stat(_path)
+	if _err != nil {
+		h._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	return h.Absolute(_path, _info.IsDir())
+} // Match()
+
+// Absolute attempts to match an absolute path against this Mercurial ignore
+// file. If the path is not located under the root of the repository, or is
+// not matched, nil is returned.
+func (h *hgignore) Absolute(path string, isdir bool) Match {
+	_rel, _err := filepath.Rel(h._base, path)
+	if _err != nil || strings.HasPrefix(_rel, "..") {
+		return nil
+	}
+
+	return h.Relative(_rel, isdir)
+} // Absolute()
+
+// Relative attempts to match a path, relative to the root of the repository,
+// against this Mercurial ignore file. The returned Match is the first pattern
+// matching the path, or one of its parent directories. If no pattern matches,
+// nil is returned. Since Mercurial patterns cannot distinguish files from
+// directories, isdir is not used.
+func (h *hgignore) Relative(path string, isdir bool) Match {
+	// the root of the repository cannot be ignored
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return nil
+	}
+
+	// Mercurial ignores a path if any pattern matches
+	for _, _pattern := range h._patterns {
+		if _pattern.match(_path) {
+			return _pattern
+		}
+	}
+
+	return nil
+} // Relative()
+
+// MatchAll attempts to match a path, relative to the root of the repository,
+// against this Mercurial ignore file, returning every pattern matching the
+// path, or one of its parent directories, in the order of the patterns. The
+// Path of each Trace is the path, or the first of its parent directories,
+// matched by the pattern. The first matching pattern is decisive.
+func (h *hgignore) MatchAll(path string, isdir bool) []Trace {
+	// the root of the repository cannot be ignored
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return nil
+	}
+
+	var _traces []Trace
+	for _, _pattern := range h._patterns {
+		_matched := _pattern.matched(_path)
+		if _matched != "" {
+			_traces = append(_traces, Trace{Match: _pattern, Path: _matched})
+		}
+	}
+
+	return decide(_traces, 0)
+} // MatchAll()
+
+// Ignore returns true if the path is ignored by this Mercurial ignore file.
+func (h *hgignore) Ignore(path string) bool {
+	return h.Match(path) != nil
+} // Ignore()
+
+// Include returns true if the path is not ignored by this Mercurial ignore
+// file.
+func (h *hgignore) Include(path string) bool {
+	return h.Match(path) == nil
+} // Include()
+
+// match returns true if the path, relative to the root of the repository, or
+// one of its parent directories, is matched by this pattern.
+func (p *hgpattern) match(path string) bool {
+	return p.matched(path) != ""
+} // match()
+
+// matched returns the path, or the first of its parent directories, matched
+// by this pattern, or the empty string if the pattern does not match.
+func (p *hgpattern) matched(path string) string {
+	if !strings.HasPrefix(path, p._prefix) {
+		return ""
+	}
+
+	// a matching directory hides its contents, so the pattern is matched
+	// against each of the parent directories of the path, and the path
+	//		- glob patterns already match the parent directories, but
+	//		  regular expressions only match the given path
+	_path := path[len(p._prefix):]
+	for _i := 0; _i <= len(_path); _i++ {
+		if _i < len(_path) && _path[_i] != byte(_SEPARATOR) {
+			continue
+		} else if p._regexp.MatchString(_path[:_i]) {
+			return path[:len(p._prefix)+_i]
+		}
+	}
+
+	return ""
+} // matched()
+
+// Ignore returns true, since Mercurial patterns always ignore the paths they
+// match.
+func (p *hgpattern) Ignore() bool { return true }
+
+// Include returns false, since Mercurial patterns have no exceptions.
+func (p *hgpattern) Include() bool { return false }
+
+// String returns the pattern as written in the ignore file, including any
+// syntax prefix.
+func (p *hgpattern) String() string { return p._pattern }
+
+// Position returns the position of the pattern within the Mercurial ignore
+// file.
+func (p *hgpattern) Position() Position { return p._position }
+
+// ensure hgignore satisfies the GitIgnore interface, and hgpattern satisfies
+// the Match interface
+var _ GitIgnore = &hgignore{}
+var _ Match = &hgpattern{}
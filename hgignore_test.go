@@ -0,0 +1,85 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestHgIgnore(t *testing.T) {
+	// create the repository for the Mercurial tests
+	_dir, _err := dir(_HGREPOSITORY)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// record the errors encountered parsing the .hgignore
+	_errors := make([]gitignore.Error, 0)
+	_file := filepath.Join(_dir, gitignore.HgIgnoreFile)
+	_ignore, _err := gitignore.NewHgIgnoreFromFile(_file,
+		func(e gitignore.Error) bool {
+			_errors = append(_errors, e)
+			return true
+		},
+	)
+	if _err != nil {
+		t.Fatalf("unable to read %q: %s", _file, _err.Error())
+	}
+
+	// ensure each path is matched by the expected pattern and line
+	for _, _test := range _HGMATCHES {
+		_match := _ignore.Relative(filepath.FromSlash(_test.Path), false)
+		if _match == nil {
+			if _test.Pattern != "" {
+				t.Errorf("failed match for %q; expected %q",
+					_test.Path, _test.Pattern,
+				)
+			}
+			continue
+		} else if _match.String() != _test.Pattern {
+			t.Errorf("pattern mismatch for %q; expected %q, got %q",
+				_test.Path, _test.Pattern, _match.String(),
+			)
+			continue
+		} else if !_match.Ignore() {
+			t.Errorf("expected %q to be ignored", _test.Path)
+		}
+
+		// ensure the match reports the position of the pattern
+		_position := _match.Position()
+		_file := filepath.Join(_dir, filepath.FromSlash(_test.File))
+		if _position.File != _file || _position.Line != _test.Line {
+			t.Errorf("position mismatch for %q; expected %s:%d, got %s",
+				_test.Path, _file, _test.Line, _position,
+			)
+		}
+	}
+
+	// ensure the invalid syntax and pattern were reported
+	_expected := []struct {
+		Error error
+		Line  int
+	}{
+		{gitignore.InvalidSyntaxError, 10},
+		{gitignore.InvalidPatternError, 15},
+	}
+	if len(_errors) != len(_expected) {
+		t.Fatalf("error count mismatch; expected %d, got %d",
+			len(_expected), len(_errors),
+		)
+	}
+	for _i, _e := range _expected {
+		if _errors[_i].Underlying() != _e.Error {
+			t.Errorf("error mismatch; expected %q, got %q",
+				_e.Error, _errors[_i].Underlying(),
+			)
+		} else if _errors[_i].Position().Line != _e.Line {
+			t.Errorf("error position mismatch; expected line %d, got %s",
+				_e.Line, _errors[_i].Position(),
+			)
+		}
+	}
+} // TestHgIgnore()
@@ -0,0 +1,403 @@
+package gitignore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// define the index file signatures and flags
+const (
+	_INDEXSIGNATURE = "DIRC"
+	_INDEXLINK      = "link"
+	_INDEXSPARSE    = "sdir"
+	_INDEXEXTENDED  = 0x4000
+	_INDEXNAMEMASK  = 0x0fff
+	_SHA1SIZE       = 20
+	_SHA256SIZE     = 32
+)
+
+// Index is the interface to the git index of a repository, describing the
+// set of paths tracked by the repository.
+type Index interface {
+	// Tracked returns true if path, relative to the root of the work tree,
+	// is tracked by the index. A directory is tracked if it contains at
+	// least one tracked path.
+	Tracked(path string, isdir bool) bool
+
+	// Paths returns the sorted list of paths tracked by the index. Sparse
+	// directory entries are listed with a trailing "/".
+	Paths() []string
+} // Index{}
+
+// index is the implementation of the git Index
+type index struct {
+	_paths  []string
+	_sparse bool
+} // index{}
+
+// indexfile represents the content of a single index file
+type indexfile struct {
+	_entries []string
+	_shared  string
+	_deleted map[int]bool
+	_sparse  bool
+} // indexfile{}
+
+// NewIndexFromFile returns the Index represented by the git index file.
+// Index versions 2, 3 and 4 are supported, including split indexes (where
+// the shared index is located in the same directory as file) and sparse
+// indexes. The untracked cache and other optional extensions are ignored,
+// since they do not describe tracked paths. The object format of the index
+// (SHA-1 or SHA-256) is taken from the extensions.objectFormat variable of
+// the configuration of the git directory containing file.
+func NewIndexFromFile(file string) (Index, error) {
+	// determine the size of the object IDs in the index
+	_hashsize, _err := hashsize(filepath.Dir(file))
+	if _err != nil {
+		return nil, _err
+	}
+
+	return readIndex(file, _hashsize)
+} // NewIndexFromFile()
+
+// hashsize returns the size in bytes of the object IDs of the repository
+// with the given gitdir, as determined by extensions.objectFormat.
+func hashsize(gitdir string) (int, error) {
+	_config, _err := newConfig(gitdir)
+	if _err != nil {
+		return 0, _err
+	}
+	_format, _ := _config.get("extensions.objectformat")
+	if strings.ToLower(_format) == "sha256" {
+		return _SHA256SIZE, nil
+	}
+
+	return _SHA1SIZE, nil
+} // hashsize()
+
+// readIndex returns the Index represented by the git index file, with object
+// IDs of hashsize bytes. If file is a split index, the shared index is read
+// from the same directory as file.
+func readIndex(file string, hashsize int) (Index, error) {
+	_file, _err := readIndexFile(file, hashsize)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// if we have a split index, merge the shared entries
+	_paths := _file._entries
+	_sparse := _file._sparse
+	if _file._shared != "" {
+		_name := filepath.Join(filepath.Dir(file), "sharedindex."+_file._shared)
+		_shared, _err := readIndexFile(_name, hashsize)
+		if _err != nil {
+			return nil, _err
+		}
+
+		// the final index is the shared entries that have not been
+		// deleted, combined with the entries of the split index
+		//		- replaced entries retain the name of the shared entry,
+		//		  so we can ignore them here
+		_paths = make([]string, 0, len(_shared._entries)+len(_file._entries))
+		for _i, _path := range _shared._entries {
+			if !_file._deleted[_i] {
+				_paths = append(_paths, _path)
+			}
+		}
+		_paths = append(_paths, _file._entries...)
+		_sparse = _sparse || _shared._sparse
+	}
+
+	// sort the paths and remove duplicates (i.e. conflict stages)
+	sort.Strings(_paths)
+	_unique := make([]string, 0, len(_paths))
+	for _i, _path := range _paths {
+		if _i == 0 || _path != _paths[_i-1] {
+			_unique = append(_unique, _path)
+		}
+	}
+
+	return &index{_paths: _unique, _sparse: _sparse}, nil
+} // readIndex()
+
+// readIndexFile parses the content of the index file, with object IDs of
+// hashsize bytes. Entries with an empty name (i.e. split index replacements)
+// are omitted.
+func readIndexFile(file string, hashsize int) (*indexfile, error) {
+	_data, _err := ioutil.ReadFile(file)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// ensure we have a valid header
+	if len(_data) < 12+hashsize || string(_data[:4]) != _INDEXSIGNATURE {
+		return nil, InvalidIndexError
+	}
+	_version := binary.BigEndian.Uint32(_data[4:8])
+	if _version < 2 || _version > 4 {
+		return nil, InvalidIndexError
+	}
+	_count := int(binary.BigEndian.Uint32(_data[8:12]))
+	_end := len(_data) - hashsize
+
+	// extract the entry names
+	_file := &indexfile{_entries: make([]string, 0, _count)}
+	_offset := 12
+	_previous := []byte{}
+	for _i := 0; _i < _count; _i++ {
+		// skip the stat information and object ID
+		_start := _offset
+		_offset += 40 + hashsize + 2
+		if _offset > _end {
+			return nil, InvalidIndexError
+		}
+		_mode := binary.BigEndian.Uint32(_data[_start+24:])
+		_flags := binary.BigEndian.Uint16(_data[_offset-2:])
+		if _flags&_INDEXEXTENDED != 0 {
+			if _version < 3 {
+				return nil, InvalidIndexError
+			}
+			_offset += 2
+		}
+
+		// extract the entry name
+		var _name []byte
+		if _version == 4 {
+			// version 4 names are prefix-compressed against the
+			// previous entry name
+			_strip, _n := varint(_data[_offset:_end])
+			if _n == 0 || _strip > len(_previous) {
+				return nil, InvalidIndexError
+			}
+			_offset += _n
+			_nul := bytes.IndexByte(_data[_offset:_end], 0)
+			if _nul == -1 {
+				return nil, InvalidIndexError
+			}
+			_name = append(
+				append([]byte{}, _previous[:len(_previous)-_strip]...),
+				_data[_offset:_offset+_nul]...,
+			)
+			_offset += _nul + 1
+			_previous = _name
+		} else {
+			// earlier versions pad each entry with NULs to a multiple of
+			// eight bytes
+			_nul := bytes.IndexByte(_data[_offset:_end], 0)
+			if _nul == -1 {
+				return nil, InvalidIndexError
+			}
+			_name = _data[_offset : _offset+_nul]
+			_offset = _start + ((_offset - _start + _nul + 8) &^ 7)
+			if _offset > _end {
+				return nil, InvalidIndexError
+			}
+		}
+
+		// split index replacement entries have no name
+		if _flags&_INDEXNAMEMASK == 0 && len(_name) == 0 {
+			continue
+		}
+
+		// is this a sparse directory entry?
+		if _mode&_MODEMASK == _MODETREE {
+			_file._sparse = true
+		}
+		_file._entries = append(_file._entries, string(_name))
+	}
+
+	// process the index extensions
+	for _offset+8 <= _end {
+		_signature := string(_data[_offset : _offset+4])
+		_size := int(binary.BigEndian.Uint32(_data[_offset+4:]))
+		_offset += 8
+		if _offset+_size > _end {
+			return nil, InvalidIndexError
+		}
+		_extension := _data[_offset : _offset+_size]
+		_offset += _size
+
+		switch _signature {
+		// the split index link identifies the shared index, and the
+		// entries deleted from the shared index
+		case _INDEXLINK:
+			if len(_extension) < hashsize {
+				return nil, InvalidIndexError
+			}
+			// a null shared index ID indicates there is no shared index
+			_id := _extension[:hashsize]
+			if !bytes.Equal(_id, make([]byte, hashsize)) {
+				_file._shared = hex.EncodeToString(_id)
+			}
+			if len(_extension) > hashsize {
+				_deleted, _, _err := ewah(_extension[hashsize:])
+				if _err != nil {
+					return nil, _err
+				}
+				_file._deleted = _deleted
+			}
+
+		// the sparse index extension requires no processing, since sparse
+		// directories are recognised from their mode
+		case _INDEXSPARSE:
+
+		default:
+			// extensions starting with an upper-case letter are optional
+			//		- we cannot process an index with an unknown required
+			//		  extension
+			if _signature[0] < 'A' || _signature[0] > 'Z' {
+				return nil, UnsupportedIndexError
+			}
+		}
+	}
+
+	return _file, nil
+} // readIndexFile()
+
+// varint decodes the variable-length offset integer used by git at the start
+// of data, returning the value and the number of bytes consumed. If data
+// does not contain a complete integer, varint returns 0 bytes consumed.
+func varint(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	_c := data[0]
+	_value := int(_c & 0x7f)
+	_n := 1
+	for _c&0x80 != 0 {
+		if _n >= len(data) {
+			return 0, 0
+		}
+		_value++
+		_c = data[_n]
+		_value = (_value << 7) + int(_c&0x7f)
+		_n++
+	}
+
+	return _value, _n
+} // varint()
+
+// ewah decodes the EWAH compressed bitmap at the start of data, returning the
+// set of positions of the set bits and the number of bytes consumed.
+func ewah(data []byte) (map[int]bool, int, error) {
+	// extract the bitmap header
+	if len(data) < 8 {
+		return nil, 0, InvalidIndexError
+	}
+	_words := int(binary.BigEndian.Uint32(data[4:8]))
+	_size := 8 + 8*_words + 4
+	if len(data) < _size {
+		return nil, 0, InvalidIndexError
+	}
+	_word := func(i int) uint64 {
+		return binary.BigEndian.Uint64(data[8+8*i:])
+	}
+
+	// decode the sequence of running length words, each followed by
+	// a number of literal words
+	_bits := make(map[int]bool)
+	_position := 0
+	for _i := 0; _i < _words; {
+		_rlw := _word(_i)
+		_i++
+		_running := _rlw&1 != 0
+		_length := int((_rlw >> 1) & 0xffffffff)
+		_literals := int(_rlw >> 33)
+
+		// add the running words
+		if _running {
+			for _b := 0; _b < _length*64; _b++ {
+				_bits[_position+_b] = true
+			}
+		}
+		_position += _length * 64
+
+		// add the literal words
+		for _l := 0; _l < _literals && _i < _words; _l++ {
+			_literal := _word(_i)
+			_i++
+			for _b := 0; _b < 64; _b++ {
+				if _literal&(1<<uint(_b)) != 0 {
+					_bits[_position+_b] = true
+				}
+			}
+			_position += 64
+		}
+	}
+
+	return _bits, _size, nil
+} // ewah()
+
+// Tracked returns true if path, relative to the root of the work tree, is
+// tracked by the index. A directory is tracked if it contains at least one
+// tracked path.
+func (i *index) Tracked(path string, isdir bool) bool {
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return len(i._paths) > 0
+	}
+
+	// is this path in the index?
+	_n := sort.SearchStrings(i._paths, _path)
+	if _n < len(i._paths) && i._paths[_n] == _path {
+		return true
+	}
+
+	// does this directory contain tracked paths?
+	if isdir {
+		_prefix := _path + string(_SEPARATOR)
+		_n = sort.SearchStrings(i._paths, _prefix)
+		if _n < len(i._paths) && strings.HasPrefix(i._paths[_n], _prefix) {
+			return true
+		}
+	}
+
+	// is this path located within a sparse directory?
+	if i._sparse {
+		_parts := strings.Split(_path, string(_SEPARATOR))
+		for _j := 1; _j < len(_parts); _j++ {
+			_dir := strings.Join(_parts[:_j], string(_SEPARATOR)) +
+				string(_SEPARATOR)
+			_n = sort.SearchStrings(i._paths, _dir)
+			if _n < len(i._paths) && i._paths[_n] == _dir {
+				return true
+			}
+		}
+	}
+
+	return false
+} // Tracked()
+
+// Paths returns the sorted list of paths tracked by the index.
+func (i *index) Paths() []string {
+	return append([]string{}, i._paths...)
+} // Paths()
+
+// indexfor returns the Index read from the index file of the repository with
+// the given common directory. If the index file does not exist, indexfor
+// returns an empty Index.
+func indexfor(file, common string) (Index, error) {
+	// an absent index has no tracked files
+	_, _err := os.Stat(file)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return &index{}, nil
+		}
+		return nil, _err
+	}
+
+	_hashsize, _err := hashsize(common)
+	if _err != nil {
+		return nil, _err
+	}
+	return readIndex(file, _hashsize)
+} // indexfor()
+
+// ensure index satisfies the Index interface
+var _ Index = &index{}
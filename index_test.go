@@ -0,0 +1,196 @@
+package gitignore_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestIndex(t *testing.T) {
+	_dir, _err := dir(nil)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure each index version is correctly read
+	for _, _version := range []int{2, 3, 4} {
+		_file := filepath.Join(_dir, "index")
+		_err = ioutil.WriteFile(
+			_file, indexed(_version, _INDEXPATHS, nil), _GITMASK,
+		)
+		if _err != nil {
+			t.Fatalf("unable to create index: %s", _err.Error())
+		}
+
+		_index, _err := gitignore.NewIndexFromFile(_file)
+		if _err != nil {
+			t.Fatalf("unable to read version %d index: %s", _version, _err)
+		}
+		tracked(t, _index, _INDEXPATHS, _INDEXTRACKED)
+	}
+
+	// ensure invalid indexes are rejected
+	_file := filepath.Join(_dir, "invalid")
+	_invalid := indexed(2, _INDEXPATHS, nil)
+	_invalid[4] = 0xff
+	_err = ioutil.WriteFile(_file, _invalid, _GITMASK)
+	if _err != nil {
+		t.Fatalf("unable to create index: %s", _err.Error())
+	}
+	_, _err = gitignore.NewIndexFromFile(_file)
+	if _err != gitignore.InvalidIndexError {
+		t.Errorf(
+			"invalid index error mismatch; expected %q, got %v",
+			gitignore.InvalidIndexError, _err,
+		)
+	}
+} // TestIndex()
+
+func TestIndexSplit(t *testing.T) {
+	_dir, _err := dir(nil)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// create the shared index
+	//		- the shared index contains a path deleted by the split index
+	_shared := append([]string{"deleted.go"}, _INDEXPATHS[:2]...)
+	_content := indexed(2, _shared, nil)
+	_id := _content[len(_content)-20:]
+	_name := filepath.Join(_dir, "sharedindex."+hex.EncodeToString(_id))
+	_err = ioutil.WriteFile(_name, _content, _GITMASK)
+	if _err != nil {
+		t.Fatalf("unable to create shared index: %s", _err.Error())
+	}
+
+	// create the split index, deleting the first shared entry
+	_link := append([]byte{}, _id...)
+	_link = append(_link, ewah(0)...)
+	_link = append(_link, ewah()...)
+	_extension := append([]byte("link"), make([]byte, 4)...)
+	binary.BigEndian.PutUint32(_extension[4:], uint32(len(_link)))
+	_extension = append(_extension, _link...)
+
+	_file := filepath.Join(_dir, "index")
+	_err = ioutil.WriteFile(
+		_file, indexed(2, _INDEXPATHS[2:], _extension), _GITMASK,
+	)
+	if _err != nil {
+		t.Fatalf("unable to create index: %s", _err.Error())
+	}
+
+	// ensure the split index is combined with the shared index
+	_index, _err := gitignore.NewIndexFromFile(_file)
+	if _err != nil {
+		t.Fatalf("unable to read split index: %s", _err.Error())
+	}
+	tracked(t, _index, _INDEXPATHS, _INDEXTRACKED)
+	if _index.Tracked("deleted.go", false) {
+		t.Errorf("unexpected tracked path %q deleted by split index",
+			"deleted.go",
+		)
+	}
+} // TestIndexSplit()
+
+func TestIndexSparse(t *testing.T) {
+	_dir, _err := dir(nil)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// create a sparse index, where src/ is a sparse directory entry
+	_paths := []string{"build/keep.o", "main.o", "src/"}
+	_file := filepath.Join(_dir, "index")
+	_err = ioutil.WriteFile(
+		_file, indexed(3, _paths, []byte("sdir\x00\x00\x00\x00")), _GITMASK,
+	)
+	if _err != nil {
+		t.Fatalf("unable to create index: %s", _err.Error())
+	}
+
+	// ensure paths under sparse directories are tracked
+	_index, _err := gitignore.NewIndexFromFile(_file)
+	if _err != nil {
+		t.Fatalf("unable to read sparse index: %s", _err.Error())
+	}
+	tracked(t, _index, _paths, _INDEXSPARSE)
+} // TestIndexSparse()
+
+func TestRepositoryTracked(t *testing.T) {
+	// create the repository with its index
+	_dir, _err := dir(map[string]string{
+		gitignore.File: _INDEXIGNORE,
+		".git/index":   string(indexed(4, _INDEXPATHS, nil)),
+	})
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure GIT_DIR and GIT_INDEX_FILE do not override the index
+	_restore, _err := setenv(map[string]string{
+		"GIT_DIR":        "",
+		"GIT_INDEX_FILE": "",
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// perform the tests with and without consulting the index
+	_tests := map[bool][]match{
+		true:  _INDEXMATCHES,
+		false: _INDEXMATCHESNONE,
+	}
+	for _tracked, _matches := range _tests {
+		_options := gitignore.Options{Tracked: _tracked, NoGlobal: true}
+		_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+		if _repository == nil {
+			t.Fatalf("unable to create repository")
+		}
+		_cb := func(path string, isdir bool) gitignore.Match {
+			return _repository.Relative(path, isdir)
+		}
+		for _, _match := range _matches {
+			do(t, _cb, _match)
+		}
+	}
+} // TestRepositoryTracked()
+
+func tracked(t *testing.T, index gitignore.Index, paths []string, m []match) {
+	// ensure the index lists the expected paths
+	_expected := append([]string{}, paths...)
+	sort.Strings(_expected)
+	_paths := index.Paths()
+	if len(_paths) != len(_expected) {
+		t.Fatalf("index paths mismatch; expected %v, got %v",
+			_expected, _paths,
+		)
+	}
+	for _i := range _paths {
+		if _paths[_i] != _expected[_i] {
+			t.Fatalf("index paths mismatch; expected %v, got %v",
+				_expected, _paths,
+			)
+		}
+	}
+
+	// ensure paths are tracked as expected
+	for _, _match := range m {
+		_tracked := index.Tracked(_match.Local(), _match.IsDir())
+		if _tracked != _match.Ignore {
+			t.Errorf("index tracked mismatch for %q; expected %v, got %v",
+				_match.Path, _match.Ignore, _tracked,
+			)
+		}
+	}
+} // tracked()
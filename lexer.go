@@ -3,6 +3,7 @@ package gitignore
 import (
 	"bufio"
 	"io"
+	"unicode/utf8"
 )
 
 //
@@ -154,10 +155,20 @@ func (l *lexer) read() (rune, Error) {
 
 		// otherwise, attempt to read a new rune
 	} else {
-		_r, _, _err = l._r.ReadRune()
+		var _size int
+		_r, _size, _err = l._r.ReadRune()
 		if _err == io.EOF {
 			return _EOF, nil
 		}
+
+		// bytes that are not valid UTF-8 are read as raw bytes
+		//		- git matches file names byte for byte, so we must not
+		//		  replace these bytes with U+FFFD
+		if _r == utf8.RuneError && _size == 1 {
+			l._r.UnreadRune()
+			_byte, _ := l._r.ReadByte()
+			_r = rawrune(_byte)
+		}
 	}
 
 	// increment the offset and column counts
@@ -470,5 +481,51 @@ func (l *lexer) beginning() bool {
 	return l._column == 1
 } // beginning()
 
+// offset is a Lexer that reports the positions of the tokens of an embedded
+// Lexer relative to a position within an enclosing stream (such as the
+// position of a pattern within a line of a .gitattributes file)
+type offset struct {
+	Lexer
+	_position Position
+} // offset{}
+
+// Next returns the next Token from the embedded Lexer, with its position
+// relative to the enclosing stream.
+func (o *offset) Next() (*Token, Error) {
+	_token, _err := o.Lexer.Next()
+	if _token != nil {
+		_token.Position = o.shift(_token.Position)
+	}
+	if _err != nil {
+		_err = NewError(_err.Underlying(), o.shift(_err.Position()))
+	}
+	return _token, _err
+} // Next()
+
+// Position returns the current position of the Lexer within the enclosing
+// stream.
+func (o *offset) Position() Position {
+	return o.shift(o.Lexer.Position())
+} // Position()
+
+// String returns the string representation of the current position of the
+// Lexer within the enclosing stream.
+func (o *offset) String() string {
+	return o.Position().String()
+} // String()
+
+// shift returns the position p of the embedded Lexer as a position within
+// the enclosing stream.
+func (o *offset) shift(p Position) Position {
+	if p.Line == 1 {
+		p.Column += o._position.Column - 1
+	}
+	p.Line += o._position.Line - 1
+	p.Offset += o._position.Offset
+	p.File = o._position.File
+	return p
+} // shift()
+
 // ensure the lexer conforms to the lexer interface
 var _ Lexer = &lexer{}
+var _ Lexer = &offset{}
@@ -0,0 +1,196 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// loader is the interface used by a repository to retrieve the ignore files
+// of its directories, and to detect the nested repositories they contain.
+// Directories are given relative to the repository base.
+type loader interface {
+	// load returns the GitIgnore for the ignore file in the directory dir,
+	// or nil if dir has no ignore file.
+	load(dir string) GitIgnore
+
+	// nested returns true if dir is the root of a nested repository.
+	nested(dir string) bool
+
+	// open returns the repository rooted at the nested repository directory
+	// root, or nil if the content of the nested repository is unavailable.
+	open(root string, options Options) (*repository, error)
+} // loader{}
+
+// files is the loader for repositories with a work tree, reading the ignore
+// files from the file system
+type files struct {
+	_base     string
+	_files    []string
+	_dialects []Dialect
+	_cache    Cache
+	_settings settings
+	_errors   func(Error) bool
+} // files{}
+
+// layers is the GitIgnore combining the ignore files of a single directory,
+// where the patterns of later files take precedence over earlier files
+type layers struct {
+	ignore
+	_layers []GitIgnore
+} // layers{}
+
+// load returns the GitIgnore for the ignore files in the directory dir, or
+// nil if dir has no ignore files.
+func (f *files) load(dir string) GitIgnore {
+	_layers := make([]GitIgnore, 0, len(f._files))
+	for _i, _name := range f._files {
+		// some dialects only read the ignore file of the root directory
+		_settings := f._settings
+		_settings._dialect = f._dialects[_i]
+		if !_settings._dialect.PerDirectory() && filepath.Clean(dir) != "." {
+			continue
+		}
+
+		_file := filepath.Join(f._base, dir, _name)
+		_ignore := newWithCache(_file, f._cache, _settings, f._errors)
+		if _ignore != nil {
+			_layers = append(_layers, _ignore)
+		}
+	}
+
+	return layer(filepath.Join(f._base, dir), _layers, f._errors)
+} // load()
+
+// nested returns true if dir contains a .git directory or file.
+func (f *files) nested(dir string) bool {
+	_, _err := os.Lstat(filepath.Join(f._base, dir, ".git"))
+	return _err == nil
+} // nested()
+
+// open returns the repository rooted at the nested repository directory root.
+func (f *files) open(root string, options Options) (*repository, error) {
+	// locate the GIT_DIR of the nested repository
+	//		- this ignores the git environment (such as GIT_DIR), which
+	//		  refers to the enclosing repository
+	_base := filepath.Join(f._base, root)
+	_gitdir, _err := locate(filepath.Join(_base, ".git"))
+	if _err != nil {
+		return nil, _err
+	}
+	_options := options
+	_options.Environment = &Environment{}
+
+	return newRepository(_base, _gitdir, _options)
+} // open()
+
+// layer returns the GitIgnore combining the ignore files of the directory
+// base, given in increasing order of precedence. If there are no ignore files,
+// layer returns nil.
+func layer(base string, ignores []GitIgnore, errors func(Error) bool) GitIgnore {
+	switch len(ignores) {
+	case 0:
+		return nil
+	case 1:
+		return ignores[0]
+	}
+
+	_ignore := ignore{_base: base, _errors: errors}
+	return &layers{ignore: _ignore, _layers: ignores}
+} // layer()
+
+// Match attempts to match the path against the ignore files of the directory.
+func (l *layers) Match(path string) Match {
+	// ensure we have the absolute path for the given file
+	_path, _err := filepath.Abs(path)
+	if _err != nil {
+		l._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	// is the path a file or a directory?
+	_info, _err := os.Stat(_path)
+	if _err != nil {
+		l._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	return l.Absolute(_path, _info.IsDir())
+} // Match()
+
+// Absolute attempts to match an absolute path against the ignore files of the
+// directory.
+func (l *layers) Absolute(path string, isdir bool) Match {
+	for _i := len(l._layers) - 1; _i >= 0; _i-- {
+		_match := l._layers[_i].Absolute(path, isdir)
+		if _match != nil {
+			return _match
+		}
+	}
+
+	return nil
+} // Absolute()
+
+// Relative attempts to match a path relative to the directory against its
+// ignore files, considering the files in decreasing order of precedence.
+func (l *layers) Relative(path string, isdir bool) Match {
+	for _i := len(l._layers) - 1; _i >= 0; _i-- {
+		_match := l._layers[_i].Relative(path, isdir)
+		if _match != nil {
+			return _match
+		}
+	}
+
+	return nil
+} // Relative()
+
+// MatchAll attempts to match a path relative to the directory against its
+// ignore files, returning the patterns of every file matching the path, in
+// increasing order of precedence.
+func (l *layers) MatchAll(path string, isdir bool) []Trace {
+	var _traces []Trace
+	for _, _layer := range l._layers {
+		_traces = append(_traces, _layer.MatchAll(path, isdir)...)
+	}
+
+	return decide(_traces, len(_traces)-1)
+} // MatchAll()
+
+// Ignore returns true if the path is ignored by the ignore files of the
+// directory.
+func (l *layers) Ignore(path string) bool {
+	_match := l.Match(path)
+	if _match != nil {
+		return _match.Ignore()
+	}
+
+	// we didn't match this path, so we don't ignore it
+	return false
+} // Ignore()
+
+// Include returns true if the path is included by the ignore files of the
+// directory.
+func (l *layers) Include(path string) bool {
+	_match := l.Match(path)
+	if _match != nil {
+		return _match.Include()
+	}
+
+	// we didn't match this path, so we include it
+	return true
+} // Include()
+
+// contains returns true if names contains name.
+func contains(names []string, name string) bool {
+	for _, _name := range names {
+		if _name == name {
+			return true
+		}
+	}
+
+	return false
+} // contains()
+
+// ensure files satisfies the loader interface, and layers satisfies the
+// GitIgnore interface
+var _ loader = &files{}
+var _ GitIgnore = &layers{}
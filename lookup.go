@@ -0,0 +1,166 @@
+package gitignore
+
+import (
+	"sort"
+	"strings"
+)
+
+// define the largest number of patterns in a bucket of the lookup
+const _LOOKUPBUCKET = 8
+
+// lookup is the index of the patterns of an ignore file, built when the
+// ignore file is parsed. Most patterns are literal names (e.g.
+// "node_modules"), extensions (e.g. "*.o") or begin with a literal path
+// component (e.g. "/build/"), so lookup buckets these patterns by the name,
+// extension or leading component of the paths they may match, and only the
+// remaining patterns are matched by the automaton for every path.
+type lookup struct {
+	_patterns   []Pattern
+	_names      map[string][]int
+	_extensions map[string][]int
+	_prefixes   map[string][]int
+	_automaton  *automaton
+	_casefold   bool
+} // lookup{}
+
+// newLookup returns the index of the patterns of an ignore file. If casefold
+// is true, the patterns are case-insensitive, and paths are case folded
+// before they are matched.
+func newLookup(patterns []Pattern, casefold bool) *lookup {
+	_lookup := &lookup{
+		_patterns:   patterns,
+		_names:      make(map[string][]int),
+		_extensions: make(map[string][]int),
+		_prefixes:   make(map[string][]int),
+		_casefold:   casefold,
+	}
+
+	// bucket the patterns by their literal names, extensions or prefixes
+	//		- the buckets preserve the order of the patterns
+	_others := make([]int, 0)
+	for _i, _pattern := range patterns {
+		var _p *pattern
+		_name := false
+		switch _kind := _pattern.(type) {
+		case *name:
+			_p, _name = &_kind.pattern, true
+		case *path:
+			_p = &_kind.pattern
+		case *any:
+			_p = &_kind.pattern
+		}
+
+		switch {
+		case _p == nil:
+			_others = append(_others, _i)
+
+		case _p._anchored:
+			// anchored patterns must match the leading path component
+			_prefix := _p._expression
+			if _slash := strings.IndexByte(_prefix, byte(_SEPARATOR)); _slash != -1 {
+				_prefix = _prefix[:_slash]
+			}
+			if _prefix == "" || strings.ContainsAny(_prefix, _WMSPECIAL) {
+				_others = append(_others, _i)
+			} else {
+				_lookup._prefixes[_prefix] = append(_lookup._prefixes[_prefix], _i)
+			}
+
+		default:
+			// unanchored names must match the last path component
+			_ext := extension(_p._expression)
+			if !_name {
+				_others = append(_others, _i)
+			} else if !strings.ContainsAny(_p._expression, _WMSPECIAL) {
+				_lookup._names[_p._expression] = append(_lookup._names[_p._expression], _i)
+			} else if _ext != "" {
+				_lookup._extensions[_ext] = append(_lookup._extensions[_ext], _i)
+			} else {
+				_others = append(_others, _i)
+			}
+		}
+	}
+
+	// large buckets are better matched by the automaton
+	for _, _buckets := range []map[string][]int{
+		_lookup._names, _lookup._extensions, _lookup._prefixes,
+	} {
+		for _key, _bucket := range _buckets {
+			if len(_bucket) > _LOOKUPBUCKET {
+				_others = append(_others, _bucket...)
+				delete(_buckets, _key)
+			}
+		}
+	}
+	sort.Ints(_others)
+
+	// the remaining patterns are matched by the automaton
+	if len(_others) != 0 {
+		_lookup._automaton = newAutomaton(patterns, _others)
+	}
+
+	return _lookup
+} // newLookup()
+
+// Match returns the highest precedence pattern (i.e. the last pattern)
+// matching the path, or nil if path is not matched. The path is assumed to
+// be relative to the base of the ignore file, using "/" separators.
+func (l *lookup) Match(path string, isdir bool) Match {
+	if l._casefold {
+		path = fold(path)
+	}
+
+	_name := path[strings.LastIndexByte(path, byte(_SEPARATOR))+1:]
+	_prefix := path
+	if _slash := strings.IndexByte(path, byte(_SEPARATOR)); _slash != -1 {
+		_prefix = path[:_slash]
+	}
+
+	// find the highest precedence candidate in each bucket
+	_index := l.candidates(l._names[_name], path, isdir, -1)
+	if _dot := strings.LastIndexByte(_name, '.'); _dot != -1 {
+		_index = l.candidates(l._extensions[_name[_dot:]], path, isdir, _index)
+	}
+	_index = l.candidates(l._prefixes[_prefix], path, isdir, _index)
+
+	// the remaining patterns take precedence if they follow the candidate
+	if l._automaton != nil {
+		_index = l._automaton.match(path, isdir, _index)
+	}
+
+	if _index < 0 {
+		return nil
+	}
+	return l._patterns[_index]
+} // Match()
+
+// candidates returns the index of the highest precedence pattern of the
+// bucket matching the path, or floor if no pattern of the bucket with a
+// higher index than floor matches the path.
+func (l *lookup) candidates(bucket []int, path string, isdir bool, floor int) int {
+	for _i := len(bucket) - 1; _i >= 0 && bucket[_i] > floor; _i-- {
+		if l._patterns[bucket[_i]].Match(path, isdir) {
+			return bucket[_i]
+		}
+	}
+
+	return floor
+} // candidates()
+
+// extension returns the extension (e.g. ".o") of the names matched by the
+// wildmatch pattern if the pattern is "*" followed by a literal containing
+// a ".", otherwise extension returns an empty string.
+func extension(pattern string) string {
+	if !strings.HasPrefix(pattern, "*") {
+		return ""
+	}
+
+	_literal := pattern[1:]
+	if strings.ContainsAny(_literal, _WMSPECIAL) {
+		return ""
+	} else if _dot := strings.LastIndexByte(_literal, '.'); _dot != -1 {
+		return _literal[_dot:]
+	}
+
+	return ""
+} // extension()
@@ -0,0 +1,30 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestLookup(t *testing.T) {
+	_reader := strings.NewReader(_LOOKUPIGNORE)
+	_ignore := gitignore.New(_reader, "/base", nil)
+
+	// ensure the literal patterns preserve the order of the patterns
+	for _, _match := range _LOOKUPMATCHES {
+		do(t, _ignore.Relative, _match)
+	}
+} // TestLookup()
+
+func BenchmarkNew(b *testing.B) {
+	_content, _paths := generated(5000, 1000)
+
+	// parse the patterns, and match each path once
+	for _i := 0; _i < b.N; _i++ {
+		_ignore := gitignore.New(strings.NewReader(_content), "/base", nil)
+		for _, _path := range _paths {
+			_ignore.Relative(_path, false)
+		}
+	}
+} // BenchmarkNew()
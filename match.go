@@ -18,6 +18,7 @@ type Match interface {
 	String() string
 
 	// Position returns the position in the .gitignore file at which the
-	// matching pattern was defined.
+	// matching pattern was defined. Where the pattern was read from a file,
+	// the File of the Position names that file.
 	Position() Position
 }
@@ -0,0 +1,214 @@
+package gitignore
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Nested defines how a repository GitIgnore treats paths located within
+// nested repositories and submodules. git regards a nested repository as
+// an opaque boundary: the ignore rules of the enclosing repository apply to
+// the root directory of the nested repository, but not to its contents.
+type Nested int
+
+const (
+	// NestedNone disables the detection of nested repositories, applying
+	// the rules of the enclosing repository to every path. This is the
+	// default, so that the ignore files of the enclosing repository apply
+	// to nested repositories unless a caller opts in to boundary detection.
+	NestedNone Nested = iota
+
+	// NestedRepository matches paths within a nested repository against the
+	// nested repository itself, including its $GIT_DIR/info/exclude, as
+	// git does.
+	NestedRepository
+
+	// NestedBoundary stops matching at the root of a nested repository,
+	// returning a Boundary Match for paths located within it.
+	NestedBoundary
+)
+
+// Boundary is the Match returned by a repository configured with
+// NestedBoundary for paths located within a nested repository or submodule.
+// A Boundary is never ignored.
+type Boundary interface {
+	Match
+
+	// Root returns the root directory of the nested repository, relative to
+	// the base of the enclosing repository.
+	Root() string
+} // Boundary{}
+
+// boundary is the implementation of a nested repository Boundary
+type boundary struct {
+	_root string
+} // boundary{}
+
+// Ignore returns false, since paths within a nested repository are not
+// ignored by the enclosing repository.
+func (b *boundary) Ignore() bool { return false }
+
+// Include returns true, since paths within a nested repository are not
+// ignored by the enclosing repository.
+func (b *boundary) Include() bool { return true }
+
+// String returns the root directory of the nested repository.
+func (b *boundary) String() string { return b._root }
+
+// Position returns the zero Position, since a Boundary is not defined by
+// a pattern.
+func (b *boundary) Position() Position { return Position{} }
+
+// Root returns the root directory of the nested repository, relative to the
+// base of the enclosing repository.
+func (b *boundary) Root() string { return b._root }
+
+// boundary returns the root directory of the outermost nested repository
+// containing path (relative to the repository base), or the empty string if
+// path is not located within a nested repository. Only the directories
+// containing path are considered, so the root of a nested repository is not
+// itself regarded as located within the nested repository.
+func (r *repository) boundary(path string) string {
+	_parts := strings.Split(filepath.ToSlash(path), string(_SEPARATOR))
+	for _i := 1; _i < len(_parts); _i++ {
+		_root := filepath.Join(_parts[:_i]...)
+		if r.root(_root) {
+			return _root
+		}
+	}
+
+	return ""
+} // boundary()
+
+// root returns true if dir (relative to the repository base) is the root of
+// a nested repository. The result is recorded for each directory, so the
+// loader is consulted only once per directory.
+func (r *repository) root(dir string) bool {
+	r._lock.Lock()
+	defer r._lock.Unlock()
+
+	// have we seen this directory before?
+	if _root, _ok := r._roots[dir]; _ok {
+		return _root
+	}
+
+	// record this directory for subsequent matches
+	_root := r._loader.nested(dir)
+	if r._roots == nil {
+		r._roots = make(map[string]bool)
+	}
+	r._roots[dir] = _root
+
+	return _root
+} // root()
+
+// nested attempts to match the path located within the nested repository
+// with the given root directory, according to the Nested configuration of
+// this repository.
+func (r *repository) nested(root, path string, isdir bool) Match {
+	// the root of the nested repository is subject to our rules
+	//		- if it is ignored, so is everything within it
+	_match := r.relative(root, true)
+	if _match != nil {
+		if _match.Ignore() {
+			return _match
+		}
+	}
+
+	// should we report the boundary?
+	if r._options.Nested == NestedBoundary {
+		return &boundary{_root: filepath.ToSlash(root)}
+	}
+
+	// otherwise, match against the nested repository
+	//		- if the content of the nested repository is unavailable
+	//		  (e.g. a submodule of a revision) we report the boundary
+	_nested, _err := r.repository(root)
+	if _err != nil {
+		r._errors(NewError(_err, Position{}))
+		return nil
+	} else if _nested == nil {
+		return &boundary{_root: filepath.ToSlash(root)}
+	}
+	_rel, _err := filepath.Rel(root, path)
+	if _err != nil {
+		r._errors(NewError(_err, Position{}))
+		return nil
+	}
+	return _nested.Relative(_rel, isdir)
+} // nested()
+
+// tracenested returns every pattern matching the path located within the
+// nested repository with the given root directory, following the rules of
+// nested(). Patterns of the nested repository are reported relative to the
+// base directory of this repository.
+func (r *repository) tracenested(root, path string, isdir bool) []Trace {
+	// the root of the nested repository is subject to our rules
+	//		- if it is ignored, so is everything within it
+	_traces := r.trace(root, true)
+	_decisive := decisive(_traces)
+	if _decisive != -1 && _traces[_decisive].Ignore() {
+		return _traces
+	}
+	_traces = decide(_traces, -1)
+
+	// should we report the boundary?
+	_slashed := filepath.ToSlash(root)
+	_boundary := Trace{Match: &boundary{_root: _slashed}, Path: _slashed}
+	if r._options.Nested == NestedBoundary {
+		_traces = append(_traces, _boundary)
+		return decide(_traces, len(_traces)-1)
+	}
+
+	// otherwise, trace the path within the nested repository
+	_nested, _err := r.repository(root)
+	if _err != nil {
+		r._errors(NewError(_err, Position{}))
+		return _traces
+	} else if _nested == nil {
+		_traces = append(_traces, _boundary)
+		return decide(_traces, len(_traces)-1)
+	}
+	_rel, _err := filepath.Rel(root, path)
+	if _err != nil {
+		r._errors(NewError(_err, Position{}))
+		return _traces
+	}
+	for _, _trace := range _nested.MatchAll(_rel, isdir) {
+		_trace.Path = _slashed + string(_SEPARATOR) + _trace.Path
+		_traces = append(_traces, _trace)
+	}
+
+	return _traces
+} // tracenested()
+
+// repository returns the repository instance for the nested repository with
+// the given root directory, creating it if necessary. If the content of the
+// nested repository is unavailable, repository returns nil. An error is
+// returned if the nested repository cannot be created.
+func (r *repository) repository(root string) (*repository, error) {
+	r._lock.Lock()
+	defer r._lock.Unlock()
+
+	// have we seen this repository before?
+	if _nested, _ok := r._repositories[root]; _ok {
+		return _nested, nil
+	}
+
+	// create the nested repository
+	_nested, _err := r._loader.open(root, r._options)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// record this repository for subsequent matches
+	if r._repositories == nil {
+		r._repositories = make(map[string]*repository)
+	}
+	r._repositories[root] = _nested
+
+	return _nested, nil
+} // repository()
+
+// ensure boundary satisfies the Boundary interface
+var _ Boundary = &boundary{}
@@ -0,0 +1,87 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestRepositoryNested(t *testing.T) {
+	// create the repository containing nested repositories
+	_dir, _err := dir(_GITNESTED)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure GIT_DIR does not override the .git directories
+	_restore, _err := setenv(map[string]string{"GIT_DIR": ""})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// perform the nested repository tests for each configuration
+	_tests := map[gitignore.Nested][]match{
+		gitignore.NestedRepository: _NESTEDMATCHES,
+		gitignore.NestedBoundary:   _NESTEDBOUNDARIES,
+		gitignore.NestedNone:       _NESTEDNONE,
+	}
+	for _nested, _matches := range _tests {
+		_options := gitignore.Options{Nested: _nested, NoGlobal: true}
+		_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+		if _repository == nil {
+			t.Fatalf("unable to create repository")
+		}
+		_cb := func(path string, isdir bool) gitignore.Match {
+			return _repository.Relative(path, isdir)
+		}
+		for _, _match := range _matches {
+			do(t, _cb, _match)
+
+			// boundaries should report the nested repository root
+			_got := _repository.Relative(_match.Local(), _match.IsDir())
+			if _boundary, _ok := _got.(gitignore.Boundary); _ok {
+				if _boundary.Root() != _match.Pattern {
+					t.Errorf(
+						"boundary mismatch for %q; expected %q, got %q",
+						_match.Path, _match.Pattern, _boundary.Root(),
+					)
+				}
+			}
+		}
+	}
+} // TestRepositoryNested()
+
+func TestRepositoryNestedDefault(t *testing.T) {
+	// create the repository containing nested repositories
+	_dir, _err := dir(_GITNESTED)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_DIR":             "",
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+		"XDG_CONFIG_HOME":     filepath.Join(_dir, "config"),
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// by default, the rules of the enclosing repository apply to the
+	// paths of nested repositories
+	_repository, _err := gitignore.NewRepository(_dir)
+	if _err != nil {
+		t.Fatalf("unable to create repository: %s", _err.Error())
+	}
+	for _, _match := range _NESTEDNONE {
+		do(t, _repository.Relative, _match)
+	}
+} // TestRepositoryNestedDefault()
@@ -0,0 +1,108 @@
+package gitignore
+
+import (
+	"io"
+	"sync"
+)
+
+// Normalization defines the Unicode normalization form of the paths and
+// patterns of a GitIgnore. Names containing accented characters may be
+// represented either precomposed (NFC, e.g. "é" as U+00E9) or decomposed
+// (NFD, e.g. "e" followed by U+0301), with macOS file systems reporting
+// decomposed names, so that a pattern typed in one form will not match a
+// path in the other form unless both are normalized.
+//
+// To keep this package free of dependencies outside the standard library,
+// the normalization forms are implemented by a Normalizer registered by the
+// program (see RegisterNormalizer).
+type Normalization int
+
+const (
+	// NormalizeConfig normalizes paths and patterns to NFC if
+	// core.precomposeUnicode is true in the configuration of the repository,
+	// otherwise paths and patterns are not normalized. This is the default.
+	NormalizeConfig Normalization = iota
+
+	// NormalizeNone compares paths and patterns as given, regardless of the
+	// configuration of the repository.
+	NormalizeNone
+
+	// NormalizeNFC normalizes paths and patterns to the precomposed
+	// Unicode normalization form C.
+	NormalizeNFC
+
+	// NormalizeNFD normalizes paths and patterns to the decomposed Unicode
+	// normalization form D.
+	NormalizeNFD
+)
+
+// Normalizer converts text to a Unicode normalization form. The forms of
+// golang.org/x/text/unicode/norm (e.g. norm.NFC) satisfy Normalizer.
+type Normalizer interface {
+	// String returns the normalized form of s.
+	String(s string) string
+
+	// Reader returns a reader of the normalized form of the text read
+	// from r.
+	Reader(r io.Reader) io.Reader
+} // Normalizer{}
+
+// the registered normalizers, keyed by normalization form
+var (
+	_NORMALIZERS    = make(map[Normalization]Normalizer)
+	_NORMALIZERLOCK sync.RWMutex
+)
+
+// RegisterNormalizer registers normalizer as the implementation of the
+// normalization form (i.e. NormalizeNFC or NormalizeNFD). No normalizers are
+// registered by default, and the paths and patterns of a form without a
+// registered Normalizer are not normalized, so a program enabling
+// normalization registers the forms it requires, for example:
+//
+//	gitignore.RegisterNormalizer(gitignore.NormalizeNFC, norm.NFC)
+//	gitignore.RegisterNormalizer(gitignore.NormalizeNFD, norm.NFD)
+//
+// If normalizer is nil, the registration for form is removed.
+func RegisterNormalizer(form Normalization, normalizer Normalizer) {
+	_NORMALIZERLOCK.Lock()
+	defer _NORMALIZERLOCK.Unlock()
+
+	if normalizer == nil {
+		delete(_NORMALIZERS, form)
+	} else {
+		_NORMALIZERS[form] = normalizer
+	}
+} // RegisterNormalizer()
+
+// form returns the Normalizer registered for n, and false if n does not
+// normalize paths and patterns, or has no registered Normalizer.
+func (n Normalization) form() (Normalizer, bool) {
+	if n != NormalizeNFC && n != NormalizeNFD {
+		return nil, false
+	}
+
+	_NORMALIZERLOCK.RLock()
+	defer _NORMALIZERLOCK.RUnlock()
+
+	_normalizer, _ok := _NORMALIZERS[n]
+	return _normalizer, _ok
+} // form()
+
+// reader returns a reader of the normalized form of the text read from r.
+func (n Normalization) reader(r io.Reader) io.Reader {
+	if _form, _ok := n.form(); _ok {
+		return _form.Reader(r)
+	}
+
+	return r
+} // reader()
+
+// string returns the normalized form of s. Strings that are already
+// normalized, such as ASCII strings, are returned without copying.
+func (n Normalization) string(s string) string {
+	if _form, _ok := n.form(); _ok {
+		return _form.String(s)
+	}
+
+	return s
+} // string()
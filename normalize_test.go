@@ -0,0 +1,142 @@
+package gitignore_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+// composer is the Normalizer of the normalization tests, composing (or
+// decomposing) the accented characters of the test data
+type composer struct {
+	_replacer *strings.Replacer
+}
+
+// define the normalizers of the normalization tests
+var (
+	_NFC = composer{strings.NewReplacer("e\u0301", "\u00e9", "a\u0308", "\u00e4")}
+	_NFD = composer{strings.NewReplacer("\u00e9", "e\u0301", "\u00e4", "a\u0308")}
+)
+
+func init() {
+	gitignore.RegisterNormalizer(gitignore.NormalizeNFC, _NFC)
+	gitignore.RegisterNormalizer(gitignore.NormalizeNFD, _NFD)
+} // init()
+
+func (c composer) String(s string) string { return c._replacer.Replace(s) }
+
+func (c composer) Reader(r io.Reader) io.Reader {
+	_data, _err := ioutil.ReadAll(r)
+	if _err != nil {
+		return r
+	}
+	return strings.NewReader(c.String(string(_data)))
+} // Reader()
+
+func TestNewWithNormalization(t *testing.T) {
+	_tests := map[gitignore.Normalization][]match{
+		gitignore.NormalizeNFC:  _NORMALIZENFCMATCHES,
+		gitignore.NormalizeNFD:  _NORMALIZENFDMATCHES,
+		gitignore.NormalizeNone: _NORMALIZENONEMATCHES,
+	}
+
+	// ensure equivalent paths match in each normalization form
+	for _form, _matches := range _tests {
+		_reader := strings.NewReader(_NORMALIZEIGNORE)
+		_ignore := gitignore.NewWithNormalization(_reader, "/base", _form, nil)
+		for _, _match := range _matches {
+			do(t, _ignore.Relative, _match)
+		}
+	}
+
+	// ensure absolute paths are normalized
+	_reader := strings.NewReader(_NORMALIZEIGNORE)
+	_ignore := gitignore.NewWithNormalization(_reader, "/caf\u00e9", gitignore.NormalizeNFC, nil)
+	_path := "/cafe\u0301/Cafe\u0301"
+	_match := _ignore.Absolute(_path, true)
+	if _match == nil {
+		t.Fatalf("absolute path %q not matched", _path)
+	} else if _match.String() != "Caf\u00e9/" {
+		t.Fatalf(
+			"absolute path %q: unexpected match; expected %q, got %q",
+			_path, "Caf\u00e9/", _match.String(),
+		)
+	}
+} // TestNewWithNormalization()
+
+func TestRepositoryNormalization(t *testing.T) {
+	// create the repository with core.precomposeUnicode enabled
+	_dir, _err := dir(_NORMALIZEREPOSITORY)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// ensure the repository configuration is honoured
+	_options := gitignore.Options{NoGlobal: true}
+	_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _dir)
+	}
+	for _, _match := range _NORMALIZEREPOSITORYMATCHES {
+		do(t, _repository.Relative, _match)
+	}
+
+	// ensure the configuration may be overridden
+	_options.Normalization = gitignore.NormalizeNone
+	_repository = gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _dir)
+	}
+	do(t, _repository.Relative, match{"Cafe\u0301/", "", false, false})
+	do(t, _repository.Relative, match{"sub/Caf\u00e9.txt", "", false, false})
+	do(t, _repository.Relative, match{"Caf\u00e9/", "Caf\u00e9/", true, false})
+
+	// ensure absolute paths are compared with the normalized base directory
+	//		- the base is precomposed, while the path is decomposed
+	_options.Normalization = gitignore.NormalizeNFC
+	_base := filepath.Join(_dir, "r\u00e9po")
+	_repository = gitignore.NewRepositoryWithOptions(_base, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _base)
+	}
+	_path := filepath.Join(_dir, "re\u0301po", "notes.txt")
+	_match := _repository.Absolute(_path, false)
+	if _match == nil {
+		t.Errorf("absolute path %q not matched", _path)
+	} else if _match.String() != "*.txt" {
+		t.Errorf(
+			"absolute path %q: unexpected match; expected %q, got %q",
+			_path, "*.txt", _match.String(),
+		)
+	}
+} // TestRepositoryNormalization()
+
+func TestUnregisteredNormalizer(t *testing.T) {
+	gitignore.RegisterNormalizer(gitignore.NormalizeNFC, nil)
+	defer gitignore.RegisterNormalizer(gitignore.NormalizeNFC, _NFC)
+
+	// without a Normalizer, paths and patterns are not normalized
+	_reader := strings.NewReader(_NORMALIZEIGNORE)
+	_ignore := gitignore.NewWithNormalization(
+		_reader, "/base", gitignore.NormalizeNFC, nil,
+	)
+	for _, _match := range _NORMALIZENONEMATCHES {
+		do(t, _ignore.Relative, _match)
+	}
+} // TestUnregisteredNormalizer()
@@ -0,0 +1,570 @@
+package gitignore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// define the name of the npm ignore file, and of the npm package manifest
+const (
+	NpmIgnoreFile = ".npmignore"
+	NpmPackage    = "package.json"
+)
+
+// define the paths that npm always excludes from a package, as .gitignore
+// patterns. Since bundled dependencies are not supported, node_modules is
+// always excluded.
+const _NPMEXCLUDES = ".npmignore\n" +
+	".gitignore\n" +
+	".git\n" +
+	".svn\n" +
+	".hg\n" +
+	"CVS\n" +
+	".lock-wscript\n" +
+	".wafpickle-*\n" +
+	".*.swp\n" +
+	".DS_Store\n" +
+	"._*\n" +
+	"npm-debug.log\n" +
+	".npmrc\n" +
+	"node_modules\n" +
+	"config.gypi\n" +
+	"*.orig\n" +
+	"/package-lock.json\n" +
+	"/yarn.lock\n" +
+	"/pnpm-lock.yaml\n" +
+	"/archived-packages/\n"
+
+// define the (case-insensitive) prefixes of the files in the root directory
+// of a package that npm always includes
+var _NPMINCLUDES = []string{"README", "LICENSE", "LICENCE"}
+
+// npm is the implementation of a GitIgnore following the rules used by
+// "npm pack" to select the files of a package
+type npm struct {
+	ignore
+	_repository *repository
+	_excludes   *ignore
+	_package    string
+	_files      []*npmentry
+	_always     map[string]bool
+} // npm{}
+
+// npmfiles is the loader for npm packages, where the .npmignore file of a
+// directory is used in preference to its .gitignore file
+type npmfiles struct {
+	files
+	_root bool
+} // npmfiles{}
+
+// npmmatch is the Match of a path by the package manifest or by the rules
+// built into npm
+type npmmatch struct {
+	_string   string
+	_ignore   bool
+	_position Position
+} // npmmatch{}
+
+// npmentry represents an entry of the "files" list of a package manifest
+type npmentry struct {
+	npmmatch
+	_pattern     *ignore
+	_directories []*ignore
+} // npmentry{}
+
+// NewNpmPackage returns a GitIgnore for the npm package with root directory
+// base, following the rules used by "npm pack" to select the files of the
+// package:
+//
+//   - the .npmignore file of each directory is used in preference to its
+//     .gitignore file, with the usual .gitignore semantics
+//   - if package.json has a "files" list, only the paths it lists are
+//     included, and the ignore file of the root directory is not used
+//   - package.json, and README*, LICENSE* and LICENCE* files, in the root
+//     directory, as well as the "main" and "bin" files of package.json, are
+//     always included
+//   - version control directories, node_modules, .npmrc, lock files and
+//     other build artifacts are always excluded
+//
+// Paths are ignored if they would not be published by "npm pack". Neither
+// the global excludes file nor $GIT_DIR/info/exclude are used. If errors is
+// given, it will be invoked for every error encountered while reading the
+// ignore files of the package.
+//
+// An error is returned if base is not a directory, or if its package.json
+// cannot be read, in which case InvalidManifestError is returned if
+// package.json is not a valid package manifest.
+//
+// The GIT_DIR of the package repository is located using the environment
+// variables of the current process. Internally, NewNpmPackage uses
+// NewNpmPackageWithEnvironment.
+func NewNpmPackage(base string, errors func(Error) bool) (GitIgnore, error) {
+	return NewNpmPackageWithEnvironment(base, nil, errors)
+} // NewNpmPackage()
+
+// NewNpmPackageWithEnvironment returns a GitIgnore for the npm package with
+// root directory base, as with NewNpmPackage, where the GIT_DIR of the
+// repository containing the package is located using environment rather
+// than the environment variables of the current process. If environment is
+// nil, the environment variables of the current process are used (see
+// NewEnvironment).
+func NewNpmPackageWithEnvironment(base string, environment *Environment, errors func(Error) bool) (GitIgnore, error) {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	// create the repository matching the ignore files of the package
+	//		- record the first error encountered creating the repository
+	var _error Error
+	_options := Options{
+		File:        NpmIgnoreFile,
+		Cache:       NewCache(),
+		NoGlobal:    true,
+		Nested:      NestedNone,
+		Environment: environment,
+		Errors: func(e Error) bool {
+			if _error == nil {
+				_error = e
+			}
+			return _errors(e)
+		},
+	}
+	_repository, _ := NewRepositoryWithOptions(base, _options).(*repository)
+	if _repository == nil {
+		if _error != nil {
+			return nil, _error.Underlying()
+		}
+		return nil, InvalidDirectoryError
+	}
+	_repository._errors = _errors
+
+	// read the package manifest
+	_package := filepath.Join(_repository.Base(), NpmPackage)
+	_npm := &npm{
+		ignore:      ignore{_base: _repository.Base(), _errors: _errors},
+		_repository: _repository,
+		_package:    _package,
+		_always:     make(map[string]bool),
+	}
+	_err := _npm.manifest()
+	if _err != nil {
+		return nil, _err
+	}
+
+	// the ignore file of the root directory is not used if the package
+	// lists its files
+	//		- repositories created by NewRepositoryWithOptions always read
+	//		  their ignore files from the file system
+	_files := _repository._loader.(*files)
+	_repository._loader = &npmfiles{files: *_files, _root: _npm._files == nil}
+
+	// parse the paths that are always excluded
+	_npm._excludes = _npm.parse(_NPMEXCLUDES)
+
+	return _npm, nil
+} // NewNpmPackageWithEnvironment()
+
+// NpmPackFiles returns the files of the npm package with root directory base
+// that would be published by "npm pack", as sorted paths relative to base
+// using "/" separators. Only regular files are listed. An error is returned
+// if the package cannot be read (see NewNpmPackage).
+func NpmPackFiles(base string) ([]string, error) {
+	_ignore, _err := NewNpmPackage(base, nil)
+	if _err != nil {
+		return nil, _err
+	}
+	_base := _ignore.Base()
+
+	// walk the package, skipping ignored directories
+	_paths := make([]string, 0)
+	_err = filepath.Walk(_base, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		_rel, _err := filepath.Rel(_base, path)
+		if _err != nil {
+			return _err
+		} else if _rel == "." {
+			return nil
+		}
+
+		_match := _ignore.Relative(_rel, info.IsDir())
+		switch {
+		case _match != nil && _match.Ignore():
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+		case info.Mode().IsRegular():
+			_paths = append(_paths, filepath.ToSlash(_rel))
+		}
+		return nil
+	})
+	if _err != nil {
+		return nil, _err
+	}
+	sort.Strings(_paths)
+
+	return _paths, nil
+} // NpmPackFiles()
+
+// manifest reads the "files" list, and the "main" and "bin" files, of the
+// package manifest.
+func (n *npm) manifest() error {
+	_data, _err := ioutil.ReadFile(n._package)
+	if _err != nil {
+		return _err
+	}
+
+	var _manifest struct {
+		Files []string        `json:"files"`
+		Main  string          `json:"main"`
+		Bin   json.RawMessage `json:"bin"`
+	}
+	_err = json.Unmarshal(_data, &_manifest)
+	if _err != nil {
+		return InvalidManifestError
+	}
+
+	// "bin" is either a single file or a map of command names to files
+	_bin := make(map[string]string)
+	if len(_manifest.Bin) != 0 && string(_manifest.Bin) != "null" {
+		var _file string
+		if json.Unmarshal(_manifest.Bin, &_file) == nil {
+			_bin[""] = _file
+		} else if json.Unmarshal(_manifest.Bin, &_bin) != nil {
+			return InvalidManifestError
+		}
+	}
+	n._always[npmpath(_manifest.Main)] = true
+	for _, _file := range _bin {
+		n._always[npmpath(_file)] = true
+	}
+	delete(n._always, ".")
+
+	// compile the "files" list
+	//		- an empty list includes only the files that are always included
+	if _manifest.Files == nil {
+		return nil
+	}
+	n._files = make([]*npmentry, 0, len(_manifest.Files))
+	for _, _file := range _manifest.Files {
+		_entry := n.entry(_file)
+		if _entry != nil {
+			n._files = append(n._files, _entry)
+		}
+	}
+
+	return nil
+} // manifest()
+
+// entry returns the npmentry for the entry file of the "files" list of the
+// package manifest, or nil if the entry is empty. An entry matches the paths
+// it names, relative to the root of the package, and their contents.
+func (n *npm) entry(file string) *npmentry {
+	_negated := strings.HasPrefix(file, string(_NEGATION))
+	_path := npmpath(strings.TrimPrefix(file, string(_NEGATION)))
+	if _path == "." {
+		return nil
+	}
+
+	// match the entry, and the contents of the entry, as anchored patterns
+	_patterns := string(_SEPARATOR) + _path + "\n" +
+		string(_SEPARATOR) + _path + string(_SEPARATOR) + "**\n"
+	_entry := &npmentry{
+		npmmatch: npmmatch{
+			_string:   file,
+			_ignore:   _negated,
+			_position: Position{File: n._package},
+		},
+		_pattern: n.parse(_patterns),
+	}
+
+	// match the directories leading to the entry
+	//		- "**" may match any number of directories, and is nil
+	_components := strings.Split(_path, string(_SEPARATOR))
+	for _, _component := range _components[:len(_components)-1] {
+		var _directory *ignore
+		if _component != "**" {
+			_directory = n.parse(string(_SEPARATOR) + _component)
+		}
+		_entry._directories = append(_entry._directories, _directory)
+	}
+
+	return _entry
+} // entry()
+
+// parse returns the GitIgnore for the .gitignore patterns, relative to the
+// root of the package.
+func (n *npm) parse(patterns string) *ignore {
+	return parse(strings.NewReader(patterns), n._base, "", settings{}, n._errors)
+} // parse()
+
+// npmpath returns the path of a file named by the package manifest, relative
+// to the root of the package, using "/" separators.
+func npmpath(file string) string {
+	_path := filepath.ToSlash(filepath.Clean(filepath.FromSlash(file)))
+	_path = strings.TrimPrefix(_path, string(_SEPARATOR))
+	if _path == "" {
+		return "."
+	}
+
+	return _path
+} // npmpath()
+
+// always returns the Match for the path if it is always included in the
+// package, or nil otherwise.
+func (n *npm) always(path string) Match {
+	if path == NpmPackage {
+		return &npmmatch{_string: NpmPackage, _position: Position{File: n._package}}
+	} else if n._always[path] {
+		return &npmmatch{_string: path, _position: Position{File: n._package}}
+	} else if strings.Contains(path, string(_SEPARATOR)) {
+		return nil
+	}
+
+	_upper := strings.ToUpper(path)
+	for _, _prefix := range _NPMINCLUDES {
+		if strings.HasPrefix(_upper, _prefix) {
+			return &npmmatch{_string: _prefix + string(_WILDCARD)}
+		}
+	}
+
+	return nil
+} // always()
+
+// excluded returns the Match for the path if it, or any of its parent
+// directories, is always excluded from the package, or nil otherwise.
+func (n *npm) excluded(path string, isdir bool) Match {
+	_components := strings.Split(path, string(_SEPARATOR))
+	for _i := range _components {
+		_path := strings.Join(_components[:_i+1], string(_SEPARATOR))
+		_isdir := isdir || _i < len(_components)-1
+		_match := n._excludes.Relative(_path, _isdir)
+		if _match != nil {
+			return _match
+		}
+	}
+
+	return nil
+} // excluded()
+
+// listed returns the Match of the last entry of the "files" list of the
+// package manifest that matches path. If no entry matches path, listed
+// returns nil, and partial is true if path is a directory that may contain
+// paths matched by an entry, or files that are always included.
+func (n *npm) listed(path string, isdir bool) (match Match, partial bool) {
+	for _i := len(n._files) - 1; _i >= 0; _i-- {
+		_entry := n._files[_i]
+		if _entry._pattern.Relative(path, isdir) != nil {
+			return _entry, false
+		}
+	}
+
+	// does an entry, or a file that is always included, name a path within
+	// this directory?
+	if isdir {
+		for _file := range n._always {
+			if strings.HasPrefix(_file, path+string(_SEPARATOR)) {
+				return nil, true
+			}
+		}
+		for _, _entry := range n._files {
+			if !_entry._ignore && _entry.within(path) {
+				return nil, true
+			}
+		}
+	}
+
+	return nil, false
+} // listed()
+
+// within returns true if the entry may match paths within the directory dir.
+func (e *npmentry) within(dir string) bool {
+	_components := strings.Split(dir, string(_SEPARATOR))
+	for _i, _component := range _components {
+		if _i >= len(e._directories) {
+			return false
+		} else if e._directories[_i] == nil {
+			return true
+		} else if e._directories[_i].Relative(_component, true) == nil {
+			return false
+		}
+	}
+
+	return true
+} // within()
+
+// Match attempts to match the path against the rules of the npm package.
+func (n *npm) Match(path string) Match {
+	// ensure we have the absolute path for the given file
+	_path, _err := filepath.Abs(path)
+	if _err != nil {
+		n._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	// is the path a file or a directory?
+	_info, _err := os.Stat(_path)
+	if _err != nil {
+		n._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	return n.Absolute(_path, _info.IsDir())
+} // Match()
+
+// Absolute attempts to match an absolute path against the rules of the npm
+// package. If the path is not located under the root of the package, or is
+// not matched, nil is returned.
+func (n *npm) Absolute(path string, isdir bool) Match {
+	_rel, _err := filepath.Rel(n._base, path)
+	if _err != nil || strings.HasPrefix(_rel, "..") {
+		return nil
+	}
+
+	return n.Relative(_rel, isdir)
+} // Absolute()
+
+// Relative attempts to match a path, relative to the root of the package,
+// against the rules of the npm package. The returned Match is the rule that
+// determined whether the path is published by "npm pack": a file that is
+// always included, a path that is always excluded, an entry of the "files"
+// list of package.json, or a pattern of an ignore file of the package. A
+// path that is not listed by the "files" list is ignored by a Match of
+// "files". If no rule applies to the path, nil is returned.
+func (n *npm) Relative(path string, isdir bool) Match {
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return nil
+	}
+
+	// some files are always included, and some paths are always excluded
+	if !isdir {
+		_match := n.always(_path)
+		if _match != nil {
+			return _match
+		}
+	}
+	_match := n.excluded(_path, isdir)
+	if _match != nil {
+		return _match
+	}
+
+	// is this path listed by the package manifest?
+	//		- directories leading to listed paths are not ignored
+	var _listed Match
+	if n._files != nil {
+		_partial := false
+		_listed, _partial = n.listed(_path, isdir)
+		if _listed == nil && !_partial {
+			return &npmmatch{_string: "files", _ignore: true, _position: Position{File: n._package}}
+		} else if _listed != nil && _listed.Ignore() {
+			return _listed
+		}
+	}
+
+	// finally, consult the ignore files of the package
+	_match = n._repository.Relative(path, isdir)
+	if _match != nil {
+		return _match
+	} else if _listed != nil {
+		return _listed
+	}
+
+	return nil
+} // Relative()
+
+// MatchAll attempts to match a path, relative to the root of the package,
+// against the rules of the npm package, returning every pattern of the
+// ignore files of the package matching the path, or its parent directories,
+// as with the MatchAll of a repository. If the path is determined by a rule
+// of the package other than its ignore files (see Relative), the rule is
+// reported as the decisive Trace, following the patterns.
+func (n *npm) MatchAll(path string, isdir bool) []Trace {
+	_traces := n._repository.MatchAll(path, isdir)
+
+	// did the ignore files of the package determine the match?
+	_match := n.Relative(path, isdir)
+	_decisive := decisive(_traces)
+	if _decisive != -1 && _traces[_decisive].Match == _match {
+		return _traces
+	}
+
+	// otherwise, the rule of the package is decisive
+	_traces = decide(_traces, -1)
+	if _match != nil {
+		_path := filepath.ToSlash(filepath.Clean(path))
+		_traces = append(_traces, Trace{Match: _match, Path: _path, Decisive: true})
+	}
+
+	return _traces
+} // MatchAll()
+
+// Ignore returns true if the path would not be published by "npm pack".
+func (n *npm) Ignore(path string) bool {
+	_match := n.Match(path)
+	if _match != nil {
+		return _match.Ignore()
+	}
+
+	// we didn't match this path, so we don't ignore it
+	return false
+} // Ignore()
+
+// Include returns true if the path would be published by "npm pack".
+func (n *npm) Include(path string) bool {
+	_match := n.Match(path)
+	if _match != nil {
+		return _match.Include()
+	}
+
+	// we didn't match this path, so we include it
+	return true
+} // Include()
+
+// load returns the GitIgnore for the .npmignore file of the directory dir,
+// or for its .gitignore file if dir has no .npmignore file. If the ignore
+// file of the root directory is not used, load returns nil for dir ".".
+func (f *npmfiles) load(dir string) GitIgnore {
+	if !f._root && filepath.Clean(dir) == "." {
+		return nil
+	}
+
+	for _, _name := range []string{NpmIgnoreFile, File} {
+		_file := filepath.Join(f._base, dir, _name)
+		_, _err := os.Stat(_file)
+		if _err == nil {
+			_settings := f._settings
+			_settings._dialect = DialectFor(_name)
+			return newWithCache(_file, f._cache, _settings, f._errors)
+		}
+	}
+
+	return nil
+} // load()
+
+// Ignore returns true if the rule excludes the paths it matches.
+func (m *npmmatch) Ignore() bool { return m._ignore }
+
+// Include returns true if the rule includes the paths it matches.
+func (m *npmmatch) Include() bool { return !m._ignore }
+
+// String returns the rule, or the entry of the package manifest.
+func (m *npmmatch) String() string { return m._string }
+
+// Position returns the position of the rule, which names the package
+// manifest for the rules of the manifest, and is otherwise zero.
+func (m *npmmatch) Position() Position { return m._position }
+
+// ensure npm satisfies the GitIgnore interface, npmfiles satisfies the
+// loader interface, and npmmatch satisfies the Match interface
+var _ GitIgnore = &npm{}
+var _ loader = &npmfiles{}
+var _ Match = &npmmatch{}
@@ -0,0 +1,81 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestNpmPackage(t *testing.T) {
+	// create the package for the npm tests
+	_dir, _err := dir(_NPMPACKAGE)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	_ignore, _err := gitignore.NewNpmPackage(_dir, nil)
+	if _err != nil {
+		t.Fatalf("unable to read package: %s", _err.Error())
+	}
+
+	// ensure the paths are matched as npm would
+	for _, _match := range _NPMMATCHES {
+		do(t, _ignore.Relative, _match)
+	}
+
+	// the entries of package.json report the manifest as their position
+	_match := _ignore.Relative("dist/a.js", false)
+	if _match != nil {
+		_file := filepath.Join(_dir, gitignore.NpmPackage)
+		if _match.Position().File != _file {
+			t.Errorf("position mismatch; expected %q, got %q",
+				_file, _match.Position().File,
+			)
+		}
+	}
+} // TestNpmPackage()
+
+func TestNpmPackFiles(t *testing.T) {
+	for _, _test := range []struct {
+		Package map[string]string
+		Packed  []string
+	}{
+		{_NPMPACKAGE, _NPMPACKED},
+		{_NPMPACKAGEIGNORE, _NPMPACKEDIGNORE},
+	} {
+		// create the package
+		_dir, _err := dir(_test.Package)
+		if _err != nil {
+			t.Fatalf("unable to create temporary directory: %s", _err.Error())
+		}
+		defer os.RemoveAll(_dir)
+
+		// ensure the packed files are listed
+		_files, _err := gitignore.NpmPackFiles(_dir)
+		if _err != nil {
+			t.Fatalf("unable to list package files: %s", _err.Error())
+		} else if !reflect.DeepEqual(_files, _test.Packed) {
+			t.Errorf("packed files mismatch; expected %v, got %v",
+				_test.Packed, _files,
+			)
+		}
+	}
+
+	// ensure an invalid package manifest is reported
+	_dir, _err := dir(map[string]string{gitignore.NpmPackage: "{"})
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	_, _err = gitignore.NpmPackFiles(_dir)
+	if _err != gitignore.InvalidManifestError {
+		t.Errorf("error mismatch; expected %q, got %v",
+			gitignore.InvalidManifestError, _err,
+		)
+	}
+} // TestNpmPackFiles()
@@ -0,0 +1,244 @@
+package gitignore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// define the git object types
+const (
+	_COMMIT = "commit"
+	_TREE   = "tree"
+	_BLOB   = "blob"
+	_TAG    = "tag"
+)
+
+// define the git tree and index entry modes
+const (
+	_MODEMASK    = 0170000
+	_MODETREE    = 0040000
+	_MODEFILE    = 0100000
+	_MODEGITLINK = 0160000
+)
+
+// define the maximum depth of nested object alternates, and of nested
+// annotated tags
+const (
+	_ALTERNATEDEPTH = 5
+	_TAGDEPTH       = 10
+)
+
+// objects represents the object database of a git repository, reading loose
+// objects and objects stored in packfiles
+type objects struct {
+	_dir        string
+	_hashsize   int
+	_packs      []*pack
+	_alternates []*objects
+} // objects{}
+
+// newObjects returns the object database of the repository with the given
+// common git directory, with object IDs of hashsize bytes. The objects are
+// located in the objects/ directory of the common directory. Alternate object
+// databases listed in objects/info/alternates are also consulted.
+func newObjects(common string, hashsize int) (*objects, error) {
+	return openObjects(filepath.Join(common, "objects"), hashsize, 0)
+} // newObjects()
+
+// openObjects returns the object database located in the objects directory
+// dir. Alternate object databases are followed to a maximum nesting depth of
+// _ALTERNATEDEPTH.
+func openObjects(dir string, hashsize int, depth int) (*objects, error) {
+	_objects := &objects{_dir: dir, _hashsize: hashsize}
+
+	// open the packfile indexes
+	_indexes, _err := filepath.Glob(filepath.Join(dir, "pack", "pack-*.idx"))
+	if _err != nil {
+		return nil, _err
+	}
+	for _, _index := range _indexes {
+		_pack, _err := newPack(_index, hashsize)
+		if _err != nil {
+			return nil, _err
+		}
+		_objects._packs = append(_objects._packs, _pack)
+	}
+
+	// are there alternate object databases?
+	if depth >= _ALTERNATEDEPTH {
+		return _objects, nil
+	}
+	_content, _err := ioutil.ReadFile(filepath.Join(dir, "info", "alternates"))
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return _objects, nil
+		}
+		return nil, _err
+	}
+	_scanner := bufio.NewScanner(bytes.NewReader(_content))
+	for _scanner.Scan() {
+		_line := strings.TrimSpace(_scanner.Text())
+		if _line == "" || strings.HasPrefix(_line, "#") {
+			continue
+		}
+
+		// relative alternates are resolved against the objects directory
+		if !filepath.IsAbs(_line) {
+			_line = filepath.Join(dir, _line)
+		}
+		_alternate, _err := openObjects(_line, hashsize, depth+1)
+		if _err != nil {
+			return nil, _err
+		}
+		_objects._alternates = append(_objects._alternates, _alternate)
+	}
+
+	return _objects, nil
+} // openObjects()
+
+// read returns the type and content of the object with the given ID. If the
+// object cannot be found, read returns MissingObjectError.
+func (o *objects) read(id []byte) (string, []byte, error) {
+	if len(id) != o._hashsize {
+		return "", nil, InvalidObjectError
+	}
+
+	// is this a loose object?
+	_hex := hex.EncodeToString(id)
+	_file := filepath.Join(o._dir, _hex[:2], _hex[2:])
+	_kind, _data, _err := o.loose(_file)
+	if _err == nil {
+		return _kind, _data, nil
+	} else if !os.IsNotExist(_err) {
+		return "", nil, _err
+	}
+
+	// is the object in a packfile?
+	for _, _pack := range o._packs {
+		_offset, _ok := _pack.find(id)
+		if _ok {
+			return _pack.read(o, _offset, 0)
+		}
+	}
+
+	// is the object in an alternate object database?
+	for _, _alternate := range o._alternates {
+		_kind, _data, _err := _alternate.read(id)
+		if _err != MissingObjectError {
+			return _kind, _data, _err
+		}
+	}
+
+	return "", nil, MissingObjectError
+} // read()
+
+// loose returns the type and content of the loose object stored in file.
+func (o *objects) loose(file string) (string, []byte, error) {
+	_fh, _err := os.Open(file)
+	if _err != nil {
+		return "", nil, _err
+	}
+	defer _fh.Close()
+
+	// loose objects are compressed with zlib
+	_reader, _err := zlib.NewReader(_fh)
+	if _err != nil {
+		return "", nil, InvalidObjectError
+	}
+	defer _reader.Close()
+	_content, _err := ioutil.ReadAll(_reader)
+	if _err != nil {
+		return "", nil, InvalidObjectError
+	}
+
+	// extract the object header (i.e. "<type> <size>\x00")
+	_nul := bytes.IndexByte(_content, 0)
+	if _nul == -1 {
+		return "", nil, InvalidObjectError
+	}
+	_header := strings.SplitN(string(_content[:_nul]), " ", 2)
+	if len(_header) != 2 {
+		return "", nil, InvalidObjectError
+	}
+	_size, _err := strconv.Atoi(_header[1])
+	if _err != nil || _size != len(_content)-_nul-1 {
+		return "", nil, InvalidObjectError
+	}
+
+	return _header[0], _content[_nul+1:], nil
+} // loose()
+
+// object returns the content of the object with the given ID, ensuring the
+// object is of the expected type.
+func (o *objects) object(id []byte, kind string) ([]byte, error) {
+	_kind, _data, _err := o.read(id)
+	if _err != nil {
+		return nil, _err
+	} else if _kind != kind {
+		return nil, InvalidObjectError
+	}
+
+	return _data, nil
+} // object()
+
+// tree returns the ID of the tree identified by id, which may be the ID of
+// a tree, a commit, or an annotated tag referring to either.
+func (o *objects) tree(id []byte) ([]byte, error) {
+	_id := id
+	for _depth := 0; _depth < _TAGDEPTH; _depth++ {
+		_kind, _data, _err := o.read(_id)
+		if _err != nil {
+			return nil, _err
+		}
+
+		// follow commits to their tree, and tags to their object
+		switch _kind {
+		case _TREE:
+			return _id, nil
+		case _COMMIT:
+			return o.header(_data, _TREE)
+		case _TAG:
+			_id, _err = o.header(_data, "object")
+			if _err != nil {
+				return nil, _err
+			}
+		default:
+			return nil, InvalidObjectError
+		}
+	}
+
+	return nil, InvalidObjectError
+} // tree()
+
+// header returns the object ID given by the named header of the commit or
+// tag object content data (e.g. "tree <id>").
+func (o *objects) header(data []byte, name string) ([]byte, error) {
+	_prefix := name + " "
+	for _, _line := range strings.Split(string(data), "\n") {
+		// the headers end at the first blank line
+		if _line == "" {
+			break
+		} else if strings.HasPrefix(_line, _prefix) {
+			return o.id(strings.TrimPrefix(_line, _prefix))
+		}
+	}
+
+	return nil, InvalidObjectError
+} // header()
+
+// id returns the object ID represented by the hexadecimal string s.
+func (o *objects) id(s string) ([]byte, error) {
+	_id, _err := hex.DecodeString(strings.TrimSpace(s))
+	if _err != nil || len(_id) != o._hashsize {
+		return nil, InvalidObjectError
+	}
+
+	return _id, nil
+} // id()
@@ -0,0 +1,211 @@
+package gitignore
+
+// Options defines the configuration of a repository GitIgnore instance
+// created by NewRepositoryWithOptions.
+type Options struct {
+	// File is the name of the files within the repository from which to
+	// load the .gitignore patterns. If File is the empty string, ".gitignore"
+	// is used.
+	File string
+
+	// Files lists the names of the files within the repository from which
+	// to load the ignore patterns, in increasing order of precedence (e.g.
+	// ".gitignore", ".ignore" and a tool-specific ignore file). Within each
+	// directory, patterns from a later file take precedence over patterns
+	// from an earlier file. If Files is empty, File is used.
+	Files []string
+
+	// Cache is used to store the GitIgnore instances of the ignore files
+	// within the repository, so that each file is loaded only once. If Cache
+	// is nil, ignore files are loaded each time they are required.
+	Cache Cache
+
+	// Errors, if defined, will be invoked for each error encountered while
+	// creating the repository, or while matching a path against the
+	// repository GitIgnore.
+	Errors func(Error) bool
+
+	// NoGlobal disables the global excludes file (i.e. core.excludesFile,
+	// or $XDG_CONFIG_HOME/git/ignore), for instance to ensure repository
+	// matching does not depend on the configuration of the current user.
+	NoGlobal bool
+
+	// Nested defines how paths located within nested repositories and
+	// submodules are matched. By default, nested repositories are not
+	// detected, and such paths are matched against the ignore files of this
+	// repository (see NestedNone). Set Nested to NestedRepository to match
+	// them as git does.
+	Nested Nested
+
+	// Tracked causes paths tracked by the repository index to be reported
+	// with a Tracked Match, since git ignore rules apply only to untracked
+	// files. The index is read when the repository is created.
+	Tracked bool
+
+	// Includes causes comments of the form "#!include:<path>" within the
+	// ignore files of the work tree to be replaced by the patterns of the
+	// file at path, relative to the directory of the ignore file (see
+	// NewWithIncludes). Since git does not support includes, Includes is
+	// intended for tool-specific ignore files.
+	Includes bool
+
+	// Dialect defines the syntax and semantics of the ignore files within
+	// the repository (see Dialect). If Dialect is nil, each ignore file is
+	// read using the Dialect registered for its name (see RegisterDialect),
+	// which defaults to GitDialect.
+	Dialect Dialect
+
+	// Case defines whether paths are matched against the patterns of the
+	// repository case-insensitively. By default, paths are matched
+	// case-insensitively if core.ignorecase is true in the configuration
+	// of the repository (see CaseConfig).
+	Case Case
+
+	// Normalization defines the Unicode normalization form of the patterns
+	// of the repository, and of the paths matched against them. By default,
+	// paths and patterns are normalized to NFC if core.precomposeUnicode is
+	// true in the configuration of the repository (see NormalizeConfig).
+	Normalization Normalization
+
+	// Environment defines the git environment of the repository (such as
+	// the location of its git directory). If Environment is nil, the git
+	// environment is taken from the environment variables of the current
+	// process (see NewEnvironment).
+	Environment *Environment
+} // Options{}
+
+// settings defines how the ignore files of a repository are parsed, as
+// derived from its Options: whether "#!include:" directives are followed,
+// whether the patterns are case-insensitive, the Unicode normalization form
+// of the patterns, and the Dialect of the ignore file. The zero settings
+// parse a .gitignore file as git does.
+type settings struct {
+	_includes bool
+	_casefold bool
+	_form     Normalization
+	_dialect  Dialect
+} // settings{}
+
+// environment returns the Environment of the options, defaulting to the
+// environment of the current process.
+func (o Options) environment() *Environment {
+	if o.Environment == nil {
+		return NewEnvironment()
+	}
+
+	return o.Environment
+} // environment()
+
+// settings returns the settings of the ignore files of the repository with
+// the given common git directory. The Dialect of each ignore file is given
+// by dialects, so the Dialect of the settings is not set.
+func (o Options) settings(common string) (settings, error) {
+	_casefold, _err := o.casefold(common)
+	if _err != nil {
+		return settings{}, _err
+	}
+	_form, _err := o.normalization(common)
+	if _err != nil {
+		return settings{}, _err
+	}
+
+	return settings{
+		_includes: o.Includes,
+		_casefold: _casefold,
+		_form:     _form,
+	}, nil
+} // settings()
+
+// casefold returns true if the patterns of the repository with the given
+// common git directory are case-insensitive, consulting core.ignorecase if
+// the options do not define the Case of the repository.
+func (o Options) casefold(common string) (bool, error) {
+	switch o.Case {
+	case CaseSensitive:
+		return false, nil
+	case CaseInsensitive:
+		return true, nil
+	}
+
+	// consult the repository configuration
+	//		- repositories without a git directory are case-sensitive
+	if common == "" {
+		return false, nil
+	}
+	_config, _err := newConfig(common)
+	if _err != nil {
+		return false, _err
+	}
+	_ignorecase, _ := _config.bool("core.ignorecase")
+
+	return _ignorecase, nil
+} // casefold()
+
+// normalization returns the Unicode normalization form of the patterns of the
+// repository with the given common git directory, consulting
+// core.precomposeUnicode if the options do not define the Normalization of
+// the repository.
+func (o Options) normalization(common string) (Normalization, error) {
+	if o.Normalization != NormalizeConfig {
+		return o.Normalization, nil
+	}
+
+	// consult the repository configuration
+	//		- repositories without a git directory are not normalized
+	if common == "" {
+		return NormalizeNone, nil
+	}
+	_config, _err := newConfig(common)
+	if _err != nil {
+		return NormalizeNone, _err
+	}
+	if _precompose, _ := _config.bool("core.precomposeunicode"); _precompose {
+		return NormalizeNFC, nil
+	}
+
+	return NormalizeNone, nil
+} // normalization()
+
+// files returns the names of the ignore files of the options, in increasing
+// order of precedence, defaulting to ".gitignore".
+func (o Options) files() []string {
+	_files := o.Files
+	if len(_files) == 0 {
+		_files = []string{o.File}
+	}
+
+	// empty file names are replaced by the default
+	_names := make([]string, len(_files))
+	for _i, _file := range _files {
+		if _file == "" {
+			_file = File
+		}
+		_names[_i] = _file
+	}
+
+	return _names
+} // files()
+
+// dialects returns the Dialect of each of the named ignore files, defaulting
+// to the Dialect registered for each name.
+func (o Options) dialects(files []string) []Dialect {
+	_dialects := make([]Dialect, len(files))
+	for _i, _file := range files {
+		if o.Dialect != nil {
+			_dialects[_i] = o.Dialect
+		} else {
+			_dialects[_i] = DialectFor(_file)
+		}
+	}
+
+	return _dialects
+} // dialects()
+
+// dialect returns the Dialect of the settings, defaulting to GitDialect.
+func (s settings) dialect() Dialect {
+	if s._dialect == nil {
+		return GitDialect
+	}
+
+	return s._dialect
+} // dialect()
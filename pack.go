@@ -0,0 +1,334 @@
+package gitignore
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// define the packfile index signature, and the packfile object types
+const (
+	_PACKSIGNATURE = "\xfftOc"
+	_PACKVERSION   = 2
+	_PACKLARGE     = 0x80000000
+	_PACKCOMMIT    = 1
+	_PACKTREE      = 2
+	_PACKBLOB      = 3
+	_PACKTAG       = 4
+	_PACKOFSDELTA  = 6
+	_PACKREFDELTA  = 7
+)
+
+// define the maximum length of a delta chain
+const _DELTADEPTH = 4096
+
+// pack represents a git packfile, together with its version 2 index
+type pack struct {
+	_file     string
+	_hashsize int
+	_fanout   []uint32
+	_names    []byte
+	_offsets  []byte
+	_large    []byte
+} // pack{}
+
+// newPack returns the pack for the packfile index idx, with object IDs of
+// hashsize bytes. The packfile is located alongside its index. Only version
+// 2 packfile indexes are supported.
+func newPack(idx string, hashsize int) (*pack, error) {
+	_data, _err := ioutil.ReadFile(idx)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// ensure we have a version 2 index
+	//		- the header is followed by the 256 entry fan-out table
+	_header := 8 + 256*4
+	if len(_data) < _header ||
+		string(_data[:4]) != _PACKSIGNATURE ||
+		binary.BigEndian.Uint32(_data[4:8]) != _PACKVERSION {
+		return nil, InvalidPackError
+	}
+	_fanout := make([]uint32, 256)
+	for _i := range _fanout {
+		_fanout[_i] = binary.BigEndian.Uint32(_data[8+4*_i:])
+	}
+	_count := int(_fanout[255])
+
+	// the fan-out table is followed by the sorted object names, their CRC32
+	// checksums, and their 4-byte offsets in the packfile
+	_names := _header
+	_offsets := _names + _count*hashsize + _count*4
+	_large := _offsets + _count*4
+	if len(_data) < _large+2*hashsize {
+		return nil, InvalidPackError
+	}
+
+	_pack := &pack{
+		_file:     strings.TrimSuffix(idx, ".idx") + ".pack",
+		_hashsize: hashsize,
+		_fanout:   _fanout,
+		_names:    _data[_names : _names+_count*hashsize],
+		_offsets:  _data[_offsets:_large],
+		_large:    _data[_large : len(_data)-2*hashsize],
+	}
+	return _pack, nil
+} // newPack()
+
+// find returns the offset in the packfile of the object with the given ID,
+// and true if the object is located in this pack.
+func (p *pack) find(id []byte) (int64, bool) {
+	// the fan-out table gives the range of objects whose IDs begin with
+	// the first byte of id
+	_first := 0
+	if id[0] > 0 {
+		_first = int(p._fanout[id[0]-1])
+	}
+	_last := int(p._fanout[id[0]])
+
+	// find the object within the range
+	_n := _first + sort.Search(_last-_first, func(i int) bool {
+		return bytes.Compare(p.name(_first+i), id) >= 0
+	})
+	if _n >= _last || !bytes.Equal(p.name(_n), id) {
+		return 0, false
+	}
+
+	// large offsets are stored in a separate table
+	_offset := binary.BigEndian.Uint32(p._offsets[4*_n:])
+	if _offset&_PACKLARGE == 0 {
+		return int64(_offset), true
+	}
+	_index := int(_offset &^ _PACKLARGE)
+	if len(p._large) < 8*(_index+1) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p._large[8*_index:])), true
+} // find()
+
+// name returns the ID of the nth object in the pack index.
+func (p *pack) name(n int) []byte {
+	return p._names[n*p._hashsize : (n+1)*p._hashsize]
+} // name()
+
+// read returns the type and content of the object at offset in the packfile.
+// Deltified objects are resolved against their base object, using o to
+// locate bases outside of this pack, to a maximum chain length of
+// _DELTADEPTH.
+func (p *pack) read(o *objects, offset int64, depth int) (string, []byte, error) {
+	if depth > _DELTADEPTH {
+		return "", nil, InvalidPackError
+	}
+
+	_fh, _err := os.Open(p._file)
+	if _err != nil {
+		return "", nil, _err
+	}
+	defer _fh.Close()
+	_info, _err := _fh.Stat()
+	if _err != nil {
+		return "", nil, _err
+	}
+
+	// extract the object header
+	//		- the header is at most a 10 byte size, followed by a delta
+	//		  base offset or object ID
+	_header := make([]byte, 16+p._hashsize)
+	_n, _err := _fh.ReadAt(_header, offset)
+	if _err != nil && _err != io.EOF {
+		return "", nil, _err
+	}
+	_header = _header[:_n]
+	_type, _size, _used := entry(_header)
+	if _used == 0 {
+		return "", nil, InvalidPackError
+	}
+
+	// do we have a deltified object?
+	var _base func() (string, []byte, error)
+	switch _type {
+	case _PACKOFSDELTA:
+		// the base is located at a negative offset from this object
+		_distance, _n := varint(_header[_used:])
+		if _n == 0 || int64(_distance) > offset || _distance == 0 {
+			return "", nil, InvalidPackError
+		}
+		_used += _n
+		_base = func() (string, []byte, error) {
+			return p.read(o, offset-int64(_distance), depth+1)
+		}
+
+	case _PACKREFDELTA:
+		// the base is identified by its object ID
+		if len(_header) < _used+p._hashsize {
+			return "", nil, InvalidPackError
+		}
+		_id := append([]byte{}, _header[_used:_used+p._hashsize]...)
+		_used += p._hashsize
+		_base = func() (string, []byte, error) {
+			_offset, _ok := p.find(_id)
+			if _ok {
+				return p.read(o, _offset, depth+1)
+			}
+			return o.read(_id)
+		}
+	}
+
+	// extract the compressed object content
+	_start := offset + int64(_used)
+	_section := io.NewSectionReader(_fh, _start, _info.Size()-_start)
+	_reader, _err := zlib.NewReader(_section)
+	if _err != nil {
+		return "", nil, InvalidPackError
+	}
+	defer _reader.Close()
+	_data, _err := ioutil.ReadAll(io.LimitReader(_reader, int64(_size)+1))
+	if _err != nil || len(_data) != _size {
+		return "", nil, InvalidPackError
+	}
+
+	// what kind of object do we have?
+	switch _type {
+	case _PACKCOMMIT:
+		return _COMMIT, _data, nil
+	case _PACKTREE:
+		return _TREE, _data, nil
+	case _PACKBLOB:
+		return _BLOB, _data, nil
+	case _PACKTAG:
+		return _TAG, _data, nil
+	case _PACKOFSDELTA, _PACKREFDELTA:
+		_kind, _source, _err := _base()
+		if _err != nil {
+			return "", nil, _err
+		}
+		_target, _err := delta(_source, _data)
+		if _err != nil {
+			return "", nil, _err
+		}
+		return _kind, _target, nil
+	}
+
+	return "", nil, InvalidPackError
+} // read()
+
+// entry decodes the packfile object header at the start of data, returning
+// the object type, the size of the uncompressed object content, and the number
+// of bytes consumed. If data does not contain a complete header, entry
+// returns 0 bytes consumed.
+func entry(data []byte) (int, int, int) {
+	if len(data) == 0 {
+		return 0, 0, 0
+	}
+	_c := data[0]
+	_type := int(_c>>4) & 0x07
+	_size := int(_c & 0x0f)
+	_shift := uint(4)
+	_n := 1
+	for _c&0x80 != 0 {
+		if _n >= len(data) || _shift > 56 {
+			return 0, 0, 0
+		}
+		_c = data[_n]
+		_size |= int(_c&0x7f) << _shift
+		_shift += 7
+		_n++
+	}
+
+	return _type, _size, _n
+} // entry()
+
+// size decodes the little-endian variable-length size used by git deltas at
+// the start of data, returning the size and the number of bytes consumed. If
+// data does not contain a complete size, size returns 0 bytes consumed.
+func size(data []byte) (int, int) {
+	_size := 0
+	_shift := uint(0)
+	for _n, _c := range data {
+		if _shift > 56 {
+			break
+		}
+		_size |= int(_c&0x7f) << _shift
+		_shift += 7
+		if _c&0x80 == 0 {
+			return _size, _n + 1
+		}
+	}
+
+	return 0, 0
+} // size()
+
+// delta applies the git delta to the source object content, returning the
+// content of the target object.
+func delta(source, delta []byte) ([]byte, error) {
+	// extract the expected source and target sizes
+	_source, _n := size(delta)
+	if _n == 0 || _source != len(source) {
+		return nil, InvalidPackError
+	}
+	delta = delta[_n:]
+	_size, _n := size(delta)
+	if _n == 0 {
+		return nil, InvalidPackError
+	}
+	delta = delta[_n:]
+
+	// apply the sequence of copy and insert instructions
+	_target := make([]byte, 0, _size)
+	for len(delta) > 0 {
+		_c := delta[0]
+		delta = delta[1:]
+
+		switch {
+		// copy a range of the source
+		//		- the low 4 bits flag the bytes of the offset, and the
+		//		  next 3 bits flag the bytes of the length
+		case _c&0x80 != 0:
+			_offset, _length := 0, 0
+			for _i := uint(0); _i < 7; _i++ {
+				if _c&(1<<_i) == 0 {
+					continue
+				} else if len(delta) == 0 {
+					return nil, InvalidPackError
+				}
+				if _i < 4 {
+					_offset |= int(delta[0]) << (8 * _i)
+				} else {
+					_length |= int(delta[0]) << (8 * (_i - 4))
+				}
+				delta = delta[1:]
+			}
+			if _length == 0 {
+				_length = 0x10000
+			}
+			if _offset+_length > len(source) {
+				return nil, InvalidPackError
+			}
+			_target = append(_target, source[_offset:_offset+_length]...)
+
+		// insert literal bytes
+		case _c != 0:
+			if int(_c) > len(delta) {
+				return nil, InvalidPackError
+			}
+			_target = append(_target, delta[:_c]...)
+			delta = delta[_c:]
+
+		// the zero instruction is reserved
+		default:
+			return nil, InvalidPackError
+		}
+	}
+
+	// ensure we have the expected target
+	if len(_target) != _size {
+		return nil, InvalidPackError
+	}
+
+	return _target, nil
+} // delta()
@@ -2,8 +2,15 @@ package gitignore
 
 import (
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
+// define the comment directive that includes the patterns of another file
+// (e.g. "#!include:.gitignore")
+const _INCLUDE = "#!include:"
+
 // Parser is the interface for parsing .gitignore files and extracting the set
 // of patterns specified in the .gitignore file.
 type Parser interface {
@@ -26,9 +33,16 @@ type Parser interface {
 
 // parser is the implementation of the .gitignore parser
 type parser struct {
-	_lexer Lexer
-	_undo  []*Token
-	_error func(Error) bool
+	_lexer   Lexer
+	_undo    []*Token
+	_error   func(Error) bool
+	_include bool
+	_file    string
+	_chain   []string
+	_pending []Pattern
+	_stop    bool
+	_dialect Dialect
+	_form    Normalization
 } // parser{}
 
 // NewParser returns a new Parser instance for the given stream r.
@@ -39,6 +53,52 @@ func NewParser(r io.Reader, err func(Error) bool) Parser {
 	return &parser{_lexer: NewLexer(r), _error: err}
 } // NewParser()
 
+// NewParserWithDialect returns a new Parser instance for the given stream r,
+// reading the patterns of an ignore file of the given dialect. As with
+// NewParser, err will be called for every error encountered during parsing.
+func NewParserWithDialect(r io.Reader, dialect Dialect, err func(Error) bool) Parser {
+	return &parser{_lexer: dialect.Lexer(r), _error: err, _dialect: dialect}
+} // NewParserWithDialect()
+
+// NewParserWithIncludes returns a new Parser instance for the given stream r,
+// read from file, where comments of the form "#!include:<path>" are replaced
+// by the patterns of the file at path. Relative paths are resolved against
+// the directory of file (or the current directory if file is the empty
+// string), and files may include further files. The Position of each pattern
+// names the file from which it was read. Including a file that is already
+// being included is reported as an IncludeCycleError, and an include that
+// cannot be read is reported with the position of its directive. As with
+// NewParser, err will be called for every error encountered during parsing.
+func NewParserWithIncludes(r io.Reader, file string, err func(Error) bool) Parser {
+	return newParserWithIncludes(r, file, GitDialect, NormalizeNone, err)
+} // NewParserWithIncludes()
+
+// newParserWithIncludes returns the parser for the stream r, read from file
+// and following "#!include:" directives, for ignore files of the given dialect,
+// where the text of the stream and of included files is normalized to form.
+func newParserWithIncludes(r io.Reader, file string, dialect Dialect, form Normalization, err func(Error) bool) *parser {
+	_lexer := &offset{Lexer: dialect.Lexer(form.reader(r))}
+	_lexer._position = Position{File: file, Line: 1, Column: 1}
+	_parser := &parser{
+		_lexer:   _lexer,
+		_error:   err,
+		_include: true,
+		_dialect: dialect,
+		_form:    form,
+	}
+
+	// record the file to detect include cycles
+	if file != "" {
+		_file, _err := filepath.Abs(file)
+		if _err == nil {
+			_parser._file = _file
+			_parser._chain = []string{_file}
+		}
+	}
+
+	return _parser
+} // newParserWithIncludes()
+
 // Parse returns all well-formed .gitignore Patterns contained within the
 // parser stream. Parsing will terminate at the end of the stream, or if
 // the parser error handler returns false.
@@ -60,6 +120,15 @@ func (p *parser) Parse() []Pattern {
 // parse the next Pattern. If the error handler returns false, or the parser
 // reaches the end of the stream, Next returns nil.
 func (p *parser) Next() Pattern {
+	// do we have patterns from an included file?
+	if len(p._pending) > 0 {
+		_pattern := p._pending[0]
+		p._pending = p._pending[1:]
+		return _pattern
+	} else if p._stop {
+		return nil
+	}
+
 	// keep searching until we find the next pattern, or until we
 	// reach the end of the file
 	for {
@@ -91,6 +160,13 @@ func (p *parser) Next() Pattern {
 		case EOL:
 			continue
 		case COMMENT:
+			// should we include the patterns of another file?
+			if p._include && strings.HasPrefix(_token.Token(), _INCLUDE) {
+				p._pending = p.include(_token)
+				if len(p._pending) > 0 || p._stop {
+					return p.Next()
+				}
+			}
 			continue
 
 		// otherwise, attempt to build the next pattern
@@ -139,6 +215,80 @@ func (p *parser) Position() Position {
 // private methods
 //
 
+// include returns the patterns of the file named by the include directive
+// comment t. Errors reading the file are reported at the position of t.
+func (p *parser) include(t *Token) []Pattern {
+	_path := strings.TrimSpace(strings.TrimPrefix(t.Token(), _INCLUDE))
+	if _path == "" {
+		p._stop = !p.errors(NewError(InvalidPatternError, t.Position))
+		return nil
+	}
+
+	// resolve the path relative to the including file
+	_file := filepath.FromSlash(_path)
+	if !filepath.IsAbs(_file) && p._file != "" {
+		_file = filepath.Join(filepath.Dir(p._file), _file)
+	}
+	_file, _err := filepath.Abs(_file)
+	if _err != nil {
+		p._stop = !p.errors(NewError(_err, t.Position))
+		return nil
+	}
+
+	// is this file already being included?
+	for _, _included := range p._chain {
+		if _included == _file {
+			p._stop = !p.errors(NewError(IncludeCycleError, t.Position))
+			return nil
+		}
+	}
+
+	_fh, _err := os.Open(_file)
+	if _err != nil {
+		p._stop = !p.errors(NewError(_err, t.Position))
+		return nil
+	}
+	defer _fh.Close()
+
+	// parse the included file, which may include further files
+	//		- parsing of this file stops if the error handler stops the
+	//		  parsing of the included file
+	_stop := false
+	_errors := func(e Error) bool {
+		if p.errors(e) {
+			return true
+		}
+		_stop = true
+		return false
+	}
+	_lexer := &offset{Lexer: p.dialect().Lexer(p._form.reader(_fh))}
+	_lexer._position = Position{File: _file, Line: 1, Column: 1}
+	_chain := make([]string, len(p._chain), len(p._chain)+1)
+	copy(_chain, p._chain)
+	_parser := &parser{
+		_lexer:   _lexer,
+		_error:   _errors,
+		_include: true,
+		_file:    _file,
+		_chain:   append(_chain, _file),
+		_dialect: p._dialect,
+		_form:    p._form,
+	}
+	_patterns := _parser.Parse()
+	p._stop = _stop
+
+	return _patterns
+} // include()
+
+// dialect returns the Dialect of the parser, defaulting to GitDialect.
+func (p *parser) dialect() Dialect {
+	if p._dialect == nil {
+		return GitDialect
+	}
+
+	return p._dialect
+} // dialect()
+
 // build attempts to build a well-formed .gitignore Pattern starting from the
 // given Token t. An Error will be returned if the sequence of tokens returned
 // by the Lexer does not represent a valid Pattern.
@@ -146,8 +296,14 @@ func (p *parser) build(t *Token) (Pattern, Error) {
 	// attempt to create a valid pattern
 	switch t.Type {
 	// we have a negated pattern
+	//		- if the dialect does not support negation, the negation is
+	//		  part of the pattern
 	case NEGATION:
-		return p.negation(t)
+		if p.dialect().Negation() {
+			return p.negation(t)
+		}
+		t.Type = PATTERN
+		return p.path(t)
 
 	// attempt to build a path specification
 	default:
@@ -158,6 +314,7 @@ func (p *parser) build(t *Token) (Pattern, Error) {
 // negation attempts to build a well-formed negated .gitignore Pattern starting
 // from the negation Token t. As with build, negation returns an Error if the
 // sequence of tokens returned by the Lexer does not represent a valid Pattern.
+// Trailing whitespace is dropped from the sequence of pattern tokens.
 func (p *parser) negation(t *Token) (Pattern, Error) {
 	// a negation appears before a path specification, so
 	// skip the negation token
@@ -172,11 +329,18 @@ func (p *parser) negation(t *Token) (Pattern, Error) {
 		return nil, _err
 	}
 
+	// remove trailing whitespace tokens
+	//		- as with a lone negation, there must be a pattern to negate
+	_tokens = trim(_tokens)
+	if len(_tokens) == 0 {
+		return nil, p.err(InvalidPatternError)
+	}
+
 	// include the "negation" token at the front of the sequence
 	_tokens = append([]*Token{t}, _tokens...)
 
 	// return the Pattern instance
-	return NewPattern(_tokens), nil
+	return newPattern(_tokens, p.dialect()), nil
 } // negation()
 
 // path attempts to build a well-formed .gitignore Pattern representing a path
@@ -191,21 +355,28 @@ func (p *parser) path(t *Token) (Pattern, Error) {
 	}
 
 	// remove trailing whitespace tokens
-	_length := len(_tokens)
+	_tokens = trim(_tokens)
+
+	// return the Pattern instance
+	return newPattern(_tokens, p.dialect()), nil
+} // path()
+
+// trim returns the sequence of tokens without its trailing whitespace tokens.
+func trim(tokens []*Token) []*Token {
+	_length := len(tokens)
 	for _length > 0 {
 		// if we have a non-whitespace token, we can stop
 		_length--
-		if _tokens[_length].Type != WHITESPACE {
+		if tokens[_length].Type != WHITESPACE {
 			break
 		}
 
 		// otherwise, truncate the token list
-		_tokens = _tokens[:_length]
+		tokens = tokens[:_length]
 	}
 
-	// return the Pattern instance
-	return NewPattern(_tokens), nil
-} // path()
+	return tokens
+} // trim()
 
 // sequence attempts to extract a well-formed Token sequence from the Lexer
 // representing a .gitignore Pattern. sequence returns an Error if the
@@ -296,14 +467,21 @@ func (p *parser) any(t *Token) ([]*Token, Error) {
 
 	// what tokens are we allowed to have follow an "any" symbol?
 	switch _token.Type {
-	// an "any" token may only be followed by a separator
 	case SEPARATOR:
 		_next, _err := p.separator(_token)
 		return append(_tokens, _next...), _err
 
-	// whitespace is acceptable if it takes us to the end of the line
+	// as with git, an "any" token that is not a complete path component
+	// is matched as a regular wildcard (e.g. "***" or "foo**")
+	case ANY:
+		_next, _err := p.any(_token)
+		return append(_tokens, _next...), _err
+
 	case WHITESPACE:
-		return _tokens, p.eol()
+		fallthrough
+	case PATTERN:
+		_next, _err := p.pattern(_token)
+		return append(_tokens, _next...), _err
 
 	// if we encounter end of line or file we are done
 	case EOL:
@@ -342,6 +520,11 @@ func (p *parser) pattern(t *Token) ([]*Token, Error) {
 		_next, _err = p.pattern(_token)
 		return append(_tokens, _next...), _err
 
+	// a pattern followed by "any" is matched as a regular wildcard
+	case ANY:
+		_next, _err = p.any(_token)
+		return append(_tokens, _next...), _err
+
 	// if we encounter end of line or file we are done
 	case EOL:
 		fallthrough
@@ -3,8 +3,6 @@ package gitignore
 import (
 	"path/filepath"
 	"strings"
-
-	"github.com/danwakefield/fnmatch"
 )
 
 // Pattern represents per-line patterns within a .gitignore file
@@ -13,19 +11,21 @@ type Pattern interface {
 
 	// Match returns true if the given path matches the name pattern. If the
 	// pattern is meant for directories only, and the path is not a directory,
-	// Match will return false. The matching is performed by fnmatch(). It
+	// Match will return false. The matching is performed by wildmatch(). It
 	// is assumed path is relative to the base path of the owning GitIgnore.
 	Match(string, bool) bool
 }
 
 // pattern is the base implementation of a .gitignore pattern
 type pattern struct {
-	_negated   bool
-	_anchored  bool
-	_directory bool
-	_string    string
-	_fnmatch   string
-	_position  Position
+	_negated    bool
+	_anchored   bool
+	_directory  bool
+	_string     string
+	_wildmatch  string
+	_expression string
+	_flags      WildmatchFlag
+	_position   Position
 } // pattern()
 
 // name represents patterns matching a file or path name (i.e. the last
@@ -45,7 +45,6 @@ type path struct {
 // allowing for recursive matching.
 type any struct {
 	pattern
-	_tokens []*Token
 } // any{}
 
 // NewPattern returns a Pattern from the ordered slice of Tokens. The tokens are
@@ -53,6 +52,12 @@ type any struct {
 // negated, anchored to the start of the path (relative to the base directory
 // of tie containing .gitignore), or match directories only.
 func NewPattern(tokens []*Token) Pattern {
+	return newPattern(tokens, GitDialect)
+} // NewPattern()
+
+// newPattern returns a Pattern from the ordered slice of Tokens, where the
+// anchoring and directory handling of the pattern are given by dialect.
+func newPattern(tokens []*Token, dialect Dialect) Pattern {
 	// if we have no tokens there is no pattern
 	if len(tokens) == 0 {
 		return nil
@@ -70,46 +75,55 @@ func NewPattern(tokens []*Token) Pattern {
 	}
 
 	// is this pattern anchored to the start of the path?
-	_anchored := false
+	_anchored := dialect.Anchored(tokens)
 	if tokens[0].Type == SEPARATOR {
-		_anchored = true
 		tokens = tokens[1:]
 	}
 
 	// is this pattern for directories only?
+	//		- a dialect may ignore the trailing separator
 	_directory := false
 	_last := len(tokens) - 1
-	if tokens[_last].Type == SEPARATOR {
-		_directory = true
+	if _last < 0 {
+		// a lone separator (i.e. "/") has no pattern
+		return nil
+	} else if tokens[_last].Type == SEPARATOR {
+		_directory = dialect.Directory()
 		tokens = tokens[:_last]
 	}
 
 	// build the pattern expression
-	_fnmatch := tokenset(tokens).String()
+	_wildmatch := tokenset(tokens).String()
 	_pattern := &pattern{
-		_negated:   _negated,
-		_anchored:  _anchored,
-		_position:  _position,
-		_directory: _directory,
-		_string:    _string,
-		_fnmatch:   _fnmatch,
+		_negated:    _negated,
+		_anchored:   _anchored,
+		_position:   _position,
+		_directory:  _directory,
+		_string:     _string,
+		_wildmatch:  _wildmatch,
+		_expression: _wildmatch,
 	}
 	return _pattern.compile(tokens)
-} // NewPattern()
+} // newPattern()
 
 // compile generates a specific Pattern (i.e. name, path or any)
 // represented by the list of tokens.
 func (p *pattern) compile(tokens []*Token) Pattern {
 	// what tokens do we have in this pattern?
-	//      - ANY token means we can match to any depth
+	//      - ANY token means we can match to any depth, but only if it
+	//        forms a complete path component
 	//      - SEPARATOR means we have path rather than file matching
 	_separator := false
-	for _, _token := range tokens {
+	_start := 0
+	for _i, _token := range tokens {
 		switch _token.Type {
 		case ANY:
-			return p.any(tokens)
+			if component(tokens, _start) {
+				return p.any(tokens)
+			}
 		case SEPARATOR:
 			_separator = true
+			_start = _i + 1
 		}
 	}
 
@@ -135,6 +149,54 @@ func (p *pattern) Position() Position { return p._position }
 // String returns the string representation of the pattern.
 func (p *pattern) String() string { return p._string }
 
+// casefold makes the pattern case-insensitive, so that paths are matched
+// using the case folded form of the pattern.
+func (p *pattern) casefold() {
+	p._expression = foldpattern(p._wildmatch)
+	p._flags = WM_CASEFOLD
+} // casefold()
+
+// wildmatch returns true if text is matched by the wildmatch expression with
+// the given flags, folding the case of text if the pattern is
+// case-insensitive.
+func (p *pattern) wildmatch(expression, text string, flags WildmatchFlag) bool {
+	if p._flags&WM_CASEFOLD != 0 {
+		text = fold(text)
+	}
+
+	return Wildmatch(expression, text, flags|p._flags)
+} // wildmatch()
+
+// pathname returns true if text is matched by the pattern expression with
+// WM_PATHNAME. As with git, the literal prefix of an anchored pattern is
+// compared before the remainder of the pattern is matched, so that a "**"
+// following the literal prefix (e.g. "foo**/bar") is matched as if it
+// starts the pattern.
+func (p *pattern) pathname(text string) bool {
+	if !p._anchored {
+		return p.wildmatch(p._expression, text, WM_PATHNAME)
+	} else if p._flags&WM_CASEFOLD != 0 {
+		text = fold(text)
+	}
+
+	_prefix := prefixlen(p._expression)
+	if !strings.HasPrefix(text, p._expression[:_prefix]) {
+		return false
+	}
+	return Wildmatch(p._expression[_prefix:], text[_prefix:], WM_PATHNAME|p._flags)
+} // pathname()
+
+// prefixlen returns the length of the literal prefix of the wildmatch
+// expression, up to its first wildcard or escape character.
+func prefixlen(expression string) int {
+	_length := strings.IndexAny(expression, "*?[\\")
+	if _length < 0 {
+		return len(expression)
+	}
+
+	return _length
+} // prefixlen()
+
 //
 // name patterns
 //      - designed to match trailing file/directory names only
@@ -148,7 +210,7 @@ func (p *pattern) name(tokens []*Token) Pattern {
 
 // Match returns true if the given path matches the name pattern. If the
 // pattern is meant for directories only, and the path is not a directory,
-// Match will return false. The matching is performed by fnmatch(). It
+// Match will return false. The matching is performed by wildmatch(). It
 // is assumed path is relative to the base path of the owning GitIgnore.
 func (n *name) Match(path string, isdir bool) bool {
 	// are we expecting a directory?
@@ -157,11 +219,12 @@ func (n *name) Match(path string, isdir bool) bool {
 	}
 
 	// should we match the whole path, or just the last component?
+	//		- an anchored name must not match across path separators
 	if n._anchored {
-		return fnmatch.Match(n._fnmatch, path, 0)
+		return n.pathname(path)
 	} else {
 		_, _base := filepath.Split(path)
-		return fnmatch.Match(n._fnmatch, _base, 0)
+		return n.wildmatch(n._expression, _base, 0)
 	}
 } // Match()
 
@@ -187,8 +250,8 @@ func (p *pattern) path(tokens []*Token) Pattern {
 
 // Match returns true if the given path matches the path pattern. If the
 // pattern is meant for directories only, and the path is not a directory,
-// Match will return false. The matching is performed by fnmatch()
-// with flags set to FNM_PATHNAME. It is assumed path is relative to the
+// Match will return false. The matching is performed by wildmatch()
+// with flags set to WM_PATHNAME. It is assumed path is relative to the
 // base path of the owning GitIgnore.
 func (p *path) Match(path string, isdir bool) bool {
 	// are we expecting a directory
@@ -196,14 +259,19 @@ func (p *path) Match(path string, isdir bool) bool {
 		return false
 	}
 
-	if fnmatch.Match(p._fnmatch, path, fnmatch.FNM_PATHNAME) {
+	if p.pathname(path) {
 		return true
 	} else if p._anchored {
 		return false
 	}
 
 	// match against the trailing path elements
-	return fnmatch.Match(p._fnmatch, path, fnmatch.FNM_PATHNAME)
+	_parts := strings.Split(path, string(_SEPARATOR))
+	if len(_parts) <= p._depth+1 {
+		return false
+	}
+	_trailing := strings.Join(_parts[len(_parts)-p._depth-1:], string(_SEPARATOR))
+	return p.pathname(_trailing)
 } // Match()
 
 //
@@ -213,70 +281,63 @@ func (p *path) Match(path string, isdir bool) bool {
 // any returns a Pattern designed to match paths that include at least one
 // any pattern '**', specifying recursive matching.
 func (p *pattern) any(tokens []*Token) Pattern {
-	// consider only the non-SEPARATOR tokens, as these will be matched
-	// against the path components
-	_tokens := make([]*Token, 0)
-	for _, _token := range tokens {
-		if _token.Type != SEPARATOR {
-			_tokens = append(_tokens, _token)
-		}
-	}
-
-	return &any{*p, _tokens}
+	return &any{*p}
 } // any()
 
 // Match returns true if the given path matches the any pattern. If the
 // pattern is meant for directories only, and the path is not a directory,
-// Match will return false. The matching is performed by recursively applying
-// fnmatch() with flags set to FNM_PATHNAME. It is assumed path is relative to
-// the base path of the owning GitIgnore.
+// Match will return false. The matching is performed by wildmatch() with
+// flags set to WM_PATHNAME, where "**" may match any number of path
+// components. It is assumed path is relative to the base path of the owning
+// GitIgnore.
 func (a *any) Match(path string, isdir bool) bool {
 	// are we expecting a directory?
 	if a._directory && !isdir {
 		return false
 	}
 
-	// split the path into components
-	_parts := strings.Split(path, string(_SEPARATOR))
-
-	// attempt to match the parts against the pattern tokens
-	return a.match(_parts, a._tokens)
-} // Match()
-
-// match performs the recursive matching for 'any' patterns. An 'any'
-// token '**' may match any path component, or no path component.
-func (a *any) match(path []string, tokens []*Token) bool {
-	// if we have no more tokens, then we have matched this path
-	// if there are also no more path elements, otherwise there's no match
-	if len(tokens) == 0 {
-		return len(path) == 0
+	// attempt to match the path against the pattern
+	if a.pathname(path) {
+		return true
+	} else if a._anchored {
+		return false
 	}
 
-	// what token are we trying to match?
-	_token := tokens[0]
-	switch _token.Type {
-	case ANY:
-		if len(path) == 0 {
-			return a.match(path, tokens[1:])
-		} else {
-			return a.match(path, tokens[1:]) || a.match(path[1:], tokens)
+	// match against the trailing path elements
+	_parts := strings.Split(path, string(_SEPARATOR))
+	for _i := 1; _i < len(_parts); _i++ {
+		_trailing := strings.Join(_parts[_i:], string(_SEPARATOR))
+		if a.pathname(_trailing) {
+			return true
 		}
+	}
+
+	return false
+} // Match()
 
-	default:
-		// if we have a non-ANY token, then we must have a non-empty path
-		if len(path) != 0 {
-			// if the current path element matches this token,
-			// we match if the remainder of the path matches the
-			// remaining tokens
-			if fnmatch.Match(_token.Token(), path[0], fnmatch.FNM_PATHNAME) {
-				return a.match(path[1:], tokens[1:])
+// component returns true if the path component of tokens starting at index
+// start consists only of wildcards, and contains an "any" token, such as
+// "**" or "***". As with git, other consecutive asterisks (e.g. "foo**")
+// are matched as a single wildcard "*".
+func component(tokens []*Token, start int) bool {
+	_any := false
+	for _, _token := range tokens[start:] {
+		switch _token.Type {
+		case SEPARATOR:
+			return _any
+		case ANY:
+			_any = true
+		case PATTERN:
+			if strings.Trim(_token.Token(), string(_WILDCARD)) != "" {
+				return false
 			}
+		default:
+			return false
 		}
 	}
 
-	// if we are here, then we have no match
-	return false
-} // match()
+	return _any
+} // component()
 
 // ensure the patterns confirm to the Pattern interface
 var _ Pattern = &name{}
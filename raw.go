@@ -0,0 +1,63 @@
+package gitignore
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// define the runes representing the bytes of the input stream that are not
+// valid UTF-8, mapping the bytes 0x80-0xFF to the surrogates U+DC80-U+DCFF,
+// which are never produced when decoding valid UTF-8
+const (
+	_RAWBYTE = rune(0xdc00)
+	_RAWMIN  = _RAWBYTE + 0x80
+	_RAWMAX  = _RAWBYTE + 0xff
+)
+
+// rawrune returns the rune representing the byte b of the input stream, where
+// b is not part of a valid UTF-8 sequence.
+func rawrune(b byte) rune {
+	return _RAWBYTE + rune(b)
+} // rawrune()
+
+// rawstring returns the string of the runes, where runes representing bytes
+// that are not valid UTF-8 (see rawrune) are replaced by their original
+// bytes, so that the string is byte-for-byte identical to the input stream
+// from which the runes were read.
+func rawstring(runes []rune) string {
+	_string := strings.Builder{}
+	_string.Grow(len(runes))
+	for _, _rune := range runes {
+		if _rune >= _RAWMIN && _rune <= _RAWMAX {
+			_string.WriteByte(byte(_rune - _RAWBYTE))
+		} else if _rune < utf8.RuneSelf {
+			_string.WriteByte(byte(_rune))
+		} else {
+			_string.WriteRune(_rune)
+		}
+	}
+
+	return _string.String()
+} // rawstring()
+
+// MatchBytes attempts to match the path, given as bytes, against the
+// GitIgnore ignore, as with GitIgnore.Match. Paths are matched byte for
+// byte, so that file names that are not valid UTF-8 (such as Latin-1 names)
+// are matched as git matches them.
+func MatchBytes(ignore GitIgnore, path []byte) Match {
+	return ignore.Match(string(path))
+} // MatchBytes()
+
+// AbsoluteBytes attempts to match an absolute path, given as bytes, against
+// the GitIgnore ignore, as with GitIgnore.Absolute. Paths are matched byte
+// for byte (see MatchBytes).
+func AbsoluteBytes(ignore GitIgnore, path []byte, isdir bool) Match {
+	return ignore.Absolute(string(path), isdir)
+} // AbsoluteBytes()
+
+// RelativeBytes attempts to match a path relative to the base directory of
+// the GitIgnore ignore, given as bytes, as with GitIgnore.Relative. Paths
+// are matched byte for byte (see MatchBytes).
+func RelativeBytes(ignore GitIgnore, path []byte, isdir bool) Match {
+	return ignore.Relative(string(path), isdir)
+} // RelativeBytes()
@@ -0,0 +1,50 @@
+package gitignore_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestTokenBytes(t *testing.T) {
+	// ensure bytes that are not valid UTF-8 are not replaced by U+FFFD
+	_lexer := gitignore.NewLexer(strings.NewReader(_BYTESIGNORE))
+	_token, _err := _lexer.Next()
+	if _err != nil {
+		t.Fatalf("unexpected lexer error: %s", _err.Error())
+	} else if _token.Type != gitignore.PATTERN {
+		t.Fatalf("unexpected token type; expected %s, got %s",
+			gitignore.PATTERN, _token.Name(),
+		)
+	}
+
+	if _token.Token() != "caf\xe9" {
+		t.Fatalf("unexpected token; expected %q, got %q", "caf\xe9", _token.Token())
+	} else if !bytes.Equal(_token.Bytes(), []byte("caf\xe9")) {
+		t.Fatalf("unexpected token bytes; expected %q, got %q", "caf\xe9", _token.Bytes())
+	}
+} // TestTokenBytes()
+
+func TestRelativeBytes(t *testing.T) {
+	_reader := strings.NewReader(_BYTESIGNORE)
+	_ignore := gitignore.New(_reader, "/base", nil)
+
+	// ensure the paths are matched byte for byte
+	for _, _match := range _BYTESMATCHES {
+		do(t, _ignore.Relative, _match)
+
+		// ensure the []byte API matches the same patterns
+		_relative := func(path string, isdir bool) gitignore.Match {
+			return gitignore.RelativeBytes(_ignore, []byte(path), isdir)
+		}
+		do(t, _relative, _match)
+
+		_absolute := func(path string, isdir bool) gitignore.Match {
+			_path := []byte("/base/" + path)
+			return gitignore.AbsoluteBytes(_ignore, _path, isdir)
+		}
+		do(t, _absolute, _match)
+	}
+} // TestRelativeBytes()
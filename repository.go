@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 const File = ".gitignore"
@@ -12,15 +13,41 @@ const File = ".gitignore"
 // repository hierarchy
 type repository struct {
 	ignore
-	_errors  func(e Error) bool
-	_cache   Cache
-	_file    string
-	_exclude GitIgnore
+	_errors   func(e Error) bool
+	_cache    Cache
+	_files    []string
+	_dialects []Dialect
+	_settings settings
+	_exclude  GitIgnore
+	_global   GitIgnore
+	_gitdir   string
+	_options  Options
+	_index    Index
+	_loader   loader
+
+	// nested repositories within this repository, and the directories
+	// known to be (or not to be) the root of a nested repository
+	_repositories map[string]*repository
+	_roots        map[string]bool
+	_lock         sync.Mutex
 } // repository{}
 
+// Repository is the interface to a git repository GitIgnore, extending the
+// GitIgnore interface to also report the repository GIT_DIR. The Base of a
+// Repository is the root directory of its work tree.
+type Repository interface {
+	GitIgnore
+
+	// GitDir returns the GIT_DIR of the repository, or the empty string if
+	// the repository has no GIT_DIR.
+	GitDir() string
+} // Repository{}
+
 // NewRepository returns a GitIgnore instance representing a git repository
 // with root directory base. If base is not a directory, or base cannot be
-// read, NewRepository will return an error.
+// read, NewRepository will return an error. Nested repositories are not
+// detected, so the ignore files of this repository apply to the paths they
+// contain (see NestedNone).
 //
 // Internally, NewRepository uses NewRepositoryWithFile.
 func NewRepository(base string) (GitIgnore, error) {
@@ -33,7 +60,8 @@ func NewRepository(base string) (GitIgnore, error) {
 // .gitignore patterns. If file is the empty string, NewRepositoryWithFile
 // uses ".gitignore". If the ignore file name is ".gitignore", the returned
 // GitIgnore instance will also consider patterns listed in
-// $GIT_DIR/info/exclude when performing repository matching.
+// $GIT_DIR/info/exclude, and the global excludes file, when performing
+// repository matching.
 //
 // Internally, NewRepositoryWithFile uses NewRepositoryWithErrors.
 func NewRepositoryWithFile(base, file string) (GitIgnore, error) {
@@ -63,13 +91,55 @@ func NewRepositoryWithFile(base, file string) (GitIgnore, error) {
 	return _repository, nil
 } // NewRepositoryWithFile()
 
+// NewRepositoryWithFiles returns a GitIgnore instance representing a git
+// repository with root directory base, loading the ignore patterns of each
+// directory from the named files, given in increasing order of precedence.
+// For example, with files ".gitignore", ".ignore" and ".mytoolignore",
+// patterns in .mytoolignore override patterns in .ignore, which override
+// patterns in .gitignore, within each directory. As with git, the ignore
+// files of a directory take precedence over those of its parent directories.
+// If ".gitignore" is one of the files, the returned GitIgnore instance will
+// also consider patterns listed in $GIT_DIR/info/exclude, and the global
+// excludes file, when performing repository matching.
+//
+// The Position of each Match names the file containing the matching pattern.
+//
+// Internally, NewRepositoryWithFiles uses NewRepositoryWithOptions.
+func NewRepositoryWithFiles(base string, files ...string) (GitIgnore, error) {
+	// define an error handler to catch any file access errors
+	//		- record the first encountered error
+	var _error Error
+	_errors := func(e Error) bool {
+		if _error == nil {
+			_error = e
+		}
+		return true
+	}
+
+	// attempt to retrieve the repository represented by these files
+	_options := Options{Files: files, Cache: NewCache(), Errors: _errors}
+	_repository := NewRepositoryWithOptions(base, _options)
+
+	// did we encounter an error?
+	//		- if the error has a zero Position then it was encountered
+	//		  before parsing was attempted, so we return that error
+	if _error != nil {
+		if _error.Position().Zero() {
+			return nil, _error.Underlying()
+		}
+	}
+
+	// otherwise, we ignore the parser errors
+	return _repository, nil
+} // NewRepositoryWithFiles()
+
 // NewRepositoryWithErrors returns a GitIgnore instance representing a git
 // repository with a root directory base. As with NewRepositoryWithFile, file
 // specifies the name of the files within the repository containing the
 // .gitignore patterns, and defaults to ".gitignore" if file is not specified.
 // If the ignore file name is ".gitignore", the returned GitIgnore instance
-// will also consider patterns listed in $GIT_DIR/info/exclude when performing
-// repository matching.
+// will also consider patterns listed in $GIT_DIR/info/exclude, and the global
+// excludes file, when performing repository matching.
 //
 // If errors is given, it will be invoked for each error encountered while
 // matching a path against the repository GitIgnore (such as file permission
@@ -85,8 +155,8 @@ func NewRepositoryWithErrors(base, file string, errors func(e Error) bool) GitIg
 // file specifies the name of the files within the repository containing the
 // .gitignore patterns, and defaults to ".gitignore" if file is not specified.
 // If the ignore file name is ".gitignore", the returned GitIgnore instance
-// will also consider patterns listed in $GIT_DIR/info/exclude when performing
-// repository matching.
+// will also consider patterns listed in $GIT_DIR/info/exclude, and the global
+// excludes file, when performing repository matching.
 //
 // NewRepositoryWithCache will attempt to load each .gitignore within the
 // repository only once, using NewWithCache to store the corresponding
@@ -96,9 +166,30 @@ func NewRepositoryWithErrors(base, file string, errors func(e Error) bool) GitIg
 // If errors is given, it will be invoked for each error encountered while
 // matching a path against the repository GitIgnore (such as file permission
 // denied, or errors during .gitignore parsing). See Match below.
+//
+// Internally, NewRepositoryWithCache uses NewRepositoryWithOptions.
 func NewRepositoryWithCache(base, file string, cache Cache, errors func(e Error) bool) GitIgnore {
+	_options := Options{File: file, Cache: cache, Errors: errors}
+	return NewRepositoryWithOptions(base, _options)
+} // NewRepositoryWithCache()
+
+// NewRepositoryWithOptions returns a GitIgnore instance representing a git
+// repository with a root directory base, configured by options. If the ignore
+// file name is ".gitignore", the returned GitIgnore instance will also
+// consider patterns listed in $GIT_DIR/info/exclude, and, unless disabled
+// by options.NoGlobal, the global excludes file. The global excludes file is
+// given by the core.excludesFile git configuration variable, and defaults to
+// $XDG_CONFIG_HOME/git/ignore (or $HOME/.config/git/ignore if
+// $XDG_CONFIG_HOME is not set). Patterns in $GIT_DIR/info/exclude take
+// precedence over the global excludes file, and patterns in the .gitignore
+// files of the repository take precedence over both.
+//
+// The GIT_DIR of the repository, and its common directory and index, are
+// located using options.Environment if given, and otherwise using the
+// environment variables of the current process.
+func NewRepositoryWithOptions(base string, options Options) GitIgnore {
 	// do we have an error handler?
-	_errors := errors
+	_errors := options.Errors
 	if _errors == nil {
 		_errors = func(e Error) bool { return true }
 	}
@@ -122,34 +213,121 @@ func NewRepositoryWithCache(base, file string, cache Cache, errors func(e Error)
 		return nil
 	}
 
+	// locate the GIT_DIR for this repository
+	//		- relative paths of the environment are interpreted relative
+	//		  to the base directory
+	_options := options
+	_options.Environment = options.environment().resolve(_base)
+	_gitdir, _err := gitdir(_base, _options.Environment)
+	if _err != nil {
+		_errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	// create the repository instance
+	_repository, _err := newRepository(_base, _gitdir, _options)
+	if _err != nil {
+		_errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	return _repository
+} // NewRepositoryWithOptions()
+
+// newRepository returns the repository instance with the absolute root
+// directory base and the given gitdir, configured by options. An error is
+// returned if $GIT_DIR/info/exclude or the global excludes file cannot be
+// loaded.
+func newRepository(base, gitdir string, options Options) (*repository, error) {
+	// do we have an error handler?
+	_errors := options.Errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
 	// if we haven't been given a base file name, use the default
-	if file == "" {
-		file = File
+	_files := options.files()
+	_dialects := options.dialects(_files)
+
+	// locate the common directory of the repository
+	//		- this holds the repository-wide files, such as info/exclude
+	//		  and the configuration
+	_options := options
+	_options.Environment = options.environment()
+	_common, _err := _options.Environment.common(gitdir)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// should we match paths case-insensitively, and normalize paths and
+	// patterns?
+	_settings, _err := options.settings(_common)
+	if _err != nil {
+		return nil, _err
 	}
 
 	// are we matching .gitignore files?
-	//		- if we are, we also consider $GIT_DIR/info/exclude
-	var _exclude GitIgnore
-	if file == File {
-		_exclude, _err = exclude(_base)
+	//		- if we are, we also consider $GIT_DIR/info/exclude and the
+	//		  global excludes file
+	var _exclude, _global GitIgnore
+	if contains(_files, File) {
+		_exclude, _err = exclude(_common, _settings._casefold, _settings._form)
+		if _err != nil {
+			return nil, _err
+		}
+		if !options.NoGlobal {
+			_global, _err = global(base, _common, _settings._casefold, _settings._form)
+			if _err != nil {
+				return nil, _err
+			}
+		}
+	}
+
+	// should we consult the index for tracked files?
+	var _index Index
+	if options.Tracked && gitdir != "" {
+		_file := _options.Environment.IndexFile
+		if _file == "" {
+			_file = filepath.Join(gitdir, "index")
+		}
+		_index, _err = indexfor(_file, _common)
 		if _err != nil {
-			_errors(NewError(_err, Position{}))
-			return nil
+			return nil, _err
 		}
 	}
 
 	// create the repository instance
-	_ignore := ignore{_base: _base}
+	_ignore := ignore{_base: base}
 	_repository := &repository{
-		ignore:   _ignore,
-		_errors:  _errors,
-		_exclude: _exclude,
-		_cache:   cache,
-		_file:    file,
+		ignore:    _ignore,
+		_errors:   _errors,
+		_exclude:  _exclude,
+		_global:   _global,
+		_gitdir:   gitdir,
+		_options:  _options,
+		_index:    _index,
+		_cache:    options.Cache,
+		_files:    _files,
+		_dialects: _dialects,
+		_settings: _settings,
+	}
+	_repository._loader = &files{
+		_base:     base,
+		_files:    _files,
+		_dialects: _dialects,
+		_cache:    options.Cache,
+		_settings: _settings,
+		_errors:   _errors,
 	}
 
-	return _repository
-} // NewRepositoryWithCache()
+	return _repository, nil
+} // newRepository()
+
+// GitDir returns the GIT_DIR of the repository, or the empty string if the
+// repository has no GIT_DIR.
+func (r *repository) GitDir() string {
+	return r._gitdir
+} // GitDir()
 
 // Match attempts to match the path against this repository. Matching proceeds
 // according to normal gitignore rules, where .gtignore files in the same
@@ -195,19 +373,27 @@ func (r *repository) Match(path string) Match {
 // path is not located under the base directory of this repository, or is not
 // matched by this repository, nil is returned.
 func (r *repository) Absolute(path string, isdir bool) Match {
+	// compare the normalized forms of the path and base directory
+	path = r._settings._form.string(path)
+	_base := r._settings._form.string(r.Base())
+
 	// does the file share the same directory as this ignore file?
-	if !strings.HasPrefix(path, r.Base()) {
+	if !strings.HasPrefix(path, _base) {
 		return nil
 	}
 
 	// extract the relative path of this file
-	_prefix := len(r.Base()) + 1
+	_prefix := len(_base) + 1
 	_rel := string(path[_prefix:])
 	return r.Relative(_rel, isdir)
 } // Absolute()
 
 // Relative attempts to match a path relative to the repository base directory.
-// If the path is not matched by the repository, nil is returned.
+// If the path is not matched by the repository, nil is returned. Paths
+// located within a nested repository or submodule are matched according to
+// the Nested configuration of the repository. If the repository has been
+// configured to consult its index, paths tracked by the index are returned
+// as a Tracked Match.
 func (r *repository) Relative(path string, isdir bool) Match {
 	// if there's no path, then there's nothing to match
 	_path := filepath.Clean(path)
@@ -215,6 +401,35 @@ func (r *repository) Relative(path string, isdir bool) Match {
 		return nil
 	}
 
+	// is this path tracked?
+	//		- ignore rules only apply to untracked files
+	if r._index != nil {
+		if r._index.Tracked(_path, isdir) {
+			return &tracked{_path: filepath.ToSlash(_path)}
+		}
+	}
+
+	// is this path located within a nested repository?
+	if r._options.Nested != NestedNone {
+		_root := r.boundary(_path)
+		if _root != "" {
+			return r.nested(_root, _path, isdir)
+		}
+	}
+
+	return r.relative(path, isdir)
+} // Relative()
+
+// relative attempts to match a path relative to the repository base directory,
+// without regard for nested repositories. If the path is not matched by the
+// repository, nil is returned.
+func (r *repository) relative(path string, isdir bool) Match {
+	// if there's no path, then there's nothing to match
+	_path := filepath.Clean(path)
+	if _path == "." {
+		return nil
+	}
+
 	// repository matching:
 	//		- a child path cannot be considered if its parent is ignored
 	//		- a .gitignore in a lower directory overrides a .gitignore in a
@@ -223,7 +438,7 @@ func (r *repository) Relative(path string, isdir bool) Match {
 	// first, is the parent directory ignored?
 	//		- extract the parent directory from the current path
 	_parent, _local := filepath.Split(_path)
-	_match := r.Relative(_parent, true)
+	_match := r.relative(_parent, true)
 	if _match != nil {
 		if _match.Ignore() {
 			return _match
@@ -236,8 +451,7 @@ func (r *repository) Relative(path string, isdir bool) Match {
 	//		  move up the path hierarchy
 	var _last string
 	for {
-		_file := filepath.Join(r._base, _parent, r._file)
-		_ignore := NewWithCache(_file, r._cache, r._errors)
+		_ignore := r._loader.load(_parent)
 		if _ignore != nil {
 			_match := _ignore.Relative(_local, isdir)
 			if _match != nil {
@@ -263,12 +477,132 @@ func (r *repository) Relative(path string, isdir bool) Match {
 
 	// do we have a global exclude file? (i.e. GIT_DIR/info/exclude)
 	if r._exclude != nil {
-		return r._exclude.Relative(path, isdir)
+		_match := r._exclude.Relative(path, isdir)
+		if _match != nil {
+			return _match
+		}
+	}
+
+	// finally, do we have a user excludes file? (i.e. core.excludesFile)
+	if r._global != nil {
+		return r._global.Relative(path, isdir)
 	}
 
 	// we have no match
 	return nil
-} // Relative()
+} // relative()
+
+// MatchAll attempts to match a path relative to the repository base
+// directory, returning every pattern matching the path, or its parent
+// directories, in increasing order of precedence. For each of the parent
+// directories of path, and then path itself, MatchAll reports the patterns of
+// the global excludes file, $GIT_DIR/info/exclude, and the .gitignore files
+// from the root of the repository down to the directory of the path. The
+// patterns matching a path are reported even if its parent directory is
+// excluded, although only the pattern excluding the parent directory is
+// decisive. If the repository has been configured to consult its index, a
+// Tracked Match is the decisive Trace of a tracked path. If no pattern
+// matches the path, or its parent directories, nil is returned.
+func (r *repository) MatchAll(path string, isdir bool) []Trace {
+	// if there's no path, then there's nothing to match
+	_path := filepath.Clean(path)
+	if _path == "." {
+		return nil
+	}
+
+	// is this path located within a nested repository?
+	var _traces []Trace
+	_root := ""
+	if r._options.Nested != NestedNone {
+		_root = r.boundary(_path)
+	}
+	if _root != "" {
+		_traces = r.tracenested(_root, _path, isdir)
+	} else {
+		_traces = r.trace(path, isdir)
+	}
+
+	// is this path tracked?
+	//		- ignore rules only apply to untracked files
+	if r._index != nil {
+		if r._index.Tracked(_path, isdir) {
+			_slashed := filepath.ToSlash(_path)
+			_tracked := Trace{Match: &tracked{_path: _slashed}, Path: _slashed}
+			_traces = append(_traces, _tracked)
+			return decide(_traces, len(_traces)-1)
+		}
+	}
+
+	return _traces
+} // MatchAll()
+
+// trace returns every pattern matching the path relative to the repository
+// base directory, or its parent directories, without regard for nested
+// repositories, following the rules of relative(). If the path is not
+// matched, nil is returned.
+func (r *repository) trace(path string, isdir bool) []Trace {
+	// if there's no path, then there's nothing to match
+	_path := filepath.Clean(path)
+	if _path == "." {
+		return nil
+	}
+
+	// first, trace the parent directory
+	//		- if the parent directory is ignored, its decisive pattern
+	//		  remains decisive for the path
+	_parent, _local := filepath.Split(_path)
+	_traces := r.trace(_parent, true)
+	_decisive := decisive(_traces)
+	if _decisive != -1 && !_traces[_decisive].Ignore() {
+		_decisive = -1
+	}
+	_parent = filepath.Clean(_parent)
+
+	// collect the ignore files that may match the path
+	//		- we consider the directory of the path first, then move up
+	//		  the path hierarchy, as with relative()
+	_dirs := []string{_parent}
+	_locals := []string{_local}
+	for _parent != "." {
+		var _last string
+		_parent, _last = filepath.Split(_parent)
+		_parent = filepath.Clean(_parent)
+		_local = _last + string(_SEPARATOR) + _local
+		_dirs = append(_dirs, _parent)
+		_locals = append(_locals, _local)
+	}
+
+	// collect the matching patterns in increasing order of precedence
+	//		- the global excludes file and $GIT_DIR/info/exclude, then the
+	//		  ignore files from the root of the repository down to the
+	//		  directory of the path
+	var _matches []Trace
+	if r._global != nil {
+		_matches = append(_matches, r._global.MatchAll(path, isdir)...)
+	}
+	if r._exclude != nil {
+		_matches = append(_matches, r._exclude.MatchAll(path, isdir)...)
+	}
+	for _i := len(_dirs) - 1; _i >= 0; _i-- {
+		_ignore := r._loader.load(_dirs[_i])
+		if _ignore != nil {
+			_matches = append(_matches, _ignore.MatchAll(_locals[_i], isdir)...)
+		}
+	}
+
+	// the matches are reported relative to the repository base directory
+	_slashed := filepath.ToSlash(_path)
+	for _i := range _matches {
+		_matches[_i].Path = _slashed
+	}
+
+	// the highest precedence match is decisive, unless the parent directory
+	// is ignored
+	if _decisive == -1 && len(_matches) != 0 {
+		_decisive = len(_traces) + len(_matches) - 1
+	}
+	return decide(append(_traces, _matches...), _decisive)
+} // trace()
 
-// ensure repository satisfies the GitIgnore interface
-var _ GitIgnore = &repository{}
+// ensure repository satisfies the Repository interface
+var _ Repository = &repository{}
@@ -1,6 +1,7 @@
 package gitignore_test
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -734,3 +735,100 @@ func run(t *testing.T, test *invalidtest) {
 		}
 	}
 } // run()
+
+func TestRepositoryLinked(t *testing.T) {
+	// create the repository with a linked worktree and submodule
+	_dir, _err := dir(_GITLINKED)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// the linked worktree references its gitdir by absolute path
+	_gitdir := filepath.Join(_dir, ".git", "worktrees", "linked")
+	_err = ioutil.WriteFile(
+		filepath.Join(_dir, "linked", ".git"),
+		[]byte("gitdir: "+_gitdir+"\n"),
+		_GITMASK,
+	)
+	if _err != nil {
+		t.Fatalf("unable to create .git file: %s", _err.Error())
+	}
+
+	// ensure GIT_DIR does not override the .git files
+	_restore, _err := setenv(map[string]string{"GIT_DIR": ""})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// ensure info/exclude is located for both the linked worktree and
+	// the submodule
+	_tests := map[string][]match{
+		"linked": _LINKEDMATCHES,
+		"sub":    _SUBMODULEMATCHES,
+	}
+	for _path, _matches := range _tests {
+		_repository, _err := gitignore.NewRepository(filepath.Join(_dir, _path))
+		if _err != nil {
+			t.Fatalf("unable to create repository: %s", _err.Error())
+		}
+		_cb := func(path string, isdir bool) gitignore.Match {
+			return _repository.Relative(path, isdir)
+		}
+		for _, _match := range _matches {
+			do(t, _cb, _match)
+		}
+	}
+} // TestRepositoryLinked()
+
+func TestRepositoryWithFiles(t *testing.T) {
+	// create the repository with several ignore files in each directory
+	_dir, _err := dir(_GITFILES)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_DIR":             "",
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+		"XDG_CONFIG_HOME":     _dir,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	_repository, _err := gitignore.NewRepositoryWithFiles(_dir, _GITFILENAMES...)
+	if _err != nil {
+		t.Fatalf("unable to create repository: %s", _err.Error())
+	}
+
+	// ensure each path is matched by the expected file
+	for _, _test := range _GITFILEMATCHES {
+		_match := _repository.Relative(filepath.FromSlash(_test.Path), false)
+		if _match == nil {
+			t.Errorf("failed match for %q; expected %q", _test.Path, _test.Pattern)
+			continue
+		} else if _match.String() != _test.Pattern {
+			t.Errorf("pattern mismatch for %q; expected %q, got %q",
+				_test.Path, _test.Pattern, _match.String(),
+			)
+		} else if _match.Ignore() != _test.Ignore {
+			t.Errorf("ignore mismatch for %q; expected %v, got %v",
+				_test.Path, _test.Ignore, _match.Ignore(),
+			)
+		}
+
+		// ensure the match reports the file containing the pattern
+		_file := filepath.Join(_dir, filepath.FromSlash(_test.File))
+		if _match.Position().File != _file {
+			t.Errorf("file mismatch for %q; expected %q, got %q",
+				_test.Path, _file, _match.Position().File,
+			)
+		}
+	}
+} // TestRepositoryWithFiles()
@@ -0,0 +1,415 @@
+package gitignore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// revision is the implementation of a repository GitIgnore for a tree
+// within the object database of a repository, where the ignore files of the
+// repository are read from the blobs of the tree rather than from a work tree
+type revision struct {
+	*repository
+	_blobs *blobs
+} // revision{}
+
+// blobs is the loader for revisions, reading ignore files from the blobs of
+// a tree in the object database
+type blobs struct {
+	_objects  *objects
+	_root     []byte
+	_files    []string
+	_dialects []Dialect
+	_settings settings
+	_cache    Cache
+	_errors   func(Error) bool
+	_trees    map[string]map[string]node
+	_lock     sync.Mutex
+} // blobs{}
+
+// node represents an entry of a git tree
+type node struct {
+	_mode uint32
+	_id   []byte
+} // node{}
+
+// NewRevision returns a Repository representing the tree of the given
+// revision of the repository with the given gitdir, which need not have a
+// work tree (i.e. gitdir may be a bare repository). revision is the
+// hexadecimal ID of a commit, a tree, or an annotated tag.
+//
+// Internally, NewRevision uses NewRevisionWithOptions.
+func NewRevision(gitdir, revision string) (Repository, error) {
+	return NewRevisionWithOptions(gitdir, revision, Options{Cache: NewCache()})
+} // NewRevision()
+
+// NewRevisionWithOptions returns a Repository representing the tree of the
+// given revision of the repository with the given gitdir, configured by
+// options. The ignore files of the revision are read directly from the
+// object database of the repository (i.e. from loose objects and packfiles),
+// so that the returned Repository matches paths as git would have done at
+// that revision, without the revision being checked out. If the ignore file
+// name is ".gitignore", $GIT_DIR/info/exclude and (unless disabled by
+// options.NoGlobal) the global excludes file are also considered. If
+// options.Tracked is set, the paths of the revision tree are regarded as
+// tracked.
+//
+// A revision has no work tree, so its Base is the empty string, and paths
+// given to Match and Absolute are interpreted relative to the root of the
+// tree. Unless options.Nested is NestedNone (the default), submodules of the
+// revision are always reported as a Boundary, since their content is not
+// part of the object database.
+//
+// The common directory of the repository (holding its configuration and
+// object database) is given by options.Environment if defined, and otherwise
+// by the GIT_COMMON_DIR environment variable or the commondir file of gitdir.
+//
+// An error is returned if gitdir is not a git directory, revision cannot be
+// found, or revision does not refer to a tree.
+func NewRevisionWithOptions(gitdir, revision string, options Options) (Repository, error) {
+	_gitdir, _err := filepath.Abs(gitdir)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// locate the common directory of the repository
+	//		- relative paths of the environment are interpreted relative
+	//		  to the git directory
+	_options := options
+	_options.Environment = options.environment().resolve(_gitdir)
+	_common, _err := _options.Environment.common(_gitdir)
+	if _err != nil {
+		return nil, _err
+	} else if !valid(_gitdir, _common) {
+		return nil, NotRepositoryError
+	}
+
+	// open the object database
+	_hashsize, _err := hashsize(_common)
+	if _err != nil {
+		return nil, _err
+	}
+	_objects, _err := newObjects(_common, _hashsize)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// locate the tree of the revision
+	_id, _err := _objects.id(revision)
+	if _err != nil {
+		return nil, _err
+	}
+	_root, _err := _objects.tree(_id)
+	if _err != nil {
+		return nil, _err
+	}
+
+	return newRevision(_gitdir, _objects, _root, _options)
+} // NewRevisionWithOptions()
+
+// newRevision returns the revision instance for the tree root of the
+// repository with the given gitdir and object database, configured by
+// options.
+func newRevision(gitdir string, objects *objects, root []byte, options Options) (*revision, error) {
+	// the tree is indexed separately, so we don't consult the index
+	_options := options
+	_options.Tracked = false
+	_repository, _err := newRepository("", gitdir, _options)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// read the ignore files from the tree
+	//		- includes name files of the work tree, so are not followed
+	//		  within the ignore files of a tree
+	_settings := _repository._settings
+	_settings._includes = false
+	_blobs := &blobs{
+		_objects:  objects,
+		_root:     root,
+		_files:    _repository._files,
+		_dialects: _repository._dialects,
+		_settings: _settings,
+		_cache:    _repository._cache,
+		_errors:   _repository._errors,
+		_trees:    make(map[string]map[string]node),
+	}
+	_repository._loader = _blobs
+	_repository._options = options
+
+	// should we regard the paths of the tree as tracked?
+	if options.Tracked {
+		_paths, _err := _blobs.paths(".")
+		if _err != nil {
+			return nil, _err
+		}
+		sort.Strings(_paths)
+		_repository._index = &index{_paths: _paths}
+	}
+
+	return &revision{repository: _repository, _blobs: _blobs}, nil
+} // newRevision()
+
+// Match attempts to match the path, relative to the root of the tree,
+// against this revision. If path is not present in the tree, the error
+// handler is invoked and Match returns nil.
+func (r *revision) Match(path string) Match {
+	// is the path a file or a directory?
+	_path := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	_node, _ok, _err := r._blobs.lookup(_path)
+	if _err != nil {
+		r._errors(NewError(_err, Position{}))
+		return nil
+	} else if !_ok {
+		_err = &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+		r._errors(NewError(_err, Position{}))
+		return nil
+	}
+	_isdir := _node._mode&_MODEMASK == _MODETREE
+
+	return r.Relative(_path, _isdir)
+} // Match()
+
+// Absolute attempts to match a path against this revision. Since a revision
+// has no work tree, Absolute interprets path relative to the root of the
+// tree.
+func (r *revision) Absolute(path string, isdir bool) Match {
+	_path := strings.TrimPrefix(filepath.ToSlash(path), "/")
+	return r.Relative(_path, isdir)
+} // Absolute()
+
+// Ignore returns true if the path is ignored by this revision. Paths that
+// are not matched by this revision are not ignored.
+func (r *revision) Ignore(path string) bool {
+	_match := r.Match(path)
+	if _match != nil {
+		return _match.Ignore()
+	}
+
+	// we didn't match this path, so we don't ignore it
+	return false
+} // Ignore()
+
+// Include returns true if the path is included by this revision. Paths that
+// are not matched by this revision are always included.
+func (r *revision) Include(path string) bool {
+	_match := r.Match(path)
+	if _match != nil {
+		return _match.Include()
+	}
+
+	// we didn't match this path, so we include it
+	return true
+} // Include()
+
+// load returns the GitIgnore for the ignore files in the directory dir of the
+// tree, or nil if dir has no ignore files.
+func (b *blobs) load(dir string) GitIgnore {
+	_tree, _err := b.tree(filepath.ToSlash(dir))
+	if _err != nil {
+		b._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	// load each of the ignore files of this directory
+	_layers := make([]GitIgnore, 0, len(b._files))
+	for _i, _name := range b._files {
+		// some dialects only read the ignore file of the root directory
+		_settings := b._settings
+		_settings._dialect = b._dialects[_i]
+		if !_settings._dialect.PerDirectory() && filepath.Clean(dir) != "." {
+			continue
+		}
+
+		_ignore := b.blob(dir, _name, _settings, _tree)
+		if _ignore != nil {
+			_layers = append(_layers, _ignore)
+		}
+	}
+
+	return layer(dir, _layers, b._errors)
+} // load()
+
+// blob returns the GitIgnore for the ignore file name, parsed according to
+// settings, in the directory dir of the tree, with entries tree, or nil if
+// dir has no such ignore file.
+func (b *blobs) blob(dir, name string, settings settings, tree map[string]node) GitIgnore {
+	// only regular files are considered as ignore files
+	_node, _ok := tree[name]
+	if !_ok || _node._mode&_MODEMASK != _MODEFILE {
+		return nil
+	}
+
+	// ignore files are identified by their object ID and path in the cache
+	//		- this allows ignore files to be shared between revisions
+	_file := filepath.ToSlash(filepath.Join(dir, name))
+	_id := hex.EncodeToString(_node._id)
+	_key := filepath.Join(b._objects._dir, _id, filepath.FromSlash(_file))
+	if b._cache != nil {
+		_ignore := b._cache.Get(_key)
+		if _ignore != nil {
+			return _ignore
+		}
+	}
+
+	// read the ignore file from the object database
+	//		- the patterns record the path of the ignore file within the
+	//		  tree
+	_data, _err := b._objects.object(_node._id, _BLOB)
+	if _err != nil {
+		b._errors(NewError(_err, Position{}))
+		return nil
+	}
+	_ignore := parse(bytes.NewReader(_data), dir, _file, settings, b._errors)
+	if b._cache != nil {
+		b._cache.Set(_key, _ignore)
+	}
+
+	return _ignore
+} // blob()
+
+// nested returns true if dir is a submodule (i.e. a gitlink) of the tree.
+func (b *blobs) nested(dir string) bool {
+	_node, _ok, _err := b.lookup(filepath.ToSlash(dir))
+	if _err != nil {
+		b._errors(NewError(_err, Position{}))
+		return false
+	}
+
+	return _ok && _node._mode&_MODEMASK == _MODEGITLINK
+} // nested()
+
+// open returns nil, since the content of submodules is not part of the
+// object database of the revision.
+func (b *blobs) open(root string, options Options) (*repository, error) {
+	return nil, nil
+} // open()
+
+// lookup returns the tree entry for the path, relative to the root of the
+// tree, and true if the path is present in the tree.
+func (b *blobs) lookup(file string) (node, bool, error) {
+	if file == "." || file == "" {
+		return node{_mode: _MODETREE, _id: b._root}, true, nil
+	}
+
+	// find the entry within the tree of its parent directory
+	_parent, _name := ".", file
+	_n := strings.LastIndex(file, string(_SEPARATOR))
+	if _n != -1 {
+		_parent, _name = file[:_n], file[_n+1:]
+	}
+	_tree, _err := b.tree(_parent)
+	if _err != nil {
+		return node{}, false, _err
+	}
+	_node, _ok := _tree[_name]
+
+	return _node, _ok, nil
+} // lookup()
+
+// tree returns the entries of the directory dir, relative to the root of the
+// tree. If dir is not a directory of the tree, tree returns no entries.
+func (b *blobs) tree(dir string) (map[string]node, error) {
+	b._lock.Lock()
+	_tree, _ok := b._trees[dir]
+	b._lock.Unlock()
+	if _ok {
+		return _tree, nil
+	}
+
+	// locate the tree object of this directory
+	_node, _ok, _err := b.lookup(dir)
+	if _err != nil {
+		return nil, _err
+	} else if !_ok || _node._mode&_MODEMASK != _MODETREE {
+		return nil, nil
+	}
+
+	// read the tree entries
+	_data, _err := b._objects.object(_node._id, _TREE)
+	if _err != nil {
+		return nil, _err
+	}
+	_tree, _err = entries(_data, b._objects._hashsize)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// record the entries for subsequent lookups
+	b._lock.Lock()
+	b._trees[dir] = _tree
+	b._lock.Unlock()
+
+	return _tree, nil
+} // tree()
+
+// paths returns the paths of the files and submodules located beneath the
+// directory dir of the tree.
+func (b *blobs) paths(dir string) ([]string, error) {
+	_tree, _err := b.tree(dir)
+	if _err != nil {
+		return nil, _err
+	}
+
+	_paths := make([]string, 0, len(_tree))
+	for _name, _node := range _tree {
+		_path := _name
+		if dir != "." {
+			_path = dir + string(_SEPARATOR) + _name
+		}
+		if _node._mode&_MODEMASK != _MODETREE {
+			_paths = append(_paths, _path)
+			continue
+		}
+
+		// descend into the subdirectory
+		_children, _err := b.paths(_path)
+		if _err != nil {
+			return nil, _err
+		}
+		_paths = append(_paths, _children...)
+	}
+
+	return _paths, nil
+} // paths()
+
+// entries parses the content of a git tree object, with object IDs of
+// hashsize bytes, returning its entries keyed by name.
+func entries(data []byte, hashsize int) (map[string]node, error) {
+	// each entry is "<octal mode> <name>\x00<id>"
+	_entries := make(map[string]node)
+	for len(data) > 0 {
+		_space := bytes.IndexByte(data, ' ')
+		if _space == -1 {
+			return nil, InvalidObjectError
+		}
+		_mode, _err := strconv.ParseUint(string(data[:_space]), 8, 32)
+		if _err != nil {
+			return nil, InvalidObjectError
+		}
+		data = data[_space+1:]
+
+		_nul := bytes.IndexByte(data, 0)
+		if _nul == -1 || len(data) < _nul+1+hashsize {
+			return nil, InvalidObjectError
+		}
+		_name := string(data[:_nul])
+		_id := data[_nul+1 : _nul+1+hashsize]
+		data = data[_nul+1+hashsize:]
+
+		_entries[_name] = node{_mode: uint32(_mode), _id: _id}
+	}
+
+	return _entries, nil
+} // entries()
+
+// ensure revision satisfies the Repository interface, and blobs satisfies
+// the loader interface
+var _ Repository = &revision{}
+var _ loader = &blobs{}
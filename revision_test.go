@@ -0,0 +1,203 @@
+package gitignore_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestRevision(t *testing.T) {
+	// create the bare repository
+	_dir, _err := dir(map[string]string{
+		"HEAD":     "ref: refs/heads/master\n",
+		"objects/": " ",
+		"refs/":    " ",
+	})
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// populate the object database
+	_revisions, _err := revisions(_dir)
+	if _err != nil {
+		t.Fatalf("unable to create objects: %s", _err.Error())
+	}
+
+	// ensure each of the commit, tree and tag give the same results
+	for _, _revision := range _revisions {
+		_options := gitignore.Options{
+			NoGlobal: true,
+			Nested:   gitignore.NestedRepository,
+		}
+		_repository, _err := gitignore.NewRevisionWithOptions(
+			_dir, _revision, _options,
+		)
+		if _err != nil {
+			t.Fatalf("unable to open revision %s: %s", _revision, _err)
+		} else if _repository.Base() != "" {
+			t.Errorf("revision base mismatch; expected %q, got %q",
+				"", _repository.Base(),
+			)
+		} else if _repository.GitDir() != _dir {
+			t.Errorf("revision gitdir mismatch; expected %q, got %q",
+				_dir, _repository.GitDir(),
+			)
+		}
+		for _, _match := range _REVISIONMATCHES {
+			do(t, _repository.Relative, _match)
+		}
+
+		// ensure Match consults the tree for the paths of the revision
+		_match := _repository.Match("src/debug.log")
+		if _match == nil || _match.String() != "*.log" {
+			t.Errorf("revision match mismatch for %q; expected %q, got %v",
+				"src/debug.log", "*.log", _match,
+			)
+		}
+
+		// ensure the paths of the tree may be regarded as tracked
+		_options.Tracked = true
+		_repository, _err = gitignore.NewRevisionWithOptions(
+			_dir, _revision, _options,
+		)
+		if _err != nil {
+			t.Fatalf("unable to open revision %s: %s", _revision, _err)
+		}
+		for _, _match := range _REVISIONTRACKED {
+			do(t, _repository.Relative, _match)
+		}
+	}
+
+	// ensure Match reports paths that are not present in the tree
+	var _error gitignore.Error
+	_errors := func(e gitignore.Error) bool { _error = e; return true }
+	_options := gitignore.Options{NoGlobal: true, Errors: _errors}
+	_repository, _err := gitignore.NewRevisionWithOptions(
+		_dir, _revisions[0], _options,
+	)
+	if _err != nil {
+		t.Fatalf("unable to open revision %s: %s", _revisions[0], _err)
+	}
+	_match := _repository.Match("missing.log")
+	if _match != nil {
+		t.Errorf("unexpected match for missing path: %s", _match)
+	} else if _error == nil || !os.IsNotExist(_error.Underlying()) {
+		t.Errorf("missing path error mismatch; got %v", _error)
+	}
+
+	// ensure invalid revisions are rejected
+	_missing := hex.EncodeToString(hashed("blob", []byte("missing")))
+	_blob := hex.EncodeToString(hashed("blob", []byte(_REVISIONIGNORE)))
+	_tests := map[string]error{
+		_missing: gitignore.MissingObjectError,
+		_blob:    gitignore.InvalidObjectError,
+		"HEAD":   gitignore.InvalidObjectError,
+	}
+	for _revision, _expected := range _tests {
+		_, _err := gitignore.NewRevision(_dir, _revision)
+		if _err != _expected {
+			t.Errorf("revision %q error mismatch; expected %q, got %v",
+				_revision, _expected, _err,
+			)
+		}
+	}
+
+	// ensure the repository must be a git directory
+	_, _err = gitignore.NewRevision(filepath.Join(_dir, "refs"), _blob)
+	if _err != gitignore.NotRepositoryError {
+		t.Errorf("repository error mismatch; expected %q, got %v",
+			gitignore.NotRepositoryError, _err,
+		)
+	}
+} // TestRevision()
+
+func revisions(gitdir string) ([]string, error) {
+	// the root .gitignore is stored in a packfile, with the other ignore
+	// files stored as offset and reference deltas against it
+	_ignore := []byte(_REVISIONIGNORE)
+	_src := []byte(_REVISIONSRC)
+	_docs := []byte(_REVISIONDOCS)
+	_root := hashed("blob", _ignore)
+	_err := pack(gitdir, []packed{
+		{Type: 3, Data: _ignore, ID: _root},
+		{
+			Type: 6,
+			Data: deltified(_ignore, _src),
+			Base: 0,
+			ID:   hashed("blob", _src),
+		},
+		{
+			Type: 7,
+			Data: deltified(_ignore, _docs),
+			Ref:  _root,
+			ID:   hashed("blob", _docs),
+		},
+	}, false)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// the src/ tree is stored in a packfile using large offsets
+	_debug, _err := loose(gitdir, "blob", []byte("debug\n"))
+	if _err != nil {
+		return nil, _err
+	}
+	_content := tree(
+		entry{"100644", gitignore.File, hashed("blob", _src)},
+		entry{"100644", "debug.log", _debug},
+	)
+	_tree := hashed("tree", _content)
+	_err = pack(gitdir, []packed{{Type: 2, Data: _content, ID: _tree}}, true)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// the remaining objects are stored as loose objects
+	//		- vendor/lib is a submodule
+	_docstree, _err := loose(gitdir, "tree", tree(
+		entry{"100644", gitignore.File, hashed("blob", _docs)},
+	))
+	if _err != nil {
+		return nil, _err
+	}
+	_vendor, _err := loose(gitdir, "tree", tree(
+		entry{"160000", "lib", hashed("commit", []byte("submodule"))},
+	))
+	if _err != nil {
+		return nil, _err
+	}
+	_top, _err := loose(gitdir, "tree", tree(
+		entry{"100644", gitignore.File, _root},
+		entry{"40000", "docs", _docstree},
+		entry{"40000", "src", _tree},
+		entry{"40000", "vendor", _vendor},
+	))
+	if _err != nil {
+		return nil, _err
+	}
+	_commit, _err := loose(gitdir, "commit", []byte(
+		"tree "+hex.EncodeToString(_top)+"\n"+
+			"author A <a@example.com> 0 +0000\n"+
+			"committer A <a@example.com> 0 +0000\n\nrevision\n",
+	))
+	if _err != nil {
+		return nil, _err
+	}
+	_tag, _err := loose(gitdir, "tag", []byte(
+		"object "+hex.EncodeToString(_commit)+"\n"+
+			"type commit\ntag v1\n\nrevision\n",
+	))
+	if _err != nil {
+		return nil, _err
+	}
+
+	return []string{
+		hex.EncodeToString(_commit),
+		hex.EncodeToString(_top),
+		hex.EncodeToString(_tag),
+	}, nil
+} // revisions()
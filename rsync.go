@@ -0,0 +1,787 @@
+package gitignore
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RsyncMode defines how the lines of an rsync filter file are interpreted.
+type RsyncMode int
+
+// define the modes of reading rsync filter files
+const (
+	// RsyncFilter reads each line as a filter rule (e.g. "- *.o" or
+	// "dir-merge .rsync-filter"), as for the --filter option and merge files.
+	RsyncFilter RsyncMode = iota
+
+	// RsyncExcludeFrom reads each line as an exclude pattern, unless
+	// prefixed by "+ " or "- ", as for the --exclude-from option.
+	RsyncExcludeFrom
+
+	// RsyncIncludeFrom reads each line as an include pattern, unless
+	// prefixed by "+ " or "- ", as for the --include-from option.
+	RsyncIncludeFrom
+
+	// the modes of merge files with the "-" and "+" modifiers, where every
+	// line is a pattern
+	_RSYNCEXCLUDES
+	_RSYNCINCLUDES
+)
+
+// define the rsync filter rules
+const (
+	_RSYNCEXCLUDE  = '-'
+	_RSYNCINCLUDE  = '+'
+	_RSYNCMERGE    = '.'
+	_RSYNCDIRMERGE = ':'
+	_RSYNCHIDE     = 'H'
+	_RSYNCSHOW     = 'S'
+	_RSYNCPROTECT  = 'P'
+	_RSYNCRISK     = 'R'
+	_RSYNCCLEAR    = '!'
+)
+
+// _RSYNCRULES maps the long names of the rsync filter rules to their short
+// names
+var _RSYNCRULES = map[string]byte{
+	"exclude":   _RSYNCEXCLUDE,
+	"include":   _RSYNCINCLUDE,
+	"merge":     _RSYNCMERGE,
+	"dir-merge": _RSYNCDIRMERGE,
+	"hide":      _RSYNCHIDE,
+	"show":      _RSYNCSHOW,
+	"protect":   _RSYNCPROTECT,
+	"risk":      _RSYNCRISK,
+	"clear":     _RSYNCCLEAR,
+}
+
+// rsync is the implementation of a GitIgnore following the semantics of
+// rsync filter rules
+type rsync struct {
+	ignore
+	_rules  []*rsyncrule
+	_merges map[string]*rsynclist
+	_lock   sync.Mutex
+} // rsync{}
+
+// rsynclist is the list of rules read from an rsync filter file
+type rsynclist struct {
+	_rules  []*rsyncrule
+	_clear  bool
+	_perdir bool
+} // rsynclist{}
+
+// rsyncrule represents a single rule of an rsync filter file
+type rsyncrule struct {
+	_rule        byte
+	_modifiers   string
+	_string      string
+	_pattern     string
+	_root        string
+	_negate      bool
+	_directory   bool
+	_wild        bool
+	_wild2       bool
+	_wild2prefix bool
+	_wild3suffix bool
+	_slashes     int
+	_mode        RsyncMode
+	_inherit     bool
+	_position    Position
+} // rsyncrule{}
+
+// NewRsyncFilter returns a GitIgnore for the rsync filter rules read from r,
+// interpreted according to mode, for the transfer with root directory base.
+// rsync filter rules differ from .gitignore patterns:
+//
+//   - the first matching rule determines whether a path is excluded ("-")
+//     or included ("+"), and paths matched by no rule are included
+//   - a path is excluded if any of its parent directories is excluded,
+//     since rsync does not descend into excluded directories
+//   - patterns starting with "/" are anchored at the root of the transfer,
+//     patterns containing "/" match the trailing components of the path,
+//     and other patterns match the final component
+//   - "*" and "?" do not match "/", while "**" matches anything, and a
+//     trailing "dir/***" matches both "dir" and its contents
+//   - "merge file" reads the rules of another file in place, while
+//     "dir-merge file" reads the rules of the file of that name in each
+//     directory, which apply within that directory and (unless given the
+//     "n" modifier) its subdirectories
+//
+// Hide and show rules are treated as exclude and include rules, while
+// protect and risk rules, which only affect deletion, are ignored. Merge files
+// are located relative to base, or to the directory of the file containing
+// the merge rule. Nested dir-merge rules within per-directory files, and the
+// "C" and "w" modifiers, are not supported.
+//
+// If errors is given, it will be invoked for every invalid or unsupported
+// rule, which is otherwise ignored.
+func NewRsyncFilter(r io.Reader, base string, mode RsyncMode, errors func(Error) bool) GitIgnore {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	_rsync := &rsync{
+		ignore:  ignore{_base: base, _errors: _errors},
+		_merges: make(map[string]*rsynclist),
+	}
+	_list := &rsynclist{}
+	_rsync.read(r, "", base, "", mode, 0, _list)
+	_rsync._rules = _list._rules
+
+	return _rsync
+} // NewRsyncFilter()
+
+// read parses the rsync filter rules from r according to mode, with positions
+// reported against file, and appends them to list. Merge files are located
+// relative to dir, and prefix is the directory of per-directory rules,
+// relative to the root of the transfer, with a trailing "/". read returns
+// false if the error handler has requested parsing to stop.
+func (s *rsync) read(r io.Reader, file, dir, prefix string, mode RsyncMode, depth int, list *rsynclist) bool {
+	_scanner := bufio.NewScanner(r)
+	_position := Position{File: file}
+	_next := 0
+	for _scanner.Scan() {
+		_line := strings.TrimSuffix(_scanner.Text(), string(_CR))
+		_position.Line++
+		_position.Column = 1
+		_position.Offset = _next
+		_next += len([]rune(_scanner.Text())) + 1
+
+		// blank lines, and lines starting with "#" or ";" are comments
+		if _line == "" || _line[0] == byte(_COMMENT) || _line[0] == ';' {
+			continue
+		}
+
+		// extract the rule of this line
+		_rule, _err := rsyncparse(_line, mode)
+		if _err != nil {
+			if !s._errors(NewError(_err, _position)) {
+				return false
+			}
+			continue
+		}
+		_rule._position = _position
+
+		// protect and risk rules only affect deletion, while rules with
+		// the "x" modifier only apply to extended attributes
+		if strings.ContainsRune(_rule._modifiers, 'x') {
+			continue
+		}
+		switch _rule._rule {
+		case _RSYNCPROTECT, _RSYNCRISK:
+			continue
+		case _RSYNCCLEAR:
+			list._rules = nil
+			list._clear = true
+			continue
+		case _RSYNCMERGE:
+			if !s.merge(_rule, dir, prefix, depth, list) {
+				return false
+			}
+			continue
+		case _RSYNCDIRMERGE:
+			// per-directory files may not define their own per-directory
+			// files
+			if list._perdir {
+				if !s._errors(NewError(UnsupportedRuleError, _position)) {
+					return false
+				}
+				continue
+			}
+		}
+
+		// anchored patterns of per-directory files are relative to the
+		// directory of the file
+		_rule.compile(prefix, s._base)
+		list._rules = append(list._rules, _rule)
+
+		// should the merge file itself be excluded?
+		if _rule._rule == _RSYNCDIRMERGE && strings.ContainsRune(_rule._modifiers, 'e') {
+			_exclude, _ := rsyncparse(rsyncbase(_rule._pattern), _RSYNCEXCLUDES)
+			_exclude._position = _position
+			_exclude.compile("", s._base)
+			list._rules = append(list._rules, _exclude)
+		}
+	}
+
+	// were there any errors reading the file?
+	_err := _scanner.Err()
+	if _err != nil {
+		return s._errors(NewError(_err, _position))
+	}
+
+	return true
+} // read()
+
+// merge reads the rules of the merge file of rule, located relative to dir,
+// into list. merge returns false if the error handler has requested parsing
+// to stop.
+func (s *rsync) merge(rule *rsyncrule, dir, prefix string, depth int, list *rsynclist) bool {
+	if depth >= _INCLUDEDEPTH {
+		return s._errors(NewError(IncludeDepthError, rule._position))
+	}
+
+	// locate the merge file
+	_file := filepath.FromSlash(rule._pattern)
+	if !filepath.IsAbs(_file) {
+		_file = filepath.Join(dir, _file)
+	}
+	_fh, _err := os.Open(_file)
+	if _err != nil {
+		return s._errors(NewError(_err, rule._position))
+	}
+	defer _fh.Close()
+
+	// should the merge file itself be excluded?
+	if strings.ContainsRune(rule._modifiers, 'e') {
+		_exclude, _ := rsyncparse(rsyncbase(rule._pattern), _RSYNCEXCLUDES)
+		_exclude._position = rule._position
+		_exclude.compile("", s._base)
+		list._rules = append(list._rules, _exclude)
+	}
+
+	return s.read(_fh, _file, filepath.Dir(_file), prefix, rule._mode, depth+1, list)
+} // merge()
+
+// rsyncparse returns the rule represented by line, interpreted according to
+// mode. An error is returned if the rule is invalid or unsupported.
+func rsyncparse(line string, mode RsyncMode) (*rsyncrule, error) {
+	_rule := &rsyncrule{_string: line, _inherit: true}
+
+	// in the pattern modes, the rule is determined by the mode, or for the
+	// --exclude-from and --include-from modes, by a "- " or "+ " prefix
+	switch mode {
+	case _RSYNCEXCLUDES, _RSYNCINCLUDES:
+		_rule._rule = _RSYNCEXCLUDE
+		if mode == _RSYNCINCLUDES {
+			_rule._rule = _RSYNCINCLUDE
+		}
+		_rule._pattern = line
+		_rule._string = string(_rule._rule) + " " + line
+		return _rule, nil
+	case RsyncExcludeFrom, RsyncIncludeFrom:
+		switch {
+		case line == string(_RSYNCCLEAR):
+			_rule._rule = _RSYNCCLEAR
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "+ "):
+			_rule._rule = line[0]
+			_rule._pattern = line[2:]
+		default:
+			_rule._rule = _RSYNCEXCLUDE
+			if mode == RsyncIncludeFrom {
+				_rule._rule = _RSYNCINCLUDE
+			}
+			_rule._pattern = line
+			_rule._string = string(_rule._rule) + " " + line
+		}
+		if _rule._rule != _RSYNCCLEAR && _rule._pattern == "" {
+			return nil, InvalidPatternError
+		}
+		return _rule, nil
+	}
+
+	// extract the rule, given by its short or long name
+	//		- long names may be followed by "," and modifiers
+	_rest := line
+	_short := true
+	if _rest[0] >= 'a' && _rest[0] <= 'z' {
+		_end := strings.IndexAny(_rest, " ,")
+		if _end < 0 {
+			_end = len(_rest)
+		}
+		_name, _ok := _RSYNCRULES[_rest[:_end]]
+		if !_ok {
+			return nil, InvalidPatternError
+		}
+		_rule._rule = _name
+		_rest = strings.TrimPrefix(_rest[_end:], ",")
+		_short = false
+	} else {
+		_rule._rule = _rest[0]
+		if rsyncname(_rule._rule) == "" {
+			return nil, InvalidPatternError
+		}
+		_rest = _rest[1:]
+	}
+
+	// extract the modifiers, which are followed by a space, or an
+	// underscore for short names
+	_end := strings.IndexByte(_rest, ' ')
+	if _short {
+		_underscore := strings.IndexByte(_rest, '_')
+		if _underscore >= 0 && (_end < 0 || _underscore < _end) {
+			_end = _underscore
+		}
+	}
+	if _end < 0 {
+		_end = len(_rest)
+	}
+	_rule._modifiers = _rest[:_end]
+	if _end < len(_rest) {
+		_rule._pattern = _rest[_end+1:]
+	}
+
+	// the clear rule has no pattern, while every other rule requires one
+	if _rule._rule == _RSYNCCLEAR {
+		if _rule._modifiers != "" || _rule._pattern != "" {
+			return nil, InvalidPatternError
+		}
+		return _rule, nil
+	} else if _rule._pattern == "" {
+		return nil, InvalidPatternError
+	}
+
+	// validate the modifiers of the rule
+	_merge := _rule._rule == _RSYNCMERGE || _rule._rule == _RSYNCDIRMERGE
+	_rule._mode = RsyncFilter
+	for _, _modifier := range _rule._modifiers {
+		switch {
+		case _modifier == 's' || _modifier == 'r' || _modifier == 'p':
+			// sender, receiver and perishable rules apply to the
+			// transfer as a whole
+		case _modifier == 'x':
+			// extended attribute rules are skipped by read()
+		case _modifier == 'C' || _modifier == 'w':
+			return nil, UnsupportedRuleError
+		case _merge && _modifier == '-':
+			_rule._mode = _RSYNCEXCLUDES
+		case _merge && _modifier == '+':
+			_rule._mode = _RSYNCINCLUDES
+		case _merge && _modifier == 'e':
+		case _merge && _modifier == 'n':
+			_rule._inherit = false
+		case !_merge && _modifier == '!':
+			_rule._negate = true
+		case !_merge && _modifier == '/':
+		default:
+			return nil, InvalidPatternError
+		}
+	}
+
+	// hide and show rules are treated as exclude and include rules
+	switch _rule._rule {
+	case _RSYNCHIDE:
+		_rule._rule = _RSYNCEXCLUDE
+	case _RSYNCSHOW:
+		_rule._rule = _RSYNCINCLUDE
+	}
+
+	return _rule, nil
+} // rsyncparse()
+
+// rsyncname returns the long name of the rsync rule with the short name rule,
+// or the empty string if rule is not a valid rule.
+func rsyncname(rule byte) string {
+	for _name, _rule := range _RSYNCRULES {
+		if _rule == rule {
+			return _name
+		}
+	}
+	return ""
+} // rsyncname()
+
+// rsyncbase returns the final component of the merge file path.
+func rsyncbase(path string) string {
+	return path[strings.LastIndexByte(path, byte(_SEPARATOR))+1:]
+} // rsyncbase()
+
+// compile prepares the rule for matching, as rsync does when adding the rule
+// to its list. Anchored patterns of per-directory rules are prefixed with the
+// directory of the rule, given by prefix, and base is the root of the
+// transfer, used by rules with the "/" modifier.
+func (r *rsyncrule) compile(prefix, base string) {
+	if r._rule == _RSYNCDIRMERGE {
+		return
+	}
+	_pattern := r._pattern
+
+	// a trailing "/" matches only directories
+	if len(_pattern) > 1 && strings.HasSuffix(_pattern, string(_SEPARATOR)) {
+		_pattern = _pattern[:len(_pattern)-1]
+		r._directory = true
+	}
+
+	// rules with the "/" modifier match against the absolute path, while
+	// anchored per-directory rules match relative to their directory
+	if strings.ContainsRune(r._modifiers, '/') {
+		r._root = strings.TrimPrefix(filepath.ToSlash(base), string(_SEPARATOR))
+	} else if prefix != "" && strings.HasPrefix(_pattern, string(_SEPARATOR)) {
+		_pattern = string(_SEPARATOR) + prefix + _pattern[1:]
+	}
+
+	// classify the wildcards of the pattern
+	if strings.ContainsAny(_pattern, "*[?") {
+		r._wild = true
+		_index := strings.Index(_pattern, "**")
+		if _index >= 0 {
+			r._wild2 = true
+			r._wild2prefix = _index == 0
+			r._wild3suffix = strings.HasSuffix(_pattern, "***")
+		}
+	}
+	r._slashes = strings.Count(_pattern, string(_SEPARATOR))
+	r._pattern = _pattern
+} // compile()
+
+// rules returns the rules applying to the entries of the directory dir,
+// relative to the root of the transfer, with the rules of the per-directory
+// files of dir and its parent directories in place of each dir-merge rule.
+func (s *rsync) rules(dir string) []*rsyncrule {
+	_rules := make([]*rsyncrule, 0, len(s._rules))
+	for _, _rule := range s._rules {
+		if _rule._rule != _RSYNCDIRMERGE {
+			_rules = append(_rules, _rule)
+			continue
+		}
+
+		// the rules of deeper directories take precedence
+		//		- a clear rule, or the "n" modifier, prevents the rules of
+		//		  parent directories being inherited
+		_dir := dir
+		for {
+			_list := s.load(_rule, _dir)
+			_rules = append(_rules, _list._rules...)
+			if _list._clear || !_rule._inherit || _dir == "" {
+				break
+			}
+			_index := strings.LastIndexByte(_dir, byte(_SEPARATOR))
+			if _index < 0 {
+				_index = 0
+			}
+			_dir = _dir[:_index]
+		}
+	}
+
+	return _rules
+} // rules()
+
+// load returns the rules of the per-directory file of the dir-merge rule in
+// the directory dir, relative to the root of the transfer. The rules of each
+// file are read once.
+func (s *rsync) load(rule *rsyncrule, dir string) *rsynclist {
+	_dir := filepath.Join(s._base, filepath.FromSlash(dir))
+	_file := filepath.Join(_dir, filepath.FromSlash(rule._pattern))
+
+	s._lock.Lock()
+	_list, _ok := s._merges[_file]
+	if _ok {
+		s._lock.Unlock()
+		return _list
+	}
+	_list = &rsynclist{}
+	s._merges[_file] = _list
+	s._lock.Unlock()
+
+	// attempt to read the per-directory file
+	//		- a missing file has no rules
+	_fh, _err := os.Open(_file)
+	if _err != nil {
+		if !os.IsNotExist(_err) {
+			s._errors(NewError(_err, Position{File: _file}))
+		}
+		return _list
+	}
+	defer _fh.Close()
+
+	_prefix := ""
+	if dir != "" {
+		_prefix = dir + string(_SEPARATOR)
+	}
+	_read := &rsynclist{_perdir: true}
+	s.read(_fh, _file, _dir, _prefix, rule._mode, 1, _read)
+
+	s._lock.Lock()
+	*_list = *_read
+	s._lock.Unlock()
+
+	return _list
+} // load()
+
+// Match attempts to match the path against this rsync filter.
+func (s *rsync) Match(path string) Match {
+	// ensure we have the absolute path for the given file
+	_path, _err := filepath.Abs(path)
+	if _err != nil {
+		s._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	// is the path a file or a directory?
+	_info, _err := os.Stat(_path)
+	if _err != nil {
+		s._errors(NewError(_err, Position{}))
+		return nil
+	}
+
+	return s.Absolute(_path, _info.IsDir())
+} // Match()
+
+// Absolute attempts to match an absolute path against this rsync filter. If
+// the path is not located under the root of the transfer, or is not matched,
+// nil is returned.
+func (s *rsync) Absolute(path string, isdir bool) Match {
+	_rel, _err := filepath.Rel(s._base, path)
+	if _err != nil || strings.HasPrefix(_rel, "..") {
+		return nil
+	}
+
+	return s.Relative(_rel, isdir)
+} // Absolute()
+
+// Relative attempts to match a path, relative to the root of the transfer,
+// against this rsync filter. The returned Match is the first rule matching
+// the path, or the rule excluding one of its parent directories. If no rule
+// matches, nil is returned.
+func (s *rsync) Relative(path string, isdir bool) Match {
+	// the root of the transfer cannot be excluded
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return nil
+	}
+
+	// rsync descends the directories of the transfer
+	//		- the entries of an excluded directory are not considered
+	_dir := ""
+	for {
+		_index := strings.IndexByte(_path[len(_dir):], byte(_SEPARATOR))
+		_isdir := isdir
+		_entry := _path
+		if _index >= 0 {
+			_entry = _path[:len(_dir)+_index]
+			_isdir = true
+		}
+
+		// the first matching rule applies to the entry
+		var _match *rsyncrule
+		for _, _rule := range s.rules(strings.TrimSuffix(_dir, string(_SEPARATOR))) {
+			if _rule.Match(_entry, _isdir) {
+				_match = _rule
+				break
+			}
+		}
+
+		if _index < 0 {
+			if _match == nil {
+				return nil
+			}
+			return _match
+		} else if _match != nil && _match.Ignore() {
+			return _match
+		}
+		_dir = _entry + string(_SEPARATOR)
+	}
+} // Relative()
+
+// MatchAll attempts to match a path, relative to the root of the transfer,
+// against this rsync filter, returning every rule matching the path, or one
+// of its parent directories, in the order rsync considers them. The first
+// rule matching the path is decisive, unless the first rule matching one of
+// its parent directories excludes that directory.
+func (s *rsync) MatchAll(path string, isdir bool) []Trace {
+	// the root of the transfer cannot be excluded
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if _path == "." {
+		return nil
+	}
+
+	// trace the directories of the transfer, as with Relative
+	//		- the rules of the entries of an excluded directory are
+	//		  reported, but are not decisive
+	var _traces []Trace
+	_decisive := -1
+	_dir := ""
+	for {
+		_index := strings.IndexByte(_path[len(_dir):], byte(_SEPARATOR))
+		_isdir := isdir
+		_entry := _path
+		if _index >= 0 {
+			_entry = _path[:len(_dir)+_index]
+			_isdir = true
+		}
+
+		// the first matching rule applies to the entry
+		_first := -1
+		for _, _rule := range s.rules(strings.TrimSuffix(_dir, string(_SEPARATOR))) {
+			if _rule.Match(_entry, _isdir) {
+				_traces = append(_traces, Trace{Match: _rule, Path: _entry})
+				if _first == -1 {
+					_first = len(_traces) - 1
+				}
+			}
+		}
+
+		if _decisive == -1 && _first != -1 {
+			if _index < 0 || _traces[_first].Ignore() {
+				_decisive = _first
+			}
+		}
+		if _index < 0 {
+			return decide(_traces, _decisive)
+		}
+		_dir = _entry + string(_SEPARATOR)
+	}
+} // MatchAll()
+
+// Ignore returns true if the path is excluded by this rsync filter.
+func (s *rsync) Ignore(path string) bool {
+	_match := s.Match(path)
+	if _match != nil {
+		return _match.Ignore()
+	}
+
+	// we didn't match this path, so we don't ignore it
+	return false
+} // Ignore()
+
+// Include returns true if the path is included by this rsync filter.
+func (s *rsync) Include(path string) bool {
+	_match := s.Match(path)
+	if _match != nil {
+		return _match.Include()
+	}
+
+	// we didn't match this path, so we include it
+	return true
+} // Include()
+
+// Match returns true if the path, relative to the root of the transfer, is
+// matched by this rule, following the rule_matches() function of rsync.
+// dir-merge rules match no paths.
+func (r *rsyncrule) Match(path string, isdir bool) bool {
+	if r._rule == _RSYNCDIRMERGE {
+		return false
+	}
+	return r.match(path, isdir) != r._negate
+} // Match()
+
+// match returns true if the pattern of this rule matches the path.
+func (r *rsyncrule) match(path string, isdir bool) bool {
+	// patterns with no "/" or "**" match the final component of the path,
+	// while the "/" modifier matches against the absolute path
+	_name := path
+	_text := path
+	_multiple := false
+	if r._slashes == 0 && !r._wild2 {
+		_name = path[strings.LastIndexByte(path, byte(_SEPARATOR))+1:]
+		_text = _name
+	} else if r._root != "" {
+		_text = r._root + string(_SEPARATOR) + path
+		_multiple = true
+	}
+
+	// a trailing "/***" also matches the directory itself
+	if isdir {
+		if r._wild3suffix {
+			_text += string(_SEPARATOR)
+			_multiple = true
+		}
+	} else if r._directory {
+		return false
+	}
+
+	_pattern := r._pattern
+	_anchored := strings.HasPrefix(_pattern, string(_SEPARATOR))
+	if _anchored {
+		_pattern = _pattern[1:]
+	}
+
+	// where should the pattern match?
+	//		- unanchored patterns with a "/" and no "**" match the trailing
+	//		  components of the path
+	//		- unanchored patterns with an infix or trailing "**" match
+	//		  after any "/"
+	//		- otherwise patterns match the whole path or name
+	_where := 0
+	if !_anchored && r._slashes > 0 && !r._wild2 {
+		_where = r._slashes + 1
+	} else if !_anchored && !r._wild2prefix && r._wild2 {
+		_where = -1
+	}
+
+	switch {
+	case r._wild:
+		return wildarray(_pattern, _text, _where)
+	case _multiple:
+		_text, _ok := trailing(_text, _where)
+		return _ok && _text == _pattern
+	case _anchored:
+		return _name == _pattern
+	}
+
+	// literal patterns match the trailing components of the name
+	return _name == _pattern ||
+		strings.HasSuffix(_name, string(_SEPARATOR)+_pattern)
+} // match()
+
+// trailing returns the final count components of text when count is
+// positive, and otherwise text. If text has fewer than count components,
+// trailing returns false.
+func trailing(text string, count int) (string, bool) {
+	if count <= 0 {
+		return text, true
+	}
+	for _i := len(text) - 1; _i >= 0; _i-- {
+		if text[_i] == byte(_SEPARATOR) {
+			count--
+			if count == 0 {
+				return text[_i+1:], true
+			}
+		}
+	}
+
+	return text, count == 1
+} // trailing()
+
+// wildarray returns true if the wildcard pattern matches text, where text is
+// matched as described by where: a positive where matches the final where
+// components of text, a negative where matches text or any suffix of text
+// following a "/", and otherwise the whole of text is matched. Patterns are
+// matched as by the wildmatch() function of rsync, where "*" and "?" do not
+// match "/", and "**" matches any characters.
+func wildarray(pattern, text string, where int) bool {
+	_text, _ok := trailing(text, where)
+	if !_ok {
+		return false
+	}
+
+	_flags := WM_PATHNAME | _WMRSYNC
+	_matched := wildmatch(pattern, _text, _flags)
+	if _matched != _WMMATCH && where < 0 && _matched != _WMABORTALL {
+		for _i := 0; _i < len(_text); _i++ {
+			if _text[_i] != byte(_SEPARATOR) {
+				continue
+			}
+			_matched = wildmatch(pattern, _text[_i+1:], _flags)
+			if _matched != _WMNOMATCH && _matched != _WMABORTTOSTARSTAR {
+				break
+			}
+		}
+	}
+
+	return _matched == _WMMATCH
+} // wildarray()
+
+// Ignore returns true if the rule excludes the paths it matches.
+func (r *rsyncrule) Ignore() bool { return r._rule == _RSYNCEXCLUDE }
+
+// Include returns true if the rule includes the paths it matches.
+func (r *rsyncrule) Include() bool { return r._rule == _RSYNCINCLUDE }
+
+// String returns the rule as written in the filter file, with the rule
+// given explicitly for lines of exclude and include files.
+func (r *rsyncrule) String() string { return r._string }
+
+// Position returns the position of the rule within its filter file.
+func (r *rsyncrule) Position() Position { return r._position }
+
+// ensure rsync satisfies the GitIgnore interface, and rsyncrule satisfies
+// the Pattern interface
+var _ GitIgnore = &rsync{}
+var _ Pattern = &rsyncrule{}
@@ -0,0 +1,123 @@
+package gitignore_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestRsyncFilter(t *testing.T) {
+	// create the transfer for the rsync tests
+	_dir, _err := dir(_RSYNCTRANSFER)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// record the errors encountered parsing the filter rules
+	_errors := make([]gitignore.Error, 0)
+	_reader := strings.NewReader(_RSYNCFILTER)
+	_filter := gitignore.NewRsyncFilter(
+		_reader, _dir, gitignore.RsyncFilter,
+		func(e gitignore.Error) bool {
+			_errors = append(_errors, e)
+			return true
+		},
+	)
+
+	// ensure the paths are matched as rsync would
+	for _, _match := range _RSYNCMATCHES {
+		do(t, _filter.Relative, _match)
+	}
+
+	// ensure the invalid and unsupported rules were reported
+	_expected := []struct {
+		Error error
+		Line  int
+	}{
+		{gitignore.InvalidPatternError, 13},
+		{gitignore.UnsupportedRuleError, 14},
+	}
+	if len(_errors) != len(_expected) {
+		t.Fatalf("error count mismatch; expected %d, got %d",
+			len(_expected), len(_errors),
+		)
+	}
+	for _i, _e := range _expected {
+		if _errors[_i].Underlying() != _e.Error {
+			t.Errorf("error mismatch; expected %q, got %q",
+				_e.Error, _errors[_i].Underlying(),
+			)
+		} else if _errors[_i].Position().Line != _e.Line {
+			t.Errorf("error position mismatch; expected line %d, got %s",
+				_e.Line, _errors[_i].Position(),
+			)
+		}
+	}
+
+	// ensure the lines of an exclude file default to exclude rules
+	_reader = strings.NewReader(_RSYNCEXCLUDE)
+	_filter = gitignore.NewRsyncFilter(
+		_reader, _dir, gitignore.RsyncExcludeFrom, nil,
+	)
+	for _, _match := range _RSYNCEXCLUDEMATCHES {
+		do(t, _filter.Relative, _match)
+	}
+} // TestRsyncFilter()
+
+func TestExportRsync(t *testing.T) {
+	// create the repository to export
+	_dir, _err := dir(_RSYNCREPOSITORY)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	_options := gitignore.Options{NoGlobal: true}
+	_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _dir)
+	}
+
+	// ensure the repository is exported as expected
+	_buffer := &bytes.Buffer{}
+	_err = gitignore.ExportRsync(_buffer, _repository)
+	if _err != nil {
+		t.Fatalf("unable to export repository: %s", _err.Error())
+	} else if _buffer.String() != _RSYNCEXPORT {
+		t.Fatalf("export mismatch; expected %q, got %q",
+			_RSYNCEXPORT, _buffer.String(),
+		)
+	}
+
+	// ensure rsync would exclude the paths ignored by the repository
+	_filter := gitignore.NewRsyncFilter(
+		_buffer, _dir, gitignore.RsyncFilter, nil,
+	)
+	for _, _path := range _RSYNCEXPORTPATHS {
+		_test := match{Path: _path}
+		_expected := _repository.Relative(_test.Local(), _test.IsDir())
+		_match := _filter.Relative(_test.Local(), _test.IsDir())
+		_ignored := _expected != nil && _expected.Ignore()
+		_excluded := _match != nil && _match.Ignore()
+		if _ignored != _excluded {
+			t.Errorf("export mismatch for %q; ignored %v, excluded %v by %v",
+				_path, _ignored, _excluded, _match,
+			)
+		}
+	}
+
+	// ensure unsupported GitIgnore instances are reported
+	_docker := gitignore.NewDockerIgnore(
+		strings.NewReader("*.log\n"), _dir, gitignore.DockerOptions{},
+	)
+	_err = gitignore.ExportRsync(_buffer, _docker)
+	if _err != gitignore.UnsupportedIgnoreError {
+		t.Errorf("export error mismatch; expected %q, got %v",
+			gitignore.UnsupportedIgnoreError, _err,
+		)
+	}
+} // TestExportRsync()
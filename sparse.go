@@ -0,0 +1,272 @@
+package gitignore
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// define the fixed patterns of a cone mode sparse checkout
+const (
+	_CONEROOT    = "/*"
+	_CONEROOTDIR = "!/*/"
+)
+
+// SparseCheckout is the interface to the sparse checkout patterns of a
+// repository (i.e. $GIT_DIR/info/sparse-checkout), describing the paths
+// included in the work tree of the repository.
+type SparseCheckout interface {
+	// Include returns true if the path, relative to the root of the work
+	// tree, is included in the sparse checkout. isdir is used to indicate
+	// whether the path represents a file or a directory.
+	Include(path string, isdir bool) bool
+
+	// Cone returns true if the sparse checkout uses cone mode, where the
+	// patterns describe sets of directories rather than arbitrary paths.
+	Cone() bool
+} // SparseCheckout{}
+
+// sparse is the implementation of a SparseCheckout
+type sparse struct {
+	_all       bool
+	_cone      bool
+	_ignore    *ignore
+	_recursive map[string]bool
+	_parent    map[string]bool
+} // sparse{}
+
+// NewSparseCheckout returns a SparseCheckout for the sparse checkout patterns
+// read from r. If cone is true, the patterns are interpreted in cone mode,
+// where "/dir/" includes dir recursively, and "!/dir/*/" restricts dir to
+// the files it contains directly. Files in the root of the work tree are
+// always included in cone mode. If the patterns are not valid cone mode
+// patterns, they are interpreted in non-cone mode, as git does.
+//
+// In non-cone mode, the patterns use the gitignore syntax, with the sense of
+// each pattern reversed: a path is included if it is matched by a pattern,
+// and excluded if it is matched by a negated pattern. As with git, a path
+// that is not matched by any pattern takes the inclusion of its parent
+// directory, with the root of the work tree excluded.
+//
+// If errors is given, it will be invoked for every error encountered when
+// parsing the sparse checkout patterns.
+func NewSparseCheckout(r io.Reader, cone bool, errors func(Error) bool) SparseCheckout {
+	// do we have an error handler?
+	_errors := errors
+	if _errors == nil {
+		_errors = func(e Error) bool { return true }
+	}
+
+	// extract the patterns from the reader
+	_parser := NewParser(r, _errors)
+	_patterns := _parser.Parse()
+	_ignore := &ignore{
+		_pattern: _patterns,
+		_lookup:  newLookup(_patterns, false),
+		_errors:  _errors,
+	}
+	_sparse := &sparse{_ignore: _ignore}
+
+	// attempt to interpret the patterns in cone mode
+	if cone {
+		_sparse._cone = _sparse.cone(_patterns)
+	}
+
+	return _sparse
+} // NewSparseCheckout()
+
+// NewSparseCheckoutFromFile returns a SparseCheckout for the sparse checkout
+// patterns in file, interpreted in cone mode if cone is true. An error will
+// be returned if file cannot be opened.
+func NewSparseCheckoutFromFile(file string, cone bool) (SparseCheckout, error) {
+	_fh, _err := os.Open(file)
+	if _err != nil {
+		return nil, _err
+	}
+	defer _fh.Close()
+
+	return NewSparseCheckout(_fh, cone, nil), nil
+} // NewSparseCheckoutFromFile()
+
+// NewSparseCheckoutFromGitDir returns the SparseCheckout of the repository
+// with the given gitdir, read from $GIT_DIR/info/sparse-checkout. Cone mode
+// is determined by the core.sparseCheckoutCone configuration variable. If
+// the core.sparseCheckout configuration variable is not set, or there is no
+// sparse-checkout file, the work tree of the repository is not sparse, and
+// the returned SparseCheckout includes every path. The configuration is
+// read from the common git directory of gitdir, and from the
+// $GIT_DIR/config.worktree of gitdir if extensions.worktreeConfig is
+// enabled, as "git sparse-checkout" does.
+func NewSparseCheckoutFromGitDir(gitdir string) (SparseCheckout, error) {
+	_config, _err := newConfig(gitdir)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// is this a sparse checkout?
+	_enabled, _ := _config.bool("core.sparsecheckout")
+	if !_enabled {
+		return &sparse{_all: true}, nil
+	}
+
+	// the sparse-checkout file is specific to each work tree, so it is
+	// not located in the common git directory
+	_cone, _ := _config.bool("core.sparsecheckoutcone")
+	_file := filepath.Join(gitdir, "info", "sparse-checkout")
+	_sparse, _err := NewSparseCheckoutFromFile(_file, _cone)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return &sparse{_all: true}, nil
+		}
+		return nil, _err
+	}
+
+	return _sparse, nil
+} // NewSparseCheckoutFromGitDir()
+
+// Cone returns true if the sparse checkout uses cone mode.
+func (s *sparse) Cone() bool { return s._cone }
+
+// Include returns true if the path, relative to the root of the work tree,
+// is included in the sparse checkout.
+func (s *sparse) Include(path string, isdir bool) bool {
+	// if we have no path, then we have the root of the work tree
+	_path := filepath.ToSlash(filepath.Clean(path))
+	if s._all || _path == "." {
+		return true
+	}
+	_path = strings.TrimPrefix(_path, "/")
+
+	// are we in cone mode?
+	if s._cone {
+		return s.included(_path, isdir)
+	}
+
+	// in non-cone mode, the inclusion of each directory is inherited by
+	// its contents, unless overridden by a pattern
+	//		- the root of the work tree is excluded
+	_include := false
+	_parts := strings.Split(_path, string(_SEPARATOR))
+	for _i := range _parts {
+		_prefix := strings.Join(_parts[:_i+1], string(_SEPARATOR))
+		_isdir := isdir || _i < len(_parts)-1
+		_match := s._ignore.Relative(_prefix, _isdir)
+		if _match != nil {
+			_include = _match.Ignore()
+		}
+	}
+
+	return _include
+} // Include()
+
+// included returns true if the path is included by the cone mode sparse
+// checkout.
+func (s *sparse) included(path string, isdir bool) bool {
+	// files in the root of the work tree are always included
+	_parts := strings.Split(path, string(_SEPARATOR))
+	if len(_parts) == 1 && !isdir {
+		return true
+	}
+
+	// is this path located within a recursive directory?
+	_last := len(_parts)
+	if !isdir {
+		_last--
+	}
+	for _i := 1; _i <= _last; _i++ {
+		if s._recursive[strings.Join(_parts[:_i], string(_SEPARATOR))] {
+			return true
+		}
+	}
+
+	// files are included if they are located directly within a parent
+	// directory
+	if !isdir {
+		return s._parent[strings.Join(_parts[:_last], string(_SEPARATOR))]
+	}
+
+	// directories are included if they are parent directories, or if they
+	// contain included directories
+	if s._parent[path] {
+		return true
+	}
+	_prefix := path + string(_SEPARATOR)
+	for _, _set := range []map[string]bool{s._recursive, s._parent} {
+		for _dir := range _set {
+			if strings.HasPrefix(_dir, _prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+} // included()
+
+// cone attempts to interpret the patterns as cone mode patterns, returning
+// true if successful. Each pattern must either be one of the fixed patterns
+// "/*" and "!/*/", name a recursive directory (i.e. "/dir/"), or restrict
+// a previously named directory to its immediate files (i.e. "!/dir/*/").
+func (s *sparse) cone(patterns []Pattern) bool {
+	_recursive := make(map[string]bool)
+	_parent := make(map[string]bool)
+	for _, _pattern := range patterns {
+		_string := _pattern.String()
+		switch {
+		case _string == _CONEROOT || _string == _CONEROOTDIR:
+			continue
+
+		// restrict a directory to its immediate files
+		case strings.HasPrefix(_string, "!/") &&
+			strings.HasSuffix(_string, "/*/"):
+			_dir, _ok := literal(_string[2 : len(_string)-3])
+			if !_ok || !_recursive[_dir] {
+				return false
+			}
+			delete(_recursive, _dir)
+			_parent[_dir] = true
+
+		// include a directory recursively
+		case strings.HasPrefix(_string, "/") &&
+			strings.HasSuffix(_string, "/") && len(_string) > 2:
+			_dir, _ok := literal(_string[1 : len(_string)-1])
+			if !_ok {
+				return false
+			}
+			_recursive[_dir] = true
+
+		// any other pattern is not a cone mode pattern
+		default:
+			return false
+		}
+	}
+
+	s._recursive = _recursive
+	s._parent = _parent
+	return true
+} // cone()
+
+// literal returns the unescaped directory named by the cone mode pattern
+// dir, and true if dir contains no wildcards.
+func literal(dir string) (string, bool) {
+	_literal := make([]rune, 0, len(dir))
+	_escaped := false
+	for _, _r := range dir {
+		switch {
+		case _escaped:
+			_literal = append(_literal, _r)
+			_escaped = false
+		case _r == _ESCAPE:
+			_escaped = true
+		case _r == _WILDCARD || _r == '?' || _r == '[':
+			return "", false
+		default:
+			_literal = append(_literal, _r)
+		}
+	}
+
+	return string(_literal), !_escaped
+} // literal()
+
+// ensure sparse satisfies the SparseCheckout interface
+var _ SparseCheckout = &sparse{}
@@ -0,0 +1,185 @@
+package gitignore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestSparseCheckout(t *testing.T) {
+	// define the sparse checkout tests
+	_tests := []struct {
+		Patterns string
+		Cone     bool
+		Expected bool
+		Includes []inclusion
+	}{
+		{_SPARSECONE, true, true, _SPARSECONEINCLUDES},
+		{_SPARSECONE, false, false, _SPARSECONEDISABLED},
+		{_SPARSENONCONE, false, false, _SPARSENONCONEINCLUDES},
+		// non-cone patterns disable cone mode
+		{_SPARSENONCONE, true, false, _SPARSENONCONEINCLUDES},
+	}
+
+	for _, _test := range _tests {
+		_reader := strings.NewReader(_test.Patterns)
+		_sparse := gitignore.NewSparseCheckout(_reader, _test.Cone, nil)
+		if _sparse.Cone() != _test.Expected {
+			t.Errorf("sparse checkout cone mismatch for %q; expected %v, got %v",
+				_test.Patterns, _test.Expected, _sparse.Cone(),
+			)
+		}
+		included(t, _sparse, _test.Includes)
+	}
+} // TestSparseCheckout()
+
+func TestSparseCheckoutFromGitDir(t *testing.T) {
+	// create the repository with the sparse checkout configuration
+	_dir, _err := dir(map[string]string{
+		".git/config": "[core]\n\tsparseCheckout = true\n" +
+			"\tsparseCheckoutCone = true\n",
+		".git/info/sparse-checkout": _SPARSECONE,
+	})
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// ensure the sparse checkout is read from the repository
+	_gitdir := filepath.Join(_dir, ".git")
+	_sparse, _err := gitignore.NewSparseCheckoutFromGitDir(_gitdir)
+	if _err != nil {
+		t.Fatalf("unable to read sparse checkout: %s", _err.Error())
+	} else if !_sparse.Cone() {
+		t.Errorf("sparse checkout cone mismatch; expected cone mode")
+	}
+	included(t, _sparse, _SPARSECONEINCLUDES)
+
+	// ensure every path is included if sparse checkout is disabled
+	_config := filepath.Join(_gitdir, "config")
+	_err = ioutil.WriteFile(_config, []byte("[core]\n\tbare = false\n"), _GITMASK)
+	if _err != nil {
+		t.Fatalf("unable to write configuration: %s", _err.Error())
+	}
+	_sparse, _err = gitignore.NewSparseCheckoutFromGitDir(_gitdir)
+	if _err != nil {
+		t.Fatalf("unable to read sparse checkout: %s", _err.Error())
+	}
+	for _, _include := range _SPARSECONEINCLUDES {
+		if !_sparse.Include(_include.Local(), _include.IsDir()) {
+			t.Errorf("sparse checkout excludes %q when disabled",
+				_include.Path,
+			)
+		}
+	}
+} // TestSparseCheckoutFromGitDir()
+
+func TestSparseCheckoutFromGit(t *testing.T) {
+	if _, _err := exec.LookPath("git"); _err != nil {
+		t.Skip("git not found: skipping sparse checkout tests")
+	}
+
+	// create the repository and the directory of its linked worktree
+	_dir, _err := dir(_SPARSETREE)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+	_linked, _err := ioutil.TempDir("", "gitignore-sparse-")
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_linked)
+	_worktree := filepath.Join(_linked, "worktree")
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// sparse returns the sparse checkout of gitdir, ensuring its cone mode
+	// is as expected
+	sparse := func(gitdir string, cone bool) gitignore.SparseCheckout {
+		_sparse, _err := gitignore.NewSparseCheckoutFromGitDir(gitdir)
+		if _err != nil {
+			t.Fatalf("unable to read sparse checkout: %s", _err.Error())
+		} else if _sparse.Cone() != cone {
+			t.Errorf("sparse checkout cone mismatch for %q; expected %v, got %v",
+				gitdir, cone, _sparse.Cone(),
+			)
+		}
+		return _sparse
+	}
+
+	// commit the work tree, so git may create the sparse checkouts
+	//		- git sparse-checkout writes the configuration to
+	//		  config.worktree
+	_gitdir := filepath.Join(_dir, ".git")
+	_cone := append([]string{"sparse-checkout", "set", "--cone"}, _SPARSEGITCONE...)
+	_noncone := []string{"sparse-checkout", "set", "--no-cone"}
+	_noncone = append(_noncone, strings.Fields(_SPARSENONCONE)...)
+	for _, _args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com",
+			"commit", "--quiet", "--message", "sparse"},
+		_cone,
+	} {
+		if _, _err := git(_dir, "", _args...); _err != nil {
+			t.Fatalf("unable to create repository: %s", _err.Error())
+		}
+	}
+	included(t, sparse(_gitdir, true), _SPARSEGITCONEINCLUDES)
+
+	// ensure non-cone mode is read from the repository
+	if _, _err := git(_dir, "", _noncone...); _err != nil {
+		t.Fatalf("unable to set sparse checkout: %s", _err.Error())
+	}
+	included(t, sparse(_gitdir, false), _SPARSENONCONEINCLUDES)
+
+	// ensure the linked worktree has its own sparse checkout
+	//		- the configuration of the repository is shared, while
+	//		  config.worktree and info/sparse-checkout are not
+	for _, _args := range [][]string{
+		{"worktree", "add", "--quiet", _worktree},
+		append([]string{"-C", _worktree}, _cone...),
+	} {
+		if _, _err := git(_dir, "", _args...); _err != nil {
+			t.Fatalf("unable to create worktree: %s", _err.Error())
+		}
+	}
+	_linkedgitdir := filepath.Join(_gitdir, "worktrees", "worktree")
+	included(t, sparse(_linkedgitdir, true), _SPARSEGITCONEINCLUDES)
+	included(t, sparse(_gitdir, false), _SPARSENONCONEINCLUDES)
+} // TestSparseCheckoutFromGit()
+
+func included(t *testing.T, sparse gitignore.SparseCheckout, i []inclusion) {
+	for _, _include := range i {
+		_included := sparse.Include(_include.Local(), _include.IsDir())
+		if _included != _include.Include {
+			t.Errorf("sparse checkout mismatch for %q; expected %v, got %v",
+				_include.Path, _include.Include, _included,
+			)
+		}
+	}
+} // included()
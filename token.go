@@ -7,6 +7,8 @@ import (
 // Token represents a parsed token from a .gitignore stream, encapsulating the
 // token type, the runes comprising the token, and the position within the
 // stream of the first rune of the token.
+// Bytes of the stream that are not valid UTF-8 are represented by the runes
+// U+DC80 to U+DCFF, so that the Token retains the bytes of the stream.
 type Token struct {
 	Type TokenType
 	Word []rune
@@ -31,11 +33,18 @@ func (t *Token) Name() string {
 	return t.Type.String()
 } // Name()
 
-// Token returns the string representation of the Token word.
+// Token returns the string representation of the Token word. Bytes of the
+// input stream that are not valid UTF-8 are returned unchanged, so that the
+// string is identical to the text of the token in the input stream.
 func (t *Token) Token() string {
-	return string(t.Word)
+	return rawstring(t.Word)
 } // Token()
 
+// Bytes returns the bytes of the Token word, as read from the input stream.
+func (t *Token) Bytes() []byte {
+	return []byte(t.Token())
+} // Bytes()
+
 // String returns a string representation of the Token, encapsulating its
 // position in the input stream, its name (i.e. type), and its runes.
 func (t *Token) String() string {
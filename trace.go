@@ -0,0 +1,42 @@
+package gitignore
+
+// Trace represents a pattern matching a path, as reported by MatchAll. The
+// Match of the Trace is the matching pattern, and may be queried to determine
+// whether the pattern ignores or includes the path, and to locate the
+// pattern within its ignore file.
+type Trace struct {
+	Match
+
+	// Path is the path matched by the pattern, relative to the base
+	// directory of the GitIgnore, using "/" separators. Where the pattern
+	// matched a parent directory of the traced path (i.e. the parent
+	// directory is excluded), Path is that parent directory.
+	Path string
+
+	// Decisive is true if the pattern determined whether the traced path is
+	// ignored or included (i.e. the Match returned by Relative for the
+	// traced path).
+	Decisive bool
+} // Trace{}
+
+// decide returns the traces with only the trace at index marked as
+// decisive. If index is negative, no trace is decisive.
+func decide(traces []Trace, index int) []Trace {
+	for _i := range traces {
+		traces[_i].Decisive = _i == index
+	}
+
+	return traces
+} // decide()
+
+// decisive returns the index of the decisive trace, or -1 if no trace is
+// decisive.
+func decisive(traces []Trace) int {
+	for _i, _trace := range traces {
+		if _trace.Decisive {
+			return _i
+		}
+	}
+
+	return -1
+} // decisive()
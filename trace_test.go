@@ -0,0 +1,246 @@
+package gitignore_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestMatchAll(t *testing.T) {
+	_reader := strings.NewReader(_TRACEIGNORE)
+	_ignore := gitignore.New(_reader, "/base", nil)
+
+	// ensure every matching pattern is reported
+	for _, _test := range _TRACEMATCHES {
+		traced(t, _ignore, "", _test)
+	}
+} // TestMatchAll()
+
+func TestRepositoryMatchAll(t *testing.T) {
+	// create the repository for the MatchAll tests
+	_dir, _err := dir(_TRACEREPOSITORY)
+	if _err != nil {
+		t.Fatalf("unable to create temporary directory: %s", _err.Error())
+	}
+	defer os.RemoveAll(_dir)
+
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// ensure the patterns of every ignore file, and of the excluded parent
+	// directories, are reported
+	_options := gitignore.Options{NoGlobal: true}
+	_repository := gitignore.NewRepositoryWithOptions(_dir, _options)
+	if _repository == nil {
+		t.Fatalf("unable to create repository for %q", _dir)
+	}
+	for _, _test := range _TRACEREPOSITORYMATCHES {
+		traced(t, _repository, _dir, _test)
+	}
+} // TestRepositoryMatchAll()
+
+func TestDecisive(t *testing.T) {
+	// ensure the tests are not influenced by the user configuration
+	_restore, _err := setenv(map[string]string{
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+		"GIT_DIR":             "",
+		"GIT_INDEX_FILE":      "",
+	})
+	if _err != nil {
+		t.Fatalf("unable to set environment: %s", _err.Error())
+	}
+	defer _restore()
+
+	// repository returns the function opening the repository of a work
+	// tree with the given options
+	repository := func(options gitignore.Options) func(string) (gitignore.GitIgnore, error) {
+		options.NoGlobal = true
+		return func(dir string) (gitignore.GitIgnore, error) {
+			return gitignore.NewRepositoryWithOptions(dir, options), nil
+		}
+	}
+
+	// define the GitIgnore instances, and the paths whose decisive trace
+	// is compared with their match
+	type decisivetest struct {
+		Name    string
+		Files   map[string]string
+		Open    func(dir string) (gitignore.GitIgnore, error)
+		Matches []match
+	}
+	_tests := []decisivetest{
+		{"gitignore", nil, func(string) (gitignore.GitIgnore, error) {
+			_reader := strings.NewReader(_GITMATCH)
+			return gitignore.New(_reader, _GITBASE, nil), nil
+		}, _GITMATCHES},
+		{"dockerignore", nil, func(string) (gitignore.GitIgnore, error) {
+			_reader := strings.NewReader(_DOCKERIGNORE)
+			_options := gitignore.DockerOptions{}
+			return gitignore.NewDockerIgnore(_reader, "/context", _options), nil
+		}, _DOCKERMATCHES},
+		{"rsync", _RSYNCTRANSFER, func(dir string) (gitignore.GitIgnore, error) {
+			_reader := strings.NewReader(_RSYNCFILTER)
+			_filter := gitignore.RsyncFilter
+			return gitignore.NewRsyncFilter(_reader, dir, _filter, nil), nil
+		}, _RSYNCMATCHES},
+		{"npm", _NPMPACKAGE, func(dir string) (gitignore.GitIgnore, error) {
+			return gitignore.NewNpmPackage(dir, nil)
+		}, _NPMMATCHES},
+		{"tracked", map[string]string{
+			gitignore.File: _INDEXIGNORE,
+			".git/index":   string(indexed(4, _INDEXPATHS, nil)),
+		}, repository(gitignore.Options{Tracked: true}), _INDEXMATCHES},
+		{"nested", _GITNESTED, repository(gitignore.Options{
+			Nested: gitignore.NestedRepository,
+		}), _NESTEDMATCHES},
+		{"boundary", _GITNESTED, repository(gitignore.Options{
+			Nested: gitignore.NestedBoundary,
+		}), _NESTEDBOUNDARIES},
+	}
+
+	// the Mercurial tests record the pattern of each path
+	_hgmatches := make([]match, 0, len(_HGMATCHES))
+	for _, _test := range _HGMATCHES {
+		_hgmatches = append(_hgmatches, match{Path: _test.Path})
+	}
+	_tests = append(_tests, decisivetest{"hgignore", _HGREPOSITORY,
+		func(dir string) (gitignore.GitIgnore, error) {
+			_file := filepath.Join(dir, gitignore.HgIgnoreFile)
+			return gitignore.NewHgIgnoreFromFile(_file, nil)
+		}, _hgmatches,
+	})
+
+	// include the repositories of the conformance tests
+	for _i, _test := range _CONFORMANCE {
+		_tests = append(_tests, decisivetest{
+			fmt.Sprintf("conformance %d", _i),
+			map[string]string{gitignore.File: _test.Ignore},
+			repository(gitignore.Options{}),
+			_test.Matches,
+		})
+	}
+
+	// ensure the decisive trace of each path is its match
+	for _, _test := range _tests {
+		_dir := ""
+		if _test.Files != nil {
+			_dir, _err = dir(_test.Files)
+			if _err != nil {
+				t.Fatalf("unable to create temporary directory: %s", _err.Error())
+			}
+			defer os.RemoveAll(_dir)
+		}
+
+		_ignore, _err := _test.Open(_dir)
+		if _err != nil {
+			t.Fatalf("%s: unable to create GitIgnore: %s", _test.Name, _err.Error())
+		} else if _ignore == nil {
+			t.Fatalf("%s: unable to create GitIgnore", _test.Name)
+		}
+		for _, _match := range _test.Matches {
+			decided(t, _ignore, _match)
+		}
+	}
+} // TestDecisive()
+
+func traced(t *testing.T, ignore gitignore.GitIgnore, base string, test tracetest) {
+	// attempt to match this path
+	_local := match{Path: test.Path}.Local()
+	_isdir := match{Path: test.Path}.IsDir()
+	_traces := ignore.MatchAll(_local, _isdir)
+	if len(_traces) != len(test.Traces) {
+		t.Errorf(
+			"trace count mismatch for %q; expected %d, got %d",
+			test.Path, len(test.Traces), len(_traces),
+		)
+		return
+	}
+
+	// ensure each trace is as expected
+	for _i, _trace := range _traces {
+		_expected := test.Traces[_i]
+		_file := _trace.Position().File
+		if _file != "" {
+			_rel, _err := filepath.Rel(base, _file)
+			if _err != nil {
+				t.Fatalf("unable to determine relative path of %q", _file)
+			}
+			_file = filepath.ToSlash(_rel)
+		}
+
+		switch {
+		case _trace.Path != _expected.Path:
+			t.Errorf(
+				"trace %d path mismatch for %q; expected %q, got %q",
+				_i, test.Path, _expected.Path, _trace.Path,
+			)
+		case _trace.String() != _expected.Pattern:
+			t.Errorf(
+				"trace %d pattern mismatch for %q; expected %q, got %q",
+				_i, test.Path, _expected.Pattern, _trace.String(),
+			)
+		case _file != _expected.File:
+			t.Errorf(
+				"trace %d file mismatch for %q; expected %q, got %q",
+				_i, test.Path, _expected.File, _file,
+			)
+		case _trace.Decisive != _expected.Decisive:
+			t.Errorf(
+				"trace %d decisive mismatch for %q; expected %v, got %v",
+				_i, test.Path, _expected.Decisive, _trace.Decisive,
+			)
+		}
+	}
+
+	// the decisive trace must be the match of the path
+	decided(t, ignore, match{Path: test.Path})
+} // traced()
+
+func decided(t *testing.T, ignore gitignore.GitIgnore, m match) {
+	// find the decisive trace of this path
+	var _decisive gitignore.Match
+	for _, _trace := range ignore.MatchAll(m.Local(), m.IsDir()) {
+		if !_trace.Decisive {
+			continue
+		} else if _decisive != nil {
+			t.Errorf("multiple decisive traces for %q", m.Path)
+			return
+		}
+		_decisive = _trace.Match
+	}
+
+	// ensure the decisive trace is the match
+	_match := ignore.Relative(m.Local(), m.IsDir())
+	if _match == nil && _decisive == nil {
+		return
+	} else if _match == nil {
+		t.Errorf(
+			"unexpected decisive trace %q for %q; expected no match",
+			_decisive.String(), m.Path,
+		)
+	} else if _decisive == nil {
+		t.Errorf(
+			"no decisive trace for %q; expected %q",
+			m.Path, _match.String(),
+		)
+	} else if _decisive.String() != _match.String() ||
+		!coincident(_decisive.Position(), _match.Position()) {
+		t.Errorf(
+			"decisive trace mismatch for %q; expected %q at %s, got %q at %s",
+			m.Path, _match.String(), pos(_match.Position()),
+			_decisive.String(), pos(_decisive.Position()),
+		)
+	}
+} // decided()
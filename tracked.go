@@ -0,0 +1,35 @@
+package gitignore
+
+// Tracked is the Match returned by a repository configured to consult its
+// index (see Options.Tracked) for paths tracked by the index. A Tracked path
+// is never ignored.
+type Tracked interface {
+	Match
+
+	// Path returns the tracked path, relative to the root of the work tree.
+	Path() string
+} // Tracked{}
+
+// tracked is the implementation of a Tracked Match
+type tracked struct {
+	_path string
+} // tracked{}
+
+// Ignore returns false, since tracked paths are never ignored.
+func (t *tracked) Ignore() bool { return false }
+
+// Include returns true, since tracked paths are never ignored.
+func (t *tracked) Include() bool { return true }
+
+// String returns the tracked path.
+func (t *tracked) String() string { return t._path }
+
+// Position returns the zero Position, since a Tracked Match is not defined by
+// a pattern.
+func (t *tracked) Position() Position { return Position{} }
+
+// Path returns the tracked path, relative to the root of the work tree.
+func (t *tracked) Path() string { return t._path }
+
+// ensure tracked satisfies the Tracked interface
+var _ Tracked = &tracked{}
@@ -2,11 +2,17 @@ package gitignore_test
 
 import (
 	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/denormal/go-gitignore"
@@ -165,3 +171,339 @@ func null() gitignore.GitIgnore {
 	// return an empty GitIgnore instance
 	return gitignore.New(bytes.NewBuffer(nil), "", nil)
 } // null()
+
+func setenv(vars map[string]string) (func(), error) {
+	// record the current values of the environment variables
+	_previous := make(map[string]*string)
+	_restore := func() {
+		for _k, _v := range _previous {
+			if _v == nil {
+				os.Unsetenv(_k)
+			} else {
+				os.Setenv(_k, *_v)
+			}
+		}
+	}
+
+	// set the new values of the variables
+	//		- the empty string unsets the variable
+	for _k, _v := range vars {
+		if _value, _ok := os.LookupEnv(_k); _ok {
+			_previous[_k] = &_value
+		} else {
+			_previous[_k] = nil
+		}
+
+		var _err error
+		if _v == "" {
+			_err = os.Unsetenv(_k)
+		} else {
+			_err = os.Setenv(_k, _v)
+		}
+		if _err != nil {
+			_restore()
+			return nil, _err
+		}
+	}
+
+	// return the function to restore the environment
+	return _restore, nil
+} // setenv()
+
+func indexed(version int, paths []string, extension []byte) []byte {
+	// create the index header
+	_index := []byte("DIRC")
+	_index = binary.BigEndian.AppendUint32(_index, uint32(version))
+	_index = binary.BigEndian.AppendUint32(_index, uint32(len(paths)))
+
+	// add the index entries
+	//		- sparse directory entries have a trailing "/"
+	_previous := ""
+	for _, _path := range paths {
+		_entry := make([]byte, 40+20+2)
+		_mode := uint32(0100644)
+		if strings.HasSuffix(_path, "/") {
+			_mode = 040000
+		}
+		binary.BigEndian.PutUint32(_entry[24:], _mode)
+		binary.BigEndian.PutUint16(_entry[60:], uint16(len(_path)))
+
+		// version 3 indexes include the extended flags
+		if version == 3 {
+			_entry[60] |= 0x40
+			_entry = append(_entry, 0, 0)
+		}
+
+		// version 4 indexes use prefix compression of entry names
+		if version == 4 {
+			_common := 0
+			for _common < len(_previous) && _common < len(_path) &&
+				_previous[_common] == _path[_common] {
+				_common++
+			}
+			_entry = append(_entry, varint(len(_previous)-_common)...)
+			_entry = append(_entry, _path[_common:]...)
+			_entry = append(_entry, 0)
+		} else {
+			_length := (len(_entry) + len(_path) + 8) &^ 7
+			_entry = append(_entry, _path...)
+			_entry = append(_entry, make([]byte, _length-len(_entry))...)
+		}
+		_index = append(_index, _entry...)
+		_previous = _path
+	}
+
+	// add the extensions and the trailing checksum
+	_index = append(_index, extension...)
+	_sum := sha1.Sum(_index)
+	return append(_index, _sum[:]...)
+} // indexed()
+
+func varint(value int) []byte {
+	// encode the value using git's variable-length offset encoding
+	_buffer := []byte{byte(value & 0x7f)}
+	for value >>= 7; value != 0; value >>= 7 {
+		value--
+		_buffer = append([]byte{byte(0x80 | (value & 0x7f))}, _buffer...)
+	}
+	return _buffer
+} // varint()
+
+func ewah(bits ...int) []byte {
+	// encode the bitmap as a single running length word followed by the
+	// literal words containing the given bits
+	_words := 0
+	for _, _bit := range bits {
+		if _bit/64+1 > _words {
+			_words = _bit/64 + 1
+		}
+	}
+	_literals := make([]uint64, _words)
+	for _, _bit := range bits {
+		_literals[_bit/64] |= 1 << uint(_bit%64)
+	}
+
+	// write the bitmap header, words and running length word position
+	_bitmap := binary.BigEndian.AppendUint32(nil, uint32(_words*64))
+	_bitmap = binary.BigEndian.AppendUint32(_bitmap, uint32(_words+1))
+	_bitmap = binary.BigEndian.AppendUint64(_bitmap, uint64(_words)<<33)
+	for _, _literal := range _literals {
+		_bitmap = binary.BigEndian.AppendUint64(_bitmap, _literal)
+	}
+	return binary.BigEndian.AppendUint32(_bitmap, 0)
+} // ewah()
+
+type entry struct {
+	Mode string // tree entry mode
+	Name string // tree entry name
+	ID   []byte // tree entry object ID
+} // entry{}
+
+type packed struct {
+	Type int    // packfile object type
+	Data []byte // object content, or the delta against the base object
+	Base int    // index of the base object of an offset delta
+	Ref  []byte // object ID of the base object of a reference delta
+	ID   []byte // object ID of the resulting object
+} // packed{}
+
+func hashed(kind string, content []byte) []byte {
+	// the object ID is the hash of the object header and content
+	_header := fmt.Sprintf("%s %d\x00", kind, len(content))
+	_sum := sha1.Sum(append([]byte(_header), content...))
+	return _sum[:]
+} // hashed()
+
+func compressed(content []byte) []byte {
+	_buffer := new(bytes.Buffer)
+	_writer := zlib.NewWriter(_buffer)
+	_writer.Write(content)
+	_writer.Close()
+	return _buffer.Bytes()
+} // compressed()
+
+func loose(gitdir, kind string, content []byte) ([]byte, error) {
+	// loose objects are stored by their hexadecimal object ID
+	_id := hashed(kind, content)
+	_hex := hex.EncodeToString(_id)
+	_dir := filepath.Join(gitdir, "objects", _hex[:2])
+	_err := os.MkdirAll(_dir, _GITMASK)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// write the compressed object
+	_header := fmt.Sprintf("%s %d\x00", kind, len(content))
+	_content := compressed(append([]byte(_header), content...))
+	_err = ioutil.WriteFile(filepath.Join(_dir, _hex[2:]), _content, _GITMASK)
+	if _err != nil {
+		return nil, _err
+	}
+
+	return _id, nil
+} // loose()
+
+func tree(entries ...entry) []byte {
+	_tree := make([]byte, 0)
+	for _, _entry := range entries {
+		_tree = append(_tree, _entry.Mode+" "+_entry.Name+"\x00"...)
+		_tree = append(_tree, _entry.ID...)
+	}
+	return _tree
+} // tree()
+
+func deltified(source, target []byte) []byte {
+	// encode the source and target sizes
+	_size := func(size int) []byte {
+		_buffer := []byte{}
+		for size >= 0x80 {
+			_buffer = append(_buffer, byte(size&0x7f)|0x80)
+			size >>= 7
+		}
+		return append(_buffer, byte(size))
+	}
+	_delta := append(_size(len(source)), _size(len(target))...)
+
+	// copy the common prefix (of up to 255 bytes) from the source
+	_common := 0
+	for _common < len(source) && _common < len(target) && _common < 0xff &&
+		source[_common] == target[_common] {
+		_common++
+	}
+	if _common > 0 {
+		_delta = append(_delta, 0x90, byte(_common))
+	}
+
+	// insert the remainder of the target
+	for _rest := target[_common:]; len(_rest) > 0; {
+		_n := len(_rest)
+		if _n > 0x7f {
+			_n = 0x7f
+		}
+		_delta = append(_delta, byte(_n))
+		_delta = append(_delta, _rest[:_n]...)
+		_rest = _rest[_n:]
+	}
+
+	return _delta
+} // deltified()
+
+func pack(gitdir string, objects []packed, large bool) error {
+	// create the packfile header
+	_pack := []byte("PACK")
+	_pack = binary.BigEndian.AppendUint32(_pack, 2)
+	_pack = binary.BigEndian.AppendUint32(_pack, uint32(len(objects)))
+
+	// add the packfile objects
+	_offsets := make([]int, len(objects))
+	_crcs := make([]uint32, len(objects))
+	for _i, _object := range objects {
+		_offsets[_i] = len(_pack)
+
+		// encode the object type and size
+		_size := len(_object.Data)
+		_entry := []byte{byte(_object.Type<<4) | byte(_size&0x0f)}
+		for _size >>= 4; _size != 0; _size >>= 7 {
+			_entry[len(_entry)-1] |= 0x80
+			_entry = append(_entry, byte(_size&0x7f))
+		}
+
+		// add the delta base
+		switch _object.Type {
+		case 6:
+			_entry = append(_entry, varint(_offsets[_i]-_offsets[_object.Base])...)
+		case 7:
+			_entry = append(_entry, _object.Ref...)
+		}
+		_entry = append(_entry, compressed(_object.Data)...)
+		_crcs[_i] = crc32.ChecksumIEEE(_entry)
+		_pack = append(_pack, _entry...)
+	}
+	_sum := sha1.Sum(_pack)
+	_pack = append(_pack, _sum[:]...)
+
+	// sort the objects by object ID for the index
+	_order := make([]int, len(objects))
+	for _i := range _order {
+		_order[_i] = _i
+	}
+	sort.Slice(_order, func(i, j int) bool {
+		return bytes.Compare(objects[_order[i]].ID, objects[_order[j]].ID) < 0
+	})
+
+	// create the version 2 index
+	//		- if required, all offsets are stored in the large offset table
+	_idx := []byte("\xfftOc")
+	_idx = binary.BigEndian.AppendUint32(_idx, 2)
+	for _b := 0; _b < 256; _b++ {
+		_count := 0
+		for _, _object := range objects {
+			if int(_object.ID[0]) <= _b {
+				_count++
+			}
+		}
+		_idx = binary.BigEndian.AppendUint32(_idx, uint32(_count))
+	}
+	for _, _i := range _order {
+		_idx = append(_idx, objects[_i].ID...)
+	}
+	for _, _i := range _order {
+		_idx = binary.BigEndian.AppendUint32(_idx, _crcs[_i])
+	}
+	for _n, _i := range _order {
+		if large {
+			_idx = binary.BigEndian.AppendUint32(_idx, 0x80000000|uint32(_n))
+		} else {
+			_idx = binary.BigEndian.AppendUint32(_idx, uint32(_offsets[_i]))
+		}
+	}
+	if large {
+		for _, _i := range _order {
+			_idx = binary.BigEndian.AppendUint64(_idx, uint64(_offsets[_i]))
+		}
+	}
+	_idx = append(_idx, _sum[:]...)
+	_idxsum := sha1.Sum(_idx)
+	_idx = append(_idx, _idxsum[:]...)
+
+	// write the packfile and its index
+	_dir := filepath.Join(gitdir, "objects", "pack")
+	_err := os.MkdirAll(_dir, _GITMASK)
+	if _err != nil {
+		return _err
+	}
+	_name := filepath.Join(_dir, "pack-"+hex.EncodeToString(_sum[:]))
+	_err = ioutil.WriteFile(_name+".pack", _pack, _GITMASK)
+	if _err != nil {
+		return _err
+	}
+	return ioutil.WriteFile(_name+".idx", _idx, _GITMASK)
+} // pack()
+
+func generated(patterns, paths int) (string, []string) {
+	// generate the patterns of a large .gitignore file
+	//		- the patterns cover each kind of pattern, with and without
+	//		  wildcards
+	_templates := []string{
+		"*.ext%d", "dir%d/", "/root%d/*.o", "**/cache%d/**", "file%d.[ch]",
+		"!keep%d.log", "src/**/*.gen%d", "doc%d/*.txt", "[Tt]emp%d*",
+		"build%d", "vendor/mod%d/", "?%d.tmp",
+	}
+	_content := make([]string, 0, patterns)
+	for _i := 0; _i < patterns; _i++ {
+		_template := _templates[_i%len(_templates)]
+		_content = append(_content, fmt.Sprintf(_template, _i))
+	}
+
+	// generate the paths to match against the patterns
+	_paths := make([]string, 0, paths)
+	for _i := 0; _i < paths; _i++ {
+		_path := fmt.Sprintf(
+			"src/module%d/dir%d/file%d.ext%d",
+			_i%7, _i%patterns, _i, _i%(patterns/2+1),
+		)
+		_paths = append(_paths, _path)
+	}
+
+	return strings.Join(_content, "\n") + "\n", _paths
+} // generated()
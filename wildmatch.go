@@ -0,0 +1,360 @@
+package gitignore
+
+import (
+	"strings"
+)
+
+// WildmatchFlag defines the flags controlling the behaviour of Wildmatch
+type WildmatchFlag int
+
+// define the Wildmatch flags, as defined by git
+const (
+	// WM_CASEFOLD performs case-insensitive matching of ASCII letters
+	WM_CASEFOLD WildmatchFlag = 1 << iota
+
+	// WM_PATHNAME prevents "*", "?" and character classes from matching
+	// "/", and gives "**" special meaning as a complete path component
+	WM_PATHNAME
+
+	// _WMRSYNC follows the wildmatch() of rsync, where "**" matches any
+	// sequence of characters, including "/", wherever it appears, and
+	// "[:space:]" includes "\v" and "\f"
+	_WMRSYNC
+)
+
+// define the results of wildmatch, as defined by git
+const (
+	_WMNOMATCH = iota
+	_WMMATCH
+	_WMABORTALL
+	_WMABORTTOSTARSTAR
+)
+
+// define the characters with special meaning in wildmatch patterns
+const _WMSPECIAL = "*?[\\"
+
+// Wildmatch returns true if text is matched by the pattern, following the
+// rules of git's wildmatch(), which is used by git to match the patterns of
+// .gitignore files and pathspecs. Matching is performed byte by byte, where
+//
+//   - "?" matches any character, and "*" any sequence of characters
+//   - "[...]" matches a character class, which may be negated by a leading
+//     "!" or "^", and may contain ranges (e.g. "a-z"), the POSIX classes
+//     "[:alnum:]", "[:alpha:]", "[:blank:]", "[:cntrl:]", "[:digit:]",
+//     "[:graph:]", "[:lower:]", "[:print:]", "[:punct:]", "[:space:]",
+//     "[:upper:]" and "[:xdigit:]", and "]" as its first character
+//   - "\" matches the following character literally
+//
+// flags may combine WM_PATHNAME, so that wildcards do not match "/" and
+// "**" matches any number of complete path components, and WM_CASEFOLD,
+// for case-insensitive matching.
+func Wildmatch(pattern, text string, flags WildmatchFlag) bool {
+	return wildmatch(pattern, text, flags) == _WMMATCH
+} // Wildmatch()
+
+// wildmatch returns the result of matching text against pattern with the
+// given flags. This is a port of dowild() from git's wildmatch.c.
+func wildmatch(pattern, text string, flags WildmatchFlag) int {
+	_p, _t := 0, 0
+	for ; _p < len(pattern); _p, _t = _p+1, _t+1 {
+		_pch := pattern[_p]
+		if _t == len(text) && _pch != '*' {
+			return _WMABORTALL
+		}
+		var _tch byte
+		if _t < len(text) {
+			_tch = text[_t]
+		}
+		if flags&WM_CASEFOLD != 0 {
+			_tch = wmlower(_tch)
+			_pch = wmlower(_pch)
+		}
+
+		switch _pch {
+		case '\\':
+			// literal match with the following character
+			//		- a trailing "\" fails to match
+			//		- as with git, the escaped character is not folded
+			_p++
+			if _p == len(pattern) {
+				return _WMNOMATCH
+			}
+			_pch = pattern[_p]
+			if _tch != _pch {
+				return _WMNOMATCH
+			}
+
+		case '?':
+			// match anything but "/"
+			if flags&WM_PATHNAME != 0 && _tch == '/' {
+				return _WMNOMATCH
+			}
+
+		case '*':
+			_matchslash := false
+			_p++
+			if _p < len(pattern) && pattern[_p] == '*' {
+				_prev := _p - 2
+				for _p < len(pattern) && pattern[_p] == '*' {
+					_p++
+				}
+				if flags&WM_PATHNAME == 0 || flags&_WMRSYNC != 0 {
+					// without WM_PATHNAME, "*" == "**", while rsync does
+					// not require "**" to be a complete path component
+					_matchslash = true
+				} else if (_prev < 0 || pattern[_prev] == '/') &&
+					(_p == len(pattern) || pattern[_p] == '/' ||
+						(pattern[_p] == '\\' && _p+1 < len(pattern) && pattern[_p+1] == '/')) {
+					// "**/" may match no directories, so attempt to match
+					// the remainder of the pattern here
+					if _p < len(pattern) && pattern[_p] == '/' &&
+						wildmatch(pattern[_p+1:], text[_t:], flags) == _WMMATCH {
+						return _WMMATCH
+					}
+					_matchslash = true
+				}
+			} else {
+				// without WM_PATHNAME, "*" == "**"
+				_matchslash = flags&WM_PATHNAME == 0
+			}
+
+			if _p == len(pattern) {
+				// a trailing "**" matches everything, while a trailing "*"
+				// only matches if there are no more separators
+				if !_matchslash && strings.IndexByte(text[_t:], '/') != -1 {
+					return _WMNOMATCH
+				}
+				return _WMMATCH
+			} else if !_matchslash && pattern[_p] == '/' {
+				// a single "*" followed by a separator matches the next
+				// directory
+				//		- the separator is consumed by the loop
+				_slash := strings.IndexByte(text[_t:], '/')
+				if _slash == -1 {
+					return _WMNOMATCH
+				}
+				_t += _slash
+				continue
+			}
+
+			for {
+				if _t == len(text) {
+					break
+				}
+
+				// advance to the next occurrence of a literal following the
+				// "*", without looking past a separator if "*" cannot match
+				// a separator
+				if strings.IndexByte(_WMSPECIAL, pattern[_p]) == -1 {
+					_pch = pattern[_p]
+					if flags&WM_CASEFOLD != 0 {
+						_pch = wmlower(_pch)
+					}
+					for _t < len(text) {
+						_tch = text[_t]
+						if !_matchslash && _tch == '/' {
+							break
+						} else if flags&WM_CASEFOLD != 0 {
+							_tch = wmlower(_tch)
+						}
+						if _tch == _pch {
+							break
+						}
+						_t++
+					}
+					if _t == len(text) || _tch != _pch {
+						return _WMNOMATCH
+					}
+				}
+
+				_matched := wildmatch(pattern[_p:], text[_t:], flags)
+				if _matched != _WMNOMATCH {
+					if !_matchslash || _matched != _WMABORTTOSTARSTAR {
+						return _matched
+					}
+				} else if !_matchslash && text[_t] == '/' {
+					return _WMABORTTOSTARSTAR
+				}
+				_t++
+			}
+			return _WMABORTALL
+
+		case '[':
+			_end, _matched, _abort := wmclass(pattern, _p+1, _tch, flags)
+			if _abort {
+				return _WMABORTALL
+			} else if !_matched || (flags&WM_PATHNAME != 0 && _tch == '/') {
+				return _WMNOMATCH
+			}
+			_p = _end
+
+		default:
+			if _tch != _pch {
+				return _WMNOMATCH
+			}
+		}
+	}
+
+	if _t < len(text) {
+		return _WMNOMATCH
+	}
+	return _WMMATCH
+} // wildmatch()
+
+// wmclass matches the character ch against the character class of pattern
+// starting at the index start (i.e. following the opening "["). wmclass
+// returns the index of the closing "]" of the class, and whether ch is
+// matched. If the class is malformed, abort is true.
+func wmclass(pattern string, start int, ch byte, flags WildmatchFlag) (end int, matched bool, abort bool) {
+	// next returns the byte of the pattern at index i, or 0 if i is beyond
+	// the end of the pattern
+	next := func(i int) byte {
+		if i < len(pattern) {
+			return pattern[i]
+		}
+		return 0
+	}
+
+	_p := start
+	_pch := next(_p)
+	_negated := false
+	if _pch == '!' || _pch == '^' {
+		_negated = true
+		_p++
+		_pch = next(_p)
+	}
+
+	var _prev byte
+	for {
+		if _pch == 0 {
+			return _p, false, true
+		}
+
+		switch {
+		case _pch == '\\':
+			_p++
+			_pch = next(_p)
+			if _pch == 0 {
+				return _p, false, true
+			}
+			if ch == _pch {
+				matched = true
+			}
+
+		case _pch == '-' && _prev != 0 && next(_p+1) != 0 && next(_p+1) != ']':
+			_p++
+			_pch = next(_p)
+			if _pch == '\\' {
+				_p++
+				_pch = next(_p)
+				if _pch == 0 {
+					return _p, false, true
+				}
+			}
+			if ch <= _pch && ch >= _prev {
+				matched = true
+			} else if flags&WM_CASEFOLD != 0 && ch >= 'a' && ch <= 'z' {
+				_upper := ch - 'a' + 'A'
+				if _upper <= _pch && _upper >= _prev {
+					matched = true
+				}
+			}
+			_pch = 0
+
+		case _pch == '[' && next(_p+1) == ':':
+			// find the end of the POSIX class
+			_s := _p + 2
+			_p = _s
+			for _p < len(pattern) && pattern[_p] != ']' {
+				_p++
+			}
+			if _p == len(pattern) {
+				return _p, false, true
+			}
+			_name := pattern[_s:_p]
+			if len(_name) == 0 || _name[len(_name)-1] != ':' {
+				// without a closing ":]" this is a normal "["
+				_p = _s - 2
+				_pch = '['
+				if ch == _pch {
+					matched = true
+				}
+				break
+			}
+
+			_ok, _valid := wmposix(_name[:len(_name)-1], ch, flags)
+			if !_valid {
+				return _p, false, true
+			} else if _ok {
+				matched = true
+			}
+			_pch = 0
+
+		case ch == _pch:
+			matched = true
+		}
+
+		// move to the next character of the class
+		_prev = _pch
+		_p++
+		_pch = next(_p)
+		if _pch == ']' {
+			break
+		}
+	}
+
+	return _p, matched != _negated, false
+} // wmclass()
+
+// wmposix returns true if the character c is a member of the POSIX
+// character class name (e.g. "alpha"), using git's locale-independent
+// classes. With WM_CASEFOLD, lower case letters are also members of "upper".
+// If name is not a valid class, wmposix returns false for its second value.
+func wmposix(name string, c byte, flags WildmatchFlag) (bool, bool) {
+	_upper := c >= 'A' && c <= 'Z'
+	_lower := c >= 'a' && c <= 'z'
+	_digit := c >= '0' && c <= '9'
+	_graph := c > ' ' && c < 0x7f
+
+	switch name {
+	case "alnum":
+		return _upper || _lower || _digit, true
+	case "alpha":
+		return _upper || _lower, true
+	case "blank":
+		return c == ' ' || c == '\t', true
+	case "cntrl":
+		return c < ' ' || c == 0x7f, true
+	case "digit":
+		return _digit, true
+	case "graph":
+		return _graph, true
+	case "lower":
+		return _lower, true
+	case "print":
+		return _graph || c == ' ', true
+	case "punct":
+		return _graph && !_upper && !_lower && !_digit, true
+	case "space":
+		// git does not consider "\v" or "\f" to be whitespace, while
+		// rsync does
+		if flags&_WMRSYNC != 0 {
+			return c == ' ' || (c >= '\t' && c <= '\r'), true
+		}
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r', true
+	case "upper":
+		return _upper || (flags&WM_CASEFOLD != 0 && _lower), true
+	case "xdigit":
+		return _digit || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'), true
+	}
+
+	return false, false
+} // wmposix()
+
+// wmlower returns the lower case of the ASCII character ch.
+func wmlower(ch byte) byte {
+	if ch >= 'A' && ch <= 'Z' {
+		return ch - 'A' + 'a'
+	}
+	return ch
+} // wmlower()
@@ -0,0 +1,45 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denormal/go-gitignore"
+)
+
+func TestWildmatch(t *testing.T) {
+	for _, _test := range _WILDMATCHTESTS {
+		// ensure the text is matched with each combination of flags
+		for _flags, _expected := range map[gitignore.WildmatchFlag]bool{
+			gitignore.WM_PATHNAME:                         _test.Glob,
+			gitignore.WM_PATHNAME | gitignore.WM_CASEFOLD: _test.IGlob,
+			0:                     _test.Path,
+			gitignore.WM_CASEFOLD: _test.IPath,
+		} {
+			_matched := gitignore.Wildmatch(_test.Pattern, _test.Text, _flags)
+			if _matched != _expected {
+				t.Errorf(
+					"%q: unexpected match for %q with flags %d; expected %v, got %v",
+					_test.Pattern, _test.Text, _flags, _expected, _matched,
+				)
+			}
+		}
+	}
+} // TestWildmatch()
+
+func TestWildmatchPattern(t *testing.T) {
+	// ensure .gitignore patterns use the wildmatch syntax
+	_content := "[[:digit:]]*.log\n[!a-z]?.tmp\n[]]x\n\\!important\n"
+	_ignore := gitignore.New(strings.NewReader(_content), "/base", nil)
+	for _, _match := range []match{
+		{"1.log", "[[:digit:]]*.log", true, false},
+		{"dir/2-error.log", "[[:digit:]]*.log", true, false},
+		{"a.log", "", false, false},
+		{"A1.tmp", "[!a-z]?.tmp", true, false},
+		{"ab.tmp", "", false, false},
+		{"]x", "[]]x", true, false},
+		{"!important", "\\!important", true, false},
+	} {
+		do(t, _ignore.Relative, _match)
+	}
+} // TestWildmatchPattern()